@@ -0,0 +1,165 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	imgManip "github.com/Ares1605/ascii-image-converter-wasm/image_manipulation"
+)
+
+/*
+AsciicastWriter writes frames of ascii art as an asciicast v2 recording, which asciinema can play and
+publish. The recording is made of JSON lines: a header with the terminal's size, followed by an output
+event for each frame, timestamped with the total delay of the frames before it.
+
+Each frame is drawn over the previous one by moving the cursor home, like playback on a terminal.
+*/
+type AsciicastWriter struct {
+	w       io.Writer
+	elapsed time.Duration
+	frames  int
+}
+
+// Header line of an asciicast v2 recording
+type asciicastHeader struct {
+	Version int `json:"version"`
+	Width   int `json:"width"`
+	Height  int `json:"height"`
+}
+
+// NewAsciicastWriter() writes the header of a recording for a terminal of the given size in characters
+// to w, and returns a writer for its frames
+func NewAsciicastWriter(w io.Writer, width, height int) (*AsciicastWriter, error) {
+	if width < 1 || height < 1 {
+		return nil, fmt.Errorf("%w: asciicast size must be positive, got %vx%v", ErrInvalidDimensions, width, height)
+	}
+
+	header, err := json.Marshal(asciicastHeader{Version: 2, Width: width, Height: height})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := fmt.Fprintf(w, "%s\n", header); err != nil {
+		return nil, err
+	}
+
+	return &AsciicastWriter{w: w}, nil
+}
+
+// WriteFrame() writes a frame of ascii art, which is displayed for delay before the next one
+func (c *AsciicastWriter) WriteFrame(ascii string, delay time.Duration) error {
+	prefix := cursorHome
+	if c.frames == 0 {
+		prefix = hideCursor + eraseScreen + cursorHome
+	}
+
+	// Recordings hold the raw output sent to the terminal, where "\n" alone doesn't return the cursor.
+	// Lines already ended by "\r\n" are left as they are
+	lines := strings.ReplaceAll(strings.ReplaceAll(ascii, "\r\n", "\n"), "\n", "\r\n")
+	if err := c.writeEvent(prefix + lines); err != nil {
+		return err
+	}
+
+	c.elapsed += delay
+	c.frames++
+	return nil
+}
+
+// Close() writes a final event showing the cursor again once the last frame's delay has passed, so the
+// last frame is displayed for its delay too. It doesn't close the underlying writer
+func (c *AsciicastWriter) Close() error {
+	if c.frames == 0 {
+		return c.writeEvent("")
+	}
+	return c.writeEvent(showCursor)
+}
+
+func (c *AsciicastWriter) writeEvent(data string) error {
+	// Timestamps are in seconds, with microsecond precision
+	seconds := math.Round(c.elapsed.Seconds()*1e6) / 1e6
+
+	event, err := json.Marshal([]any{seconds, "o", data})
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(c.w, "%s\n", event)
+	return err
+}
+
+/*
+ConvertAsciicast() writes the ascii art of the passed image or animation to w as an asciicast v2
+recording. The recording's size is taken from the ascii art, and each frame of an animation is an
+event timed by the delays of the frames before it. The animation is recorded once, regardless of its
+loop count. A still image is recorded as a single frame.
+*/
+func ConvertAsciicast(w io.Writer, inputBytes []byte, flags Flags) error {
+	flags.JsonOutput = false
+	c, err := parseMetadata(inputBytes, flags)
+	if err != nil {
+		return err
+	}
+
+	if !c.inputIsAnimated {
+		asciiSet, err := pathIsImage(c, inputBytes, func(asciiSet [][]imgManip.AsciiChar, colored bool) [][]imgManip.AsciiChar {
+			return asciiSet
+		})
+		if err != nil {
+			return err
+		}
+		if len(asciiSet) == 0 {
+			return fmt.Errorf("%w: ascii art is empty", ErrInvalidDimensions)
+		}
+
+		cast, err := NewAsciicastWriter(w, len(asciiSet[0]), len(asciiSet))
+		if err != nil {
+			return err
+		}
+		if err := cast.WriteFrame(c.flattenToAscii(asciiSet, c.colored || c.grayscale), 0); err != nil {
+			return err
+		}
+		return cast.Close()
+	}
+
+	// The header is written with the first frame, once the size of the ascii art is known
+	var cast *AsciicastWriter
+
+	err = c.convertAnimation(inputBytes, func(frame asciiFrame) error {
+		if cast == nil {
+			var err error
+			if cast, err = NewAsciicastWriter(w, frame.width, frame.height); err != nil {
+				return err
+			}
+		}
+
+		return cast.WriteFrame(frame.Ascii, frame.Delay)
+	})
+	if err != nil {
+		return err
+	}
+
+	if cast == nil {
+		return fmt.Errorf("%w: animation has no frames", ErrInvalidDimensions)
+	}
+	return cast.Close()
+}
@@ -0,0 +1,226 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// An output event of an asciicast v2 recording
+type asciicastEvent struct {
+	time float64
+	data string
+}
+
+// Parses a recording line by line as asciicast v2, returning its header's fields and its events
+func parseAsciicast(t *testing.T, recording string) (map[string]any, []asciicastEvent) {
+	t.Helper()
+
+	if !strings.HasSuffix(recording, "\n") {
+		t.Fatalf("recording doesn't end with a newline: %q", recording)
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(recording))
+	if !scanner.Scan() {
+		t.Fatal("recording has no header")
+	}
+
+	var header map[string]any
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		t.Fatalf("header %q isn't a JSON object: %v", scanner.Text(), err)
+	}
+
+	var events []asciicastEvent
+	for scanner.Scan() {
+		var fields []any
+		if err := json.Unmarshal(scanner.Bytes(), &fields); err != nil {
+			t.Fatalf("event %q isn't a JSON array: %v", scanner.Text(), err)
+		}
+
+		time, timeOk := fields[0].(float64)
+		kind, kindOk := fields[1].(string)
+		data, dataOk := fields[2].(string)
+		if len(fields) != 3 || !timeOk || !dataOk || !kindOk || kind != "o" {
+			t.Fatalf("event %q isn't [time, \"o\", data]", scanner.Text())
+		}
+
+		if len(events) > 0 && time < events[len(events)-1].time {
+			t.Fatalf("event at %v follows one at %v", time, events[len(events)-1].time)
+		}
+		events = append(events, asciicastEvent{time, data})
+	}
+
+	return header, events
+}
+
+// Checks the header of a recording for a terminal of the passed size
+func checkAsciicastHeader(t *testing.T, header map[string]any, width, height int) {
+	t.Helper()
+
+	want := map[string]any{"version": 2.0, "width": float64(width), "height": float64(height)}
+	if len(header) != len(want) {
+		t.Errorf("got header %v, want %v", header, want)
+	}
+	for key, value := range want {
+		if header[key] != value {
+			t.Errorf("got header %v, want %v", header, want)
+		}
+	}
+}
+
+func TestAsciicastWriter(t *testing.T) {
+	var output bytes.Buffer
+
+	cast, err := NewAsciicastWriter(&output, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frames := []struct {
+		ascii string
+		delay time.Duration
+	}{
+		{"abc\ndef", 100 * time.Millisecond},
+		{"ghi\njkl", time.Second / 3},
+		{"mno\r\npqr", 1500 * time.Millisecond},
+	}
+	for _, frame := range frames {
+		if err := cast.WriteFrame(frame.ascii, frame.delay); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cast.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	header, events := parseAsciicast(t, output.String())
+	checkAsciicastHeader(t, header, 3, 2)
+
+	// Timestamps add up the delays of the frames before them, rounded to microseconds
+	want := []asciicastEvent{
+		{0, hideCursor + eraseScreen + cursorHome + "abc\r\ndef"},
+		{0.1, cursorHome + "ghi\r\njkl"},
+		{0.433333, cursorHome + "mno\r\npqr"},
+		{1.933333, showCursor},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("got %v events, want %v", len(events), len(want))
+	}
+	for i, event := range events {
+		if math.Abs(event.time-want[i].time) > 1e-9 || event.data != want[i].data {
+			t.Errorf("event %v is %v %q, want %v %q", i, event.time, event.data, want[i].time, want[i].data)
+		}
+	}
+}
+
+func TestAsciicastWriterWithoutFrames(t *testing.T) {
+	var output bytes.Buffer
+
+	cast, err := NewAsciicastWriter(&output, 80, 24)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cast.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The cursor was never hidden, so there's nothing to restore
+	header, events := parseAsciicast(t, output.String())
+	checkAsciicastHeader(t, header, 80, 24)
+	if len(events) != 1 || events[0] != (asciicastEvent{0, ""}) {
+		t.Errorf("got events %v, want a single empty one", events)
+	}
+
+	if _, err := NewAsciicastWriter(&output, 0, 24); !errors.Is(err, ErrInvalidDimensions) {
+		t.Errorf("got error %v for a width of 0, want ErrInvalidDimensions", err)
+	}
+}
+
+func TestConvertAsciicast(t *testing.T) {
+	palette := color.Palette{color.Black, color.White}
+	var frames []*image.Paletted
+	for i := range 3 {
+		frame := image.NewPaletted(image.Rect(0, 0, 8, 8), palette)
+		frame.SetColorIndex(i, i, 1)
+		frames = append(frames, frame)
+	}
+
+	var animation bytes.Buffer
+	if err := gif.EncodeAll(&animation, &gif.GIF{Image: frames, Delay: []int{10, 20, 30}, LoopCount: 0}); err != nil {
+		t.Fatal(err)
+	}
+
+	var still bytes.Buffer
+	if err := png.Encode(&still, frames[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		input []byte
+		times []float64
+	}{
+		{"animation", animation.Bytes(), []float64{0, 0.1, 0.3, 0.6}},
+		{"still image", still.Bytes(), []float64{0, 0}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			flags := DefaultFlags()
+			flags.Dimensions = []int{6, 3}
+
+			var output bytes.Buffer
+			if err := ConvertAsciicast(&output, test.input, flags); err != nil {
+				t.Fatal(err)
+			}
+
+			header, events := parseAsciicast(t, output.String())
+			checkAsciicastHeader(t, header, 6, 3)
+
+			if len(events) != len(test.times) {
+				t.Fatalf("got %v events, want %v", len(events), len(test.times))
+			}
+			for i, event := range events {
+				if math.Abs(event.time-test.times[i]) > 1e-9 {
+					t.Errorf("event %v is at %v, want %v", i, event.time, test.times[i])
+				}
+
+				// Frames are 3 lines of 6 characters, with lines ended for a raw terminal
+				if i < len(events)-1 {
+					lines := strings.Split(strings.TrimPrefix(strings.TrimPrefix(event.data, hideCursor+eraseScreen), cursorHome), "\r\n")
+					if len(lines) != 3 || len(lines[0]) != 6 || strings.Count(event.data, "\n") != 2 {
+						t.Errorf("frame %v is %q, want 3 lines of 6 characters ended by \\r\\n", i, event.data)
+					}
+				}
+			}
+			if last := events[len(events)-1]; last.data != showCursor {
+				t.Errorf("last event is %q, want the cursor shown again", last.data)
+			}
+		})
+	}
+}
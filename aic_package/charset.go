@@ -0,0 +1,109 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"fmt"
+	"image"
+	"sort"
+	"strings"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// Font size in points characters are drawn at to measure their coverage
+const coverageFontSize = 32
+
+// CharCoverage is a character of a character map, along with how much of its cell is covered by ink
+type CharCoverage struct {
+	Char string
+
+	// Share of the character's cell covered by ink, from 0 for a space to 1 for a filled cell
+	Coverage float64
+}
+
+/*
+MeasureCharMap() draws each character of charMap in a monospaced cell, with the same font ConvertPNG()
+uses, and measures how much of the cell it covers. Characters are returned in the order of charMap.
+
+Ascii art maps the darkest pixels onto the first character of the map, which shows the least ink on a
+dark terminal, so a map reads best when coverage increases along it. See SortCharMap()
+*/
+func MeasureCharMap(charMap string) ([]CharCoverage, error) {
+	if charMap == "" {
+		return nil, fmt.Errorf("character map is empty")
+	}
+
+	parsedFont, err := truetype.Parse(asciiFontBytes)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse font: %v", err)
+	}
+
+	face := truetype.NewFace(parsedFont, &truetype.Options{Size: coverageFontSize, Hinting: font.HintingFull})
+	defer face.Close()
+
+	advance, ok := face.GlyphAdvance('W')
+	if !ok {
+		return nil, fmt.Errorf("font has no glyph for measuring character width")
+	}
+
+	metrics := face.Metrics()
+	cell := image.Rect(0, 0, advance.Ceil(), metrics.Height.Ceil())
+
+	var coverages []CharCoverage
+
+	for _, char := range charMap {
+		canvas := image.NewAlpha(cell)
+
+		drawer := font.Drawer{Dst: canvas, Src: image.Opaque, Face: face, Dot: fixed.P(0, metrics.Ascent.Ceil())}
+		drawer.DrawString(string(char))
+
+		ink := 0
+		for _, alpha := range canvas.Pix {
+			ink += int(alpha)
+		}
+
+		coverages = append(coverages, CharCoverage{
+			Char:     string(char),
+			Coverage: float64(ink) / float64(len(canvas.Pix)*255),
+		})
+	}
+
+	return coverages, nil
+}
+
+// SortCharMap() orders the characters of charMap from least to most ink, as measured by MeasureCharMap(),
+// which maps them from the darkest to the lightest pixels. Characters with the same coverage keep their order
+func SortCharMap(charMap string) (string, error) {
+	coverages, err := MeasureCharMap(charMap)
+	if err != nil {
+		return "", err
+	}
+
+	sort.SliceStable(coverages, func(i, j int) bool {
+		return coverages[i].Coverage < coverages[j].Coverage
+	})
+
+	var sorted strings.Builder
+	for _, coverage := range coverages {
+		sorted.WriteString(coverage.Char)
+	}
+
+	return sorted.String(), nil
+}
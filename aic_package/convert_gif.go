@@ -17,138 +17,231 @@ limitations under the License.
 package aic_package
 
 import (
-	"bytes"
+	"errors"
 	"fmt"
-	"image"
-	"image/gif"
+	"io"
 	"os"
 	"runtime"
-	"strconv"
-	"sync"
-	"time"
+	"unsafe"
 
+	"github.com/Ares1605/ascii-image-converter-wasm/image_formats"
 	imgManip "github.com/Ares1605/ascii-image-converter-wasm/image_manipulation"
 )
 
-type GifFrame struct {
-	asciiCharSet [][]imgManip.AsciiChar
-	delay        int
+/*
+PlayAnimation() plays animated input (GIF, APNG or animated WebP) on the terminal like Convert() does.
+If stdout and the controlling terminal allow it, playback can also be controlled with the keyboard,
+see EnterInteractivePlayback(). The last frame shown is left on the terminal once playback ends.
+*/
+func PlayAnimation(inputBytes []byte, flags Flags) error {
+	flags.JsonOutput = false
+	c, err := parseMetadata(inputBytes, flags)
+	if err != nil {
+		return err
+	}
+	if !c.inputIsAnimated {
+		return errors.New("input is not animated")
+	}
+
+	return c.pathIsAnimation(inputBytes, true)
 }
 
 /*
-This function grabs each image frame from passed gif and turns it into ascii art. If SaveGifPath flag is passed,
-it'll turn each ascii art into an image instance of the same dimensions as the original gif and save them
-as an ascii art gif.
+This function grabs each frame from the passed animation (GIF, APNG or animated WebP) and turns it into
+ascii art, which is then played back in real time on the terminal's alternate screen with Play(). If
+interactive is set, playback is controlled with keys pressed when possible.
 
-Multi-threading has been implemented in multiple places due to long execution time
+Frames are decoded and handed to Play() as they're converted, so playback starts without waiting for
+the whole animation, and only a few frames ahead of playback are held in memory. Frames that don't fit
+in Play()'s cache are decoded again for each loop
 */
-func pathIsGif(inputBytes []byte) error {
+func (c *converter) pathIsAnimation(inputBytes []byte, interactive bool) error {
+
+	// The layout is read up front, so invalid input is reported before the screen is switched
+	decoder, err := newAnimationDecoder(inputBytes)
+	if err != nil {
+		return err
+	}
 
 	var (
-		originalGif *gif.GIF
-		err         error
+		controls <-chan PlaybackControl
+		restore  func()
 	)
+	if interactive {
+		// Without a terminal to read keys from, the animation is played back as usual
+		controls, restore, err = EnterInteractivePlayback(os.Stdout)
+	}
+	if !interactive || err != nil {
+		restore = EnterPlaybackScreen(os.Stdout)
+	}
+
+	options := PlaybackOptions{
+		LoopCount: decoder.LoopCount,
+		Controls:  controls,
+		Colored:   c.colored || c.grayscale,
+		Negative:  c.negative,
+	}
+
+	// The last frame is printed again once the terminal's screen is restored, so it stays visible
+	lastFrame, err := play(os.Stdout, options, func(emit func(frame PlaybackFrame) error) error {
+		return c.convertAnimation(inputBytes, func(frame asciiFrame) error {
+			return emit(frame.PlaybackFrame)
+		})
+	})
 
-	originalGif, err = gif.DecodeAll(bytes.NewReader(inputBytes))
+	restore()
 	if err != nil {
-		return fmt.Errorf("Can't decode input: %v", err)
+		return err
 	}
 
-	var (
-		asciiArtSet    = make([]string, len(originalGif.Image))
-		gifFramesSlice = make([]GifFrame, len(originalGif.Image))
+	fmt.Println(lastFrame)
+	return nil
+}
 
-		counter             = 0
-		concurrentProcesses = 0
-		wg                  sync.WaitGroup
-		hostCpuCount        = runtime.NumCPU()
-	)
+// Passed in place of a frame once every frame has been converted
+var errNoMoreFrames = errors.New("no more frames")
 
-	fmt.Printf("Generating ascii art... 0%%\r")
+// A converted animation frame, along with the size of its ascii art in characters
+type asciiFrame struct {
+	PlaybackFrame
+	width, height int
+}
 
-	// Get first frame of gif and its dimensions
-	firstGifFrame := originalGif.Image[0].SubImage(originalGif.Image[0].Rect)
-	firstGifFrameWidth := firstGifFrame.Bounds().Dx()
-	firstGifFrameHeight := firstGifFrame.Bounds().Dy()
+// Reads the layout of an animation, without decoding its frames
+func newAnimationDecoder(inputBytes []byte) (*image_formats.AnimationDecoder, error) {
+	decoder, err := image_formats.NewAnimationDecoder(inputBytes)
+	if err != nil {
+		format, _ := image_formats.Sniff(inputBytes)
+		return nil, &DecodeError{Format: format.Name, Err: err}
+	}
+	return decoder, nil
+}
 
-	// Multi-threaded loop to decrease execution time
-	for i, frame := range originalGif.Image {
+/*
+Decodes the frames of an animation and converts them, passing them to emit in order. Frames are
+converted concurrently, up to the host's CPU count at a time, and each is passed on as soon as it and
+the ones before it are ready. Frames are only decoded once there's room for their conversion, so
+decoding doesn't run ahead of emit either
+*/
+func (c *converter) convertAnimation(inputBytes []byte, emit func(frame asciiFrame) error) error {
 
-		wg.Add(1)
-		concurrentProcesses++
+	decoder, err := newAnimationDecoder(inputBytes)
+	if err != nil {
+		return err
+	}
 
-		go func(i int, frame *image.Paletted) {
+	type result struct {
+		frame asciiFrame
+		err   error
+	}
 
-			frameImage := frame.SubImage(frame.Rect)
+	// Conversions in flight, in frame order. The capacity limits them to the host's CPU count
+	pending := make(chan chan result, runtime.NumCPU())
+	stop := make(chan struct{})
+	defer close(stop)
 
-			// If a frame is found that is smaller than the first frame, then this gif contains smaller subimages that are
-			// positioned inside the original gif. This behavior isn't supported by this app
-			if firstGifFrameWidth != frameImage.Bounds().Dx() || firstGifFrameHeight != frameImage.Bounds().Dy() {
-				fmt.Printf("Error: Input contains subimages smaller than default width and height\n\nProcess aborted because ascii-image-converter doesn't support subimage placement and transparency in GIFs\n\n")
-				os.Exit(0)
-			}
+	go func() {
+		defer close(pending)
 
-			var imgSet [][]imgManip.AsciiPixel
+		for {
+			converted := make(chan result, 1)
 
-			imgSet, err = imgManip.ConvertToAsciiPixels(frameImage, dimensions, width, height, flipX, flipY, braille, dither)
-			if err != nil {
-				fmt.Printf("Error: %v\n", err)
-				os.Exit(0)
+			select {
+			case pending <- converted:
+			case <-stop:
+				return
 			}
 
-			var asciiCharSet [][]imgManip.AsciiChar
-			if braille {
-				asciiCharSet, err = imgManip.ConvertToBrailleChars(imgSet, negative, colored, grayscale, colorBg, fontColor, threshold, colorLevel)
-			} else {
-				asciiCharSet, err = imgManip.ConvertToAsciiChars(imgSet, negative, colored, grayscale, complex, colorBg, customMap, fontColor, colorLevel)
-			}
-			if err != nil {
-				fmt.Printf("Error: %v\n", err)
-				os.Exit(0)
+			// Frames are composited onto the previous ones, so they're decoded in order
+			frame, err := decoder.Next()
+			if err == io.EOF {
+				converted <- result{err: errNoMoreFrames}
+				return
+			} else if err != nil {
+				format, _ := image_formats.Sniff(inputBytes)
+				converted <- result{err: &DecodeError{Format: format.Name, Err: err}}
+				return
 			}
 
-			gifFramesSlice[i].asciiCharSet = asciiCharSet
-			gifFramesSlice[i].delay = originalGif.Delay[i]
+			go func(frame image_formats.Frame) {
+				asciiFrame, err := c.convertAnimationFrame(frame)
+				converted <- result{asciiFrame, err}
+			}(frame)
+		}
+	}()
+
+	for converted := range pending {
+		converted := <-converted
+		if converted.err == errNoMoreFrames {
+			return nil
+		} else if converted.err != nil {
+			return converted.err
+		}
 
-			asciiArtSet[i] = flattenToAscii(asciiCharSet, colored || grayscale)
+		if err := emit(converted.frame); err != nil {
+			return err
+		}
+	}
 
-			counter++
-			percentage := int((float64(counter) / float64(len(originalGif.Image))) * 100)
-			fmt.Printf("Generating ascii art... " + strconv.Itoa(percentage) + "%%\r")
+	return nil
+}
 
-			wg.Done()
+// Converts a single animation frame to ascii art, which can be rendered again with other colors
+func (c *converter) convertAnimationFrame(frame image_formats.Frame) (asciiFrame, error) {
 
-		}(i, frame)
+	// Frames are composited onto the whole canvas, so they all share the same dimensions
+	frameImage, err := c.prepareImage(frame.Image)
+	if err != nil {
+		return asciiFrame{}, err
+	}
 
-		// Limit concurrent processes according to host's CPU count to avoid overwhelming memory
-		if concurrentProcesses == hostCpuCount {
-			wg.Wait()
-			concurrentProcesses = 0
-		}
+	imgSet, err := imgManip.ConvertToAsciiPixels(frameImage, c.dimensions, c.width, c.height, c.flipX, c.flipY, c.braille, c.dither, c.resampleFilter, c.cellAspect, c.fitMode)
+	if err != nil {
+		return asciiFrame{}, err
 	}
 
-	wg.Wait()
-	fmt.Printf("                              \r")
+	render := func(withColors, inverted bool) (string, error) {
+		ascii, _, _, err := c.renderAnimationFrame(imgSet, withColors, inverted)
+		return ascii, err
+	}
 
-	// Display the gif
-	loopCount := 0
-	for {
-		for i, asciiFrame := range asciiArtSet {
-			clearScreen()
-			fmt.Println(asciiFrame)
-			time.Sleep(time.Duration((time.Second * time.Duration(originalGif.Delay[i])) / 100))
-		}
+	ascii, frameWidth, frameHeight, err := c.renderAnimationFrame(imgSet, c.colored || c.grayscale, c.negative)
+	if err != nil {
+		return asciiFrame{}, err
+	}
 
-		// If gif is infinite loop
-		if originalGif.LoopCount == 0 {
-			continue
-		}
+	// render keeps the frame's pixels, which outweigh its ascii art
+	size := len(ascii)
+	for _, row := range imgSet {
+		size += len(row) * int(unsafe.Sizeof(imgManip.AsciiPixel{}))
+	}
 
-		loopCount++
-		if loopCount == originalGif.LoopCount {
-			break
-		}
+	return asciiFrame{
+		PlaybackFrame: PlaybackFrame{Ascii: ascii, Delay: frame.Delay, Render: render, Size: size},
+		width:         frameWidth,
+		height:        frameHeight,
+	}, nil
+}
+
+// Maps a frame's AsciiPixels onto characters, returning the ascii art and its size in characters.
+// Colors toggled on use the colors from the flags, or the original image's if none were set
+func (c *converter) renderAnimationFrame(imgSet [][]imgManip.AsciiPixel, withColors, inverted bool) (string, int, int, error) {
+	useColored, useGrayscale := c.colored, c.grayscale
+	if !withColors {
+		useColored, useGrayscale = false, false
+	} else if !c.colored && !c.grayscale {
+		useColored = true
 	}
 
-	return nil
+	asciiCharSet, err := c.convertToCharsWith(imgSet, inverted, useColored, useGrayscale)
+	if err != nil {
+		return "", 0, 0, err
+	}
+
+	frameWidth := 0
+	if len(asciiCharSet) > 0 {
+		frameWidth = len(asciiCharSet[0])
+	}
+
+	return c.flattenToAscii(asciiCharSet, useColored || useGrayscale), frameWidth, len(asciiCharSet), nil
 }
@@ -18,43 +18,95 @@ package aic_package
 
 import (
 	"bytes"
-	"fmt"
 	"image"
+	"io"
 
 	imgManip "github.com/Ares1605/ascii-image-converter-wasm/image_manipulation"
 )
 
-// This function decodes the passed image and returns an ascii art string, optionaly saving it as a .txt and/or .png file
-func pathIsImage[T any](pipedInputBytes []byte, flatten2DAscii func(asciiSet [][]imgManip.AsciiChar, colored bool) T) (T, error) {
+// This function decodes the passed image with the converter's settings and returns an ascii art string, optionaly saving it as a .txt and/or .png file
+func pathIsImage[T any](c *converter, pipedInputBytes []byte, flatten2DAscii func(asciiSet [][]imgManip.AsciiChar, colored bool) T) (T, error) {
 
-	var (
-		imData image.Image
-		err    error
-		zero T 
-	)
+	var zero T
 
-	imData, _, err = image.Decode(bytes.NewReader(pipedInputBytes))
+	imData, err := c.decodeImage(pipedInputBytes)
 	if err != nil {
-		return zero, fmt.Errorf("Can't decode input: %v", err)
+		return zero, err
 	}
 
-	imgSet, err := imgManip.ConvertToAsciiPixels(imData, dimensions, width, height, flipX, flipY, braille, dither)
+	imgSet, err := imgManip.ConvertToAsciiPixels(imData, c.dimensions, c.width, c.height, c.flipX, c.flipY, c.braille, c.dither, c.resampleFilter, c.cellAspect, c.fitMode)
 	if err != nil {
 		return zero, err
 	}
 
-	var asciiSet [][]imgManip.AsciiChar
-
-	if braille {
-		asciiSet, err = imgManip.ConvertToBrailleChars(imgSet, negative, colored, grayscale, colorBg, fontColor, threshold, colorLevel)
-	} else {
-		asciiSet, err = imgManip.ConvertToAsciiChars(imgSet, negative, colored, grayscale, complex, colorBg, customMap, fontColor, colorLevel)
-	}
+	asciiSet, err := c.convertToChars(imgSet)
 	if err != nil {
 		return zero, err
 	}
 
-	ascii := flatten2DAscii(asciiSet, colored || grayscale)
+	ascii := flatten2DAscii(asciiSet, c.colored || c.grayscale)
 
 	return ascii, nil
 }
+
+/*
+Streaming counterpart of pathIsImage(). Each row of characters is flattened and written to w as soon
+as it's computed, followed by a newline, so only a few rows are held in memory beyond the resized image
+*/
+func (c *converter) streamImage(w io.Writer, pipedInputBytes []byte, flattenRow func(asciiRow []imgManip.AsciiChar, colored bool) ([]byte, error)) error {
+
+	imData, err := c.decodeImage(pipedInputBytes)
+	if err != nil {
+		return err
+	}
+
+	return imgManip.StreamAsciiPixels(imData, c.dimensions, c.width, c.height, c.flipX, c.flipY, c.braille, c.dither, c.resampleFilter, c.cellAspect, c.fitMode, func(rows [][]imgManip.AsciiPixel) error {
+
+		asciiSet, err := c.convertToChars(rows)
+		if err != nil {
+			return err
+		}
+
+		for _, asciiRow := range asciiSet {
+			line, err := flattenRow(asciiRow, c.colored || c.grayscale)
+			if err != nil {
+				return err
+			}
+
+			if _, err := w.Write(append(line, '\n')); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Decodes the passed image, displays it upright and runs it through the transform and adjustment stages
+func (c *converter) decodeImage(pipedInputBytes []byte) (image.Image, error) {
+
+	imData, format, err := image.Decode(bytes.NewReader(pipedInputBytes))
+	if err != nil {
+		return nil, &DecodeError{Format: format, Err: err}
+	}
+
+	// Phone cameras store photos sideways and rely on viewers honoring this tag
+	if !c.ignoreExif {
+		imData = imgManip.ApplyOrientation(imData, imgManip.ExifOrientation(pipedInputBytes))
+	}
+
+	return c.prepareImage(imData)
+}
+
+// Maps AsciiPixels onto braille or ascii characters, depending on the flags
+func (c *converter) convertToChars(imgSet [][]imgManip.AsciiPixel) ([][]imgManip.AsciiChar, error) {
+	return c.convertToCharsWith(imgSet, c.negative, c.colored, c.grayscale)
+}
+
+// Same as convertToChars(), with negative and colors passed instead of taken from the flags
+func (c *converter) convertToCharsWith(imgSet [][]imgManip.AsciiPixel, negative, colored, grayscale bool) ([][]imgManip.AsciiChar, error) {
+	if c.braille {
+		return imgManip.ConvertToBrailleChars(imgSet, negative, colored, grayscale, c.colorBg, c.fontColor, c.threshold, c.colorLevel)
+	}
+	return imgManip.ConvertToAsciiChars(imgSet, negative, colored, grayscale, c.complex, c.colorBg, c.customMap, c.fontColor, c.colorLevel)
+}
@@ -17,27 +17,20 @@ limitations under the License.
 package aic_package
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
 	"fmt"
-	"net/http"
-
-	// Image format initialization
-	_ "image/jpeg"
-	_ "image/png"
+	"image"
+	"io"
 
 	// Image format initialization
+	"github.com/Ares1605/ascii-image-converter-wasm/image_formats"
 	image_conversions "github.com/Ares1605/ascii-image-converter-wasm/image_manipulation"
-	_ "golang.org/x/image/bmp"
-	_ "golang.org/x/image/tiff"
-	_ "golang.org/x/image/webp"
 )
 
-var pipedInputTypes = []string{
-	"image/png",
-	"image/jpeg",
-	"image/webp",
-	"image/tiff",
-	"image/bmp",
-}
+// Number of bytes read ahead for sniffing the input's file type
+const sniffLength = 512
 
 // Return default configuration for flags.
 // Can be sent directly to Convert() for default ascii art
@@ -47,6 +40,7 @@ func DefaultFlags() Flags {
 		Dimensions:          nil,
 		Width:               0,
 		Height:              0,
+		FitMode:             image_conversions.FitNone,
 		Negative:            false,
 		Colored:             false,
 		CharBackgroundColor: false,
@@ -59,6 +53,25 @@ func DefaultFlags() Flags {
 		Threshold:           128,
 		Dither:              false,
 		ColorLevel:          image_conversions.Millions,
+		Brightness:          0,
+		Contrast:            0,
+		Gamma:               1,
+		AutoLevels:          false,
+		Equalize:            false,
+		Crop:                nil,
+		CropPercent:         false,
+		Rotate:              0,
+		RotateBackground:    [3]int{0, 0, 0},
+		IgnoreExif:          false,
+		AlphaBackground:     nil,
+		TransparentEmpty:    false,
+		ResampleFilter:      image_conversions.Lanczos,
+		CellAspect:          0,
+		CellWidth:           0,
+		CellHeight:          0,
+		MaxInputBytes:       0,
+		MaxPixels:           0,
+		FrameRate:           0,
 	}
 }
 
@@ -67,72 +80,273 @@ Convert() takes a bytes array of the image/gif as its first argument
 and a aic_package.Flags literal as the second argument, with which it alters
 the returned ascii art string.
 */
-func parseMetadata (inputBytes []byte, flags Flags) error {
-	if flags.Dimensions == nil {
-		dimensions = nil
-	} else {
-		dimensions = flags.Dimensions
-	}
-	width = flags.Width
-	height = flags.Height
-	complex = flags.Complex
-	negative = flags.Negative
-	colored = flags.Colored
-	colorBg = flags.CharBackgroundColor
-	grayscale = flags.Grayscale
-	customMap = flags.CustomMap
-	flipX = flags.FlipX
-	flipY = flags.FlipY
-	jsonOutput = flags.JsonOutput
-	fontColor = flags.FontColor
-	braille = flags.Braille
-	threshold = flags.Threshold
-	dither = flags.Dither
-	colorLevel = flags.ColorLevel
-
-	fileType := http.DetectContentType(inputBytes)
-	invalidInput := true
-
-	if fileType == "image/gif" {
-		inputIsGif = true
-		invalidInput = false
-	} else {
-		for _, inputType := range pipedInputTypes {
-			if fileType == inputType {
-				invalidInput = false
-				break
+func parseMetadata (inputBytes []byte, flags Flags) (*converter, error) {
+	c, err := newConverter(flags)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := detectInputType(inputBytes); err != nil {
+		return nil, err
+	}
+	c.inputIsAnimated = image_formats.IsAnimated(inputBytes)
+
+	if c.maxPixels > 0 {
+		config, _, err := image.DecodeConfig(bytes.NewReader(inputBytes))
+		if err != nil {
+			return nil, &DecodeError{Err: err}
+		}
+
+		if err := c.checkPixels(config.Width, config.Height); err != nil {
+			return nil, err
+		}
+
+		// Every frame of an animation is decoded onto the whole canvas, so the limit covers all of them
+		if c.inputIsAnimated {
+			decoder, err := newAnimationDecoder(inputBytes)
+			if err != nil {
+				return nil, err
+			}
+			if err := c.checkFramePixels(decoder.Width, decoder.Height, decoder.Frames()); err != nil {
+				return nil, err
 			}
 		}
 	}
 
-	if invalidInput {
-		return fmt.Errorf("File type of piped input could not be determined, input may be malformed or not be one of the supported file types")
+	return c, nil
+}
+
+// Validates the flags and parses them into a converter
+func newConverter(flags Flags) (*converter, error) {
+	if err := flags.Validate(); err != nil {
+		return nil, err
+	}
+
+	c := &converter{
+		dimensions:       flags.Dimensions,
+		width:            flags.Width,
+		height:           flags.Height,
+		fitMode:          flags.FitMode,
+		complex:          flags.Complex,
+		negative:         flags.Negative,
+		colored:          flags.Colored,
+		colorBg:          flags.CharBackgroundColor,
+		grayscale:        flags.Grayscale,
+		customMap:        flags.CustomMap,
+		flipX:            flags.FlipX,
+		flipY:            flags.FlipY,
+		jsonOutput:       flags.JsonOutput,
+		fontColor:        flags.FontColor,
+		braille:          flags.Braille,
+		threshold:        flags.Threshold,
+		dither:           flags.Dither,
+		colorLevel:       flags.ColorLevel,
+		maxPixels:        flags.MaxPixels,
+		frameRate:        flags.FrameRate,
+		brightness:       flags.Brightness,
+		contrast:         flags.Contrast,
+		gamma:            flags.Gamma,
+		autoLevels:       flags.AutoLevels,
+		equalize:         flags.Equalize,
+		crop:             flags.Crop,
+		cropPercent:      flags.CropPercent,
+		rotate:           flags.Rotate,
+		rotateBg:         flags.RotateBackground,
+		ignoreExif:       flags.IgnoreExif,
+		alphaBg:          flags.AlphaBackground,
+		transparentEmpty: flags.TransparentEmpty,
+		resampleFilter:   flags.ResampleFilter,
+		cellAspect:       flags.CellAspect,
+	}
+
+	// A cell aspect ratio that was set takes precedence over font metrics
+	if c.cellAspect == 0 {
+		if flags.CellWidth > 0 && flags.CellHeight > 0 {
+			c.cellAspect = flags.CellWidth / flags.CellHeight
+		} else {
+			c.cellAspect = image_conversions.DefaultCellAspect
+		}
+	}
+	if c.colorLevel == 0 {
+		c.colorLevel = image_conversions.Millions
+	}
+
+	return c, nil
+}
+
+// Returns an error if an image of the passed size exceeds Flags.MaxPixels
+func (c *converter) checkPixels(width, height int) error {
+	if c.maxPixels > 0 && width*height > c.maxPixels {
+		return fmt.Errorf("%w: %vx%v pixels exceeds the limit of %v pixels", ErrInputTooLarge, width, height, c.maxPixels)
 	}
 	return nil
 }
+
+// Returns an error if decoding frames of an animation with the passed canvas size exceeds Flags.MaxPixels
+func (c *converter) checkFramePixels(width, height, frames int) error {
+	if c.maxPixels > 0 && int64(width)*int64(height)*int64(frames) > int64(c.maxPixels) {
+		return fmt.Errorf("%w: %v frames of %vx%v pixels exceed the limit of %v pixels", ErrInputTooLarge, frames, width, height, c.maxPixels)
+	}
+	return nil
+}
+
+// Sniffs the file type from the first bytes of the input, which needn't be complete.
+// Returns an error if it isn't a supported file type
+func detectInputType(header []byte) error {
+	if _, ok := image_formats.Sniff(header); ok {
+		return nil
+	}
+
+	return fmt.Errorf("%w: file type of input could not be determined, input may be malformed or not be one of the supported file types", ErrUnsupportedFormat)
+}
 func Convert(inputBytes []byte, flags Flags) (string, error) {
 	// Force JsonOutput to false
 	flags.JsonOutput = false
-	// parseMetadata mutates the inputIsGif global
-	if err := parseMetadata(inputBytes, flags); err != nil {
+	c, err := parseMetadata(inputBytes, flags)
+	if err != nil {
 		return "", err
 	}
-	if inputIsGif {
-		return "", pathIsGif(inputBytes)
+	if c.inputIsAnimated {
+		return "", c.pathIsAnimation(inputBytes, false)
 	} else {
-		return pathIsImage(inputBytes, flattenToAscii)
+		return pathIsImage(c, inputBytes, c.flattenToAscii)
 	}
 }
 func ConvertJSON(inputBytes []byte, flags Flags) ([][]ColoredChar, error) {
 	// Force Jsonoutput to true
 	flags.JsonOutput = true
-	// parseMetadata mutates the inputIsGif global
-	if err := parseMetadata(inputBytes, flags); err != nil {
+	c, err := parseMetadata(inputBytes, flags)
+	if err != nil {
 		return [][]ColoredChar{}, err
 	}
-	if inputIsGif {
-		return [][]ColoredChar{}, fmt.Errorf("JSON output is not supported with GIFs.")
+	if c.inputIsAnimated {
+		return [][]ColoredChar{}, fmt.Errorf("%w by JSON output", ErrAnimationUnsupported)
 	} else {
-		return pathIsImage(inputBytes, flattenToJSONable)
+		return pathIsImage(c, inputBytes, c.flattenToJSONable)
 	}
 }
+func ConvertHTML(inputBytes []byte, flags Flags) (string, error) {
+	flags.JsonOutput = false
+	c, err := parseMetadata(inputBytes, flags)
+	if err != nil {
+		return "", err
+	}
+	if c.inputIsAnimated {
+		return "", fmt.Errorf("%w by HTML output", ErrAnimationUnsupported)
+	} else {
+		return pathIsImage(c, inputBytes, c.flattenToHTML)
+	}
+}
+func ConvertSVG(inputBytes []byte, flags Flags) (string, error) {
+	flags.JsonOutput = false
+	c, err := parseMetadata(inputBytes, flags)
+	if err != nil {
+		return "", err
+	}
+	if c.inputIsAnimated {
+		return "", fmt.Errorf("%w by SVG output", ErrAnimationUnsupported)
+	} else {
+		return pathIsImage(c, inputBytes, c.flattenToSVG)
+	}
+}
+func ConvertPNG(inputBytes []byte, flags Flags) ([]byte, error) {
+	flags.JsonOutput = false
+	c, err := parseMetadata(inputBytes, flags)
+	if err != nil {
+		return nil, err
+	}
+	if c.inputIsAnimated {
+		return nil, fmt.Errorf("%w by PNG output", ErrAnimationUnsupported)
+	}
+
+	// The grid is kept as is and drawn afterwards, since drawing can fail
+	asciiSet, err := pathIsImage(c, inputBytes, func(asciiSet [][]image_conversions.AsciiChar, colored bool) [][]image_conversions.AsciiChar {
+		return asciiSet
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return c.renderPNG(asciiSet, c.colored || c.grayscale)
+}
+
+/*
+ConvertStream() writes the ascii art of the passed image to w row by row, as soon as each row is
+computed, instead of returning it as a whole. Every row is followed by a newline. If
+Flags.JsonOutput is set, each row is written as a JSON array of characters (JSON lines).
+
+This bounds memory for large renders and suits streaming to sockets. Animated input isn't supported.
+*/
+func ConvertStream(w io.Writer, inputBytes []byte, flags Flags) error {
+	c, err := parseMetadata(inputBytes, flags)
+	if err != nil {
+		return err
+	}
+	if c.inputIsAnimated {
+		return fmt.Errorf("%w by streaming output", ErrAnimationUnsupported)
+	}
+
+	return c.streamImage(w, inputBytes, func(asciiRow []image_conversions.AsciiChar, colored bool) ([]byte, error) {
+		row := [][]image_conversions.AsciiChar{asciiRow}
+
+		if c.jsonOutput {
+			return json.Marshal(c.flattenToJSONable(row, colored)[0])
+		}
+		return []byte(c.flattenToAscii(row, colored)), nil
+	})
+}
+
+/*
+ReadInput() reads an image or gif from r, up to maxBytes bytes (unlimited if 0 or less).
+
+The file type is sniffed from the first 512 bytes before the rest is read, so unsupported input is
+rejected early. Input exceeding maxBytes returns an error rather than being read in full.
+*/
+func ReadInput(r io.Reader, maxBytes int64) ([]byte, error) {
+	buffered := bufio.NewReader(r)
+
+	// Peek returns an error along with fewer bytes for inputs shorter than the sniffing length
+	header, err := buffered.Peek(sniffLength)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("unable to read input: %w", err)
+	}
+
+	if err := detectInputType(header); err != nil {
+		return nil, err
+	}
+
+	var limited io.Reader = buffered
+	if maxBytes > 0 {
+		// One byte over the limit is enough to tell that the input is too large
+		limited = io.LimitReader(buffered, maxBytes+1)
+	}
+
+	inputBytes, err := io.ReadAll(limited)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read input: %w", err)
+	}
+
+	if maxBytes > 0 && int64(len(inputBytes)) > maxBytes {
+		return nil, fmt.Errorf("%w: input exceeds the limit of %v bytes", ErrInputTooLarge, maxBytes)
+	}
+
+	return inputBytes, nil
+}
+
+/*
+ConvertReader() is the io.Reader counterpart of Convert(). Input is read with ReadInput(), enforcing
+Flags.MaxInputBytes, and Flags.MaxPixels is checked against the decoded image's dimensions before
+it's fully decoded.
+*/
+func ConvertReader(r io.Reader, flags Flags) (string, error) {
+	if err := flags.Validate(); err != nil {
+		return "", err
+	}
+
+	inputBytes, err := ReadInput(r, flags.MaxInputBytes)
+	if err != nil {
+		return "", err
+	}
+
+	return Convert(inputBytes, flags)
+}
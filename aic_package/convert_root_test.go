@@ -0,0 +1,138 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"io"
+	"testing"
+
+	"github.com/Ares1605/ascii-image-converter-wasm/image_formats"
+)
+
+// Returns a GIF of frames single-colored frames, each covering the whole canvas
+func testManyFrameGIF(t *testing.T, width, height, frames int) []byte {
+	t.Helper()
+
+	palette := color.Palette{color.Black, color.White}
+	animation := &gif.GIF{}
+	for i := range frames {
+		frame := image.NewPaletted(image.Rect(0, 0, width, height), palette)
+		for j := range frame.Pix {
+			frame.Pix[j] = uint8(i % 2)
+		}
+		animation.Image = append(animation.Image, frame)
+		animation.Delay = append(animation.Delay, 1)
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, animation); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestMaxPixels(t *testing.T) {
+	var still bytes.Buffer
+	if err := png.Encode(&still, image.NewGray(image.Rect(0, 0, 100, 100))); err != nil {
+		t.Fatal(err)
+	}
+
+	// 2000 frames of 10x10 pixels, 200000 pixels in total on a canvas of only 100
+	animation := testManyFrameGIF(t, 10, 10, 2000)
+
+	tests := []struct {
+		name      string
+		input     []byte
+		maxPixels int
+		tooLarge  bool
+	}{
+		{"still image within limit", still.Bytes(), 10000, false},
+		{"still image over limit", still.Bytes(), 9999, true},
+		{"no limit", animation, 0, false},
+		{"all frames within limit", animation, 200000, false},
+		{"canvas within limit but frames over it", animation, 199999, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			flags := DefaultFlags()
+			flags.Dimensions = []int{4, 2}
+			flags.MaxPixels = test.maxPixels
+
+			_, err := Inspect(test.input, flags)
+			if test.tooLarge {
+				if !errors.Is(err, ErrInputTooLarge) {
+					t.Fatalf("Inspect() error = %v, want ErrInputTooLarge", err)
+				}
+			} else if err != nil {
+				t.Fatalf("Inspect() error = %v", err)
+			}
+
+			if !test.tooLarge || !image_formats.IsAnimated(test.input) {
+				return
+			}
+
+			// Conversion is rejected before any frame is decoded
+			if err := ConvertAsciicast(io.Discard, test.input, flags); !errors.Is(err, ErrInputTooLarge) {
+				t.Fatalf("ConvertAsciicast() error = %v, want ErrInputTooLarge", err)
+			}
+		})
+	}
+}
+
+func TestCellAspect(t *testing.T) {
+	var square bytes.Buffer
+	if err := png.Encode(&square, image.NewGray(image.Rect(0, 0, 100, 100))); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name                  string
+		cellAspect            float64
+		cellWidth, cellHeight float64
+		rows                  int
+	}{
+		{"default", 0, 0, 0, 10},
+		{"from font metrics", 0, 8, 8, 20},
+		{"explicit ratio", 0.25, 0, 0, 5},
+		{"explicit default ratio over font metrics", 0.5, 8, 8, 10},
+		{"only one font metric", 0, 8, 0, 10},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			flags := DefaultFlags()
+			flags.Width = 20
+			flags.CellAspect = test.cellAspect
+			flags.CellWidth, flags.CellHeight = test.cellWidth, test.cellHeight
+
+			info, err := Inspect(square.Bytes(), flags)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Columns != 20 || info.Rows != test.rows {
+				t.Errorf("got %vx%v characters, want 20x%v", info.Columns, info.Rows, test.rows)
+			}
+		})
+	}
+}
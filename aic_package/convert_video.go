@@ -0,0 +1,145 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Ares1605/ascii-image-converter-wasm/image_formats"
+	imgManip "github.com/Ares1605/ascii-image-converter-wasm/image_manipulation"
+)
+
+// Frame rate used for video streams that don't specify one, such as MJPEG
+const DefaultFrameRate = 25
+
+// VideoFrame is a single frame of a video stream, converted into ascii art
+type VideoFrame struct {
+	// Position of the frame in the stream, starting at 0
+	Index int
+
+	// Time at which the frame is displayed, relative to the first frame
+	Timestamp time.Duration
+
+	// How long the frame is displayed for
+	Delay time.Duration
+
+	// Frame rate of the stream, in frames per second
+	FrameRate float64
+
+	// The frame's ascii art, with ANSI color codes. Empty if Flags.JsonOutput is set
+	Ascii string
+
+	// The frame's characters and their colors. Only set if Flags.JsonOutput is set
+	Chars [][]ColoredChar
+}
+
+/*
+ConvertVideo() converts a YUV4MPEG2 (.y4m) or MJPEG (concatenated JPEG) stream read from r, such
+as ffmpeg's output, into ascii art frame by frame. Each frame is converted as soon as it has been
+read and passed to onFrame, so the stream can be played back live or collected.
+
+The frame rate is taken from Flags.FrameRate, the Y4M stream's header, or DefaultFrameRate, in that
+order. Flags.MaxInputBytes limits the size of each MJPEG frame, since the stream itself can be endless. Conversion stops at the end of the stream, returning nil, or at the first error, including
+one returned by onFrame.
+*/
+func ConvertVideo(r io.Reader, flags Flags, onFrame func(frame VideoFrame) error) error {
+	c, err := newConverter(flags)
+	if err != nil {
+		return err
+	}
+
+	buffered := bufio.NewReader(r)
+
+	header, err := buffered.Peek(sniffLength)
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("unable to read input: %v", err)
+	}
+
+	if format, ok := image_formats.Sniff(header); !ok || !format.Video {
+		return fmt.Errorf("%w: input is not a Y4M or MJPEG stream", ErrUnsupportedFormat)
+	}
+
+	video, err := image_formats.NewVideoReader(buffered, flags.MaxInputBytes, c.checkPixels)
+	if errors.Is(err, ErrInputTooLarge) {
+		return err
+	} else if err != nil {
+		return &DecodeError{Err: err}
+	}
+
+	rate := c.frameRate
+	if rate == 0 {
+		rate = video.FrameRate()
+	}
+	if rate == 0 {
+		rate = DefaultFrameRate
+	}
+	delay := time.Duration(float64(time.Second) / rate)
+
+	// Calculated for each frame, so rounding errors of the delay don't add up
+	timestamp := func(i int) time.Duration {
+		return time.Duration(float64(i) * float64(time.Second) / rate)
+	}
+
+	for i := 0; ; i++ {
+		frameImage, err := video.Next()
+		if err == io.EOF {
+			return nil
+		} else if errors.Is(err, image_formats.ErrFrameTooLarge) {
+			return fmt.Errorf("%w: video frame exceeds the limit of %v bytes", ErrInputTooLarge, flags.MaxInputBytes)
+		} else if errors.Is(err, ErrInputTooLarge) {
+			return err
+		} else if err != nil {
+			return &DecodeError{Err: err}
+		}
+
+		frameImage, err = c.prepareImage(frameImage)
+		if err != nil {
+			return err
+		}
+
+		imgSet, err := imgManip.ConvertToAsciiPixels(frameImage, c.dimensions, c.width, c.height, c.flipX, c.flipY, c.braille, c.dither, c.resampleFilter, c.cellAspect, c.fitMode)
+		if err != nil {
+			return err
+		}
+
+		asciiSet, err := c.convertToChars(imgSet)
+		if err != nil {
+			return err
+		}
+
+		frame := VideoFrame{
+			Index:     i,
+			Timestamp: timestamp(i),
+			Delay:     delay,
+			FrameRate: rate,
+		}
+
+		if c.jsonOutput {
+			frame.Chars = c.flattenToJSONable(asciiSet, c.colored || c.grayscale)
+		} else {
+			frame.Ascii = c.flattenToAscii(asciiSet, c.colored || c.grayscale)
+		}
+
+		if err := onFrame(frame); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,237 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"testing"
+	"time"
+)
+
+// Returns a full range, grayscale Y4M stream of 8x4 frames, each a single shade, with the passed
+// header parameters
+func testY4M(params string, shades ...uint8) []byte {
+	stream := []byte("YUV4MPEG2 W8 H4 Cmono XCOLORRANGE=FULL " + params + "\n")
+	for _, shade := range shades {
+		stream = append(stream, "FRAME\n"...)
+		stream = append(stream, bytes.Repeat([]byte{shade}, 8*4)...)
+	}
+	return stream
+}
+
+// Returns an 8x4 image of a single shade, encoded as a JPEG
+func testJPEG(t *testing.T, shade uint8) []byte {
+	t.Helper()
+
+	img := image.NewGray(image.Rect(0, 0, 8, 4))
+	for i := range img.Pix {
+		img.Pix[i] = shade
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 100}); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// Returns the ascii art of an 8x4 image of a single shade, as Convert() returns it
+func testShadeAscii(t *testing.T, shade uint8, flags Flags) string {
+	t.Helper()
+
+	img := image.NewGray(image.Rect(0, 0, 8, 4))
+	for i := range img.Pix {
+		img.Pix[i] = shade
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	ascii, err := Convert(buf.Bytes(), flags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ascii
+}
+
+// Returns every frame of a video converted by ConvertVideo()
+func convertVideoFrames(input []byte, flags Flags) ([]VideoFrame, error) {
+	var frames []VideoFrame
+	err := ConvertVideo(bytes.NewReader(input), flags, func(frame VideoFrame) error {
+		frames = append(frames, frame)
+		return nil
+	})
+	return frames, err
+}
+
+func testVideoFlags() Flags {
+	flags := DefaultFlags()
+	flags.Dimensions = []int{8, 4}
+	return flags
+}
+
+func TestConvertVideo(t *testing.T) {
+	shades := []uint8{0, 128, 255}
+
+	var mjpeg []byte
+	for _, shade := range shades {
+		mjpeg = append(mjpeg, testJPEG(t, shade)...)
+	}
+
+	tests := []struct {
+		name  string
+		input []byte
+	}{
+		{"y4m", testY4M("F25:1", shades...)},
+		{"mjpeg", mjpeg},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			flags := testVideoFlags()
+
+			frames, err := convertVideoFrames(test.input, flags)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(frames) != len(shades) {
+				t.Fatalf("got %v frames, want %v", len(frames), len(shades))
+			}
+
+			for i, frame := range frames {
+				if frame.Index != i {
+					t.Errorf("frame %v has index %v", i, frame.Index)
+				}
+				if want := testShadeAscii(t, shades[i], flags); frame.Ascii != want {
+					t.Errorf("frame %v is\n%v\nwant\n%v", i, frame.Ascii, want)
+				}
+			}
+		})
+	}
+}
+
+func TestConvertVideoFrameRate(t *testing.T) {
+	mjpeg := append(testJPEG(t, 0), testJPEG(t, 255)...)
+
+	tests := []struct {
+		name      string
+		input     []byte
+		frameRate float64
+		want      float64
+	}{
+		{"y4m header", testY4M("F30000:1001", 0, 255), 0, 30000.0 / 1001},
+		{"y4m header without a rate", testY4M("F0:0", 0, 255), 0, DefaultFrameRate},
+		{"y4m without a rate parameter", testY4M("", 0, 255), 0, DefaultFrameRate},
+		{"flag over y4m header", testY4M("F30:1", 0, 255), 10, 10},
+		{"mjpeg", mjpeg, 0, DefaultFrameRate},
+		{"flag over mjpeg default", mjpeg, 12.5, 12.5},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			flags := testVideoFlags()
+			flags.FrameRate = test.frameRate
+
+			frames, err := convertVideoFrames(test.input, flags)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(frames) != 2 {
+				t.Fatalf("got %v frames, want 2", len(frames))
+			}
+
+			delay := time.Duration(float64(time.Second) / test.want)
+			for _, frame := range frames {
+				if frame.FrameRate != test.want {
+					t.Errorf("frame %v has a frame rate of %v, want %v", frame.Index, frame.FrameRate, test.want)
+				}
+				if frame.Delay != delay {
+					t.Errorf("frame %v has a delay of %v, want %v", frame.Index, frame.Delay, delay)
+				}
+			}
+			if frames[0].Timestamp != 0 || frames[1].Timestamp != delay {
+				t.Errorf("got timestamps %v and %v, want 0 and %v", frames[0].Timestamp, frames[1].Timestamp, delay)
+			}
+		})
+	}
+}
+
+func TestConvertVideoErrors(t *testing.T) {
+	still := testJPEG(t, 128)
+	mjpeg := append(append([]byte{}, still...), still...)
+
+	tests := []struct {
+		name          string
+		input         []byte
+		maxInputBytes int64
+		maxPixels     int
+		frames        int
+		wantErr       error
+	}{
+		{"invalid header parameter", []byte("YUV4MPEG2 Wabc H4\nFRAME\n"), 0, 0, 0, &DecodeError{}},
+		{"unsupported colorspace", []byte("YUV4MPEG2 W8 H4 C420p10\nFRAME\n"), 0, 0, 0, &DecodeError{}},
+		{"invalid frame header", append(testY4M("", 0), "FRAMX\n"...), 0, 0, 1, &DecodeError{}},
+		{"truncated frame", testY4M("", 0, 255)[:len(testY4M("", 0, 255))-1], 0, 0, 1, &DecodeError{}},
+		{"truncated jpeg", mjpeg[:len(mjpeg)-10], 0, 0, 1, &DecodeError{}},
+		{"y4m frames over the pixel limit", testY4M("", 0), 0, 31, 0, ErrInputTooLarge},
+		{"mjpeg frames at the size limit", mjpeg, int64(len(still)), 0, 2, nil},
+		{"mjpeg frame over the size limit by its end marker", mjpeg, int64(len(still) - 1), 0, 0, ErrInputTooLarge},
+		{"not a video", []byte("GIF89a"), 0, 0, 0, ErrUnsupportedFormat},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			flags := testVideoFlags()
+			flags.MaxInputBytes = test.maxInputBytes
+			flags.MaxPixels = test.maxPixels
+
+			frames, err := convertVideoFrames(test.input, flags)
+
+			if decodeErr := (*DecodeError)(nil); errors.As(test.wantErr, &decodeErr) {
+				if !errors.As(err, &decodeErr) {
+					t.Errorf("got error %v, want a DecodeError", err)
+				}
+			} else if !errors.Is(err, test.wantErr) {
+				t.Errorf("got error %v, want %v", err, test.wantErr)
+			}
+
+			if len(frames) != test.frames {
+				t.Errorf("got %v frames before the error, want %v", len(frames), test.frames)
+			}
+		})
+	}
+}
+
+func TestConvertVideoStopsOnCallbackError(t *testing.T) {
+	errStop := errors.New("stop")
+	calls := 0
+
+	err := ConvertVideo(bytes.NewReader(testY4M("", 0, 128, 255)), testVideoFlags(), func(frame VideoFrame) error {
+		calls++
+		return errStop
+	})
+
+	if !errors.Is(err, errStop) || calls != 1 {
+		t.Errorf("got error %v after %v frames, want the callback's error after 1", err, calls)
+	}
+}
@@ -0,0 +1,58 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"errors"
+	"fmt"
+
+	imgManip "github.com/Ares1605/ascii-image-converter-wasm/image_manipulation"
+)
+
+// Errors returned by the Convert functions, re-exported from image_manipulation so callers needn't
+// import it. They're the same values, so errors.Is() and errors.As() match across both packages
+var (
+	ErrUnsupportedFormat     = imgManip.ErrUnsupportedFormat
+	ErrInvalidDimensions     = imgManip.ErrInvalidDimensions
+	ErrAnimationUnsupported  = imgManip.ErrAnimationUnsupported
+	ErrInputTooLarge         = imgManip.ErrInputTooLarge
+	ErrUnsupportedColorLevel = imgManip.ErrUnsupportedColorLevel
+)
+
+// ErrInvalidSettings is returned for settings that can't be parsed, or that name an unknown preset
+var ErrInvalidSettings = errors.New("invalid settings")
+
+// DecodeError is returned when input of a supported file type can't be decoded
+type DecodeError = imgManip.DecodeError
+
+/*
+FlagError describes a single invalid field of Flags, named after the struct field (e.g. "Threshold").
+Flags.Validate() joins one FlagError per invalid field with errors.Join(), so all of them can be
+reported at once. Where a sentinel error applies, such as ErrInvalidDimensions, Err wraps it
+*/
+type FlagError struct {
+	Field string
+	Err   error
+}
+
+func (e *FlagError) Error() string {
+	return fmt.Sprintf("%v: %v", e.Field, e.Err)
+}
+
+func (e *FlagError) Unwrap() error {
+	return e.Err
+}
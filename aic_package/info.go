@@ -0,0 +1,113 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"bytes"
+	"image"
+	"time"
+
+	"github.com/Ares1605/ascii-image-converter-wasm/image_formats"
+	imgManip "github.com/Ares1605/ascii-image-converter-wasm/image_manipulation"
+)
+
+// InputInfo describes an input image or animation, along with the size of the ascii art it's converted into
+type InputInfo struct {
+	// Name of the input's format, e.g. "png"
+	Format string `json:"format"`
+
+	// Size of the image in pixels, as stored in the input. For animations, this is the canvas' size
+	Width  int `json:"width"`
+	Height int `json:"height"`
+
+	// Number of frames, which is 1 for still images
+	Frames int `json:"frames"`
+
+	// Number of times an animation is played, where 0 loops forever, and the total delay of its frames
+	LoopCount int           `json:"loopCount"`
+	Duration  time.Duration `json:"duration"`
+
+	// Size of the ascii art in characters, as converted with the passed flags. For animations,
+	// every frame is the same size
+	Columns int `json:"columns"`
+	Rows    int `json:"rows"`
+}
+
+/*
+Inspect() decodes the passed image or animation and describes it, without returning its ascii art.
+The ascii art's size is computed by converting the image with the passed flags, or the first frame
+of an animation, so it matches what Convert() returns.
+*/
+func Inspect(inputBytes []byte, flags Flags) (InputInfo, error) {
+	flags.JsonOutput = false
+	c, err := parseMetadata(inputBytes, flags)
+	if err != nil {
+		return InputInfo{}, err
+	}
+
+	format, _ := image_formats.Sniff(inputBytes)
+	info := InputInfo{Format: format.Name, Frames: 1, LoopCount: 1}
+
+	if !c.inputIsAnimated {
+		config, _, err := image.DecodeConfig(bytes.NewReader(inputBytes))
+		if err != nil {
+			return InputInfo{}, &DecodeError{Format: format.Name, Err: err}
+		}
+		info.Width, info.Height = config.Width, config.Height
+
+		asciiSet, err := pathIsImage(c, inputBytes, func(asciiSet [][]imgManip.AsciiChar, colored bool) [][]imgManip.AsciiChar {
+			return asciiSet
+		})
+		if err != nil {
+			return InputInfo{}, err
+		}
+
+		info.Rows = len(asciiSet)
+		if len(asciiSet) > 0 {
+			info.Columns = len(asciiSet[0])
+		}
+
+		return info, nil
+	}
+
+	// Only the first frame is decoded, the rest of the animation's layout is read without decoding it
+	decoder, err := newAnimationDecoder(inputBytes)
+	if err != nil {
+		return InputInfo{}, err
+	}
+
+	info.Width, info.Height = decoder.Width, decoder.Height
+	info.Frames = decoder.Frames()
+	info.LoopCount = decoder.LoopCount
+
+	for _, delay := range decoder.Delays() {
+		info.Duration += delay
+	}
+
+	frame, err := decoder.Next()
+	if err != nil {
+		return InputInfo{}, &DecodeError{Format: format.Name, Err: err}
+	}
+
+	firstFrame, err := c.convertAnimationFrame(frame)
+	if err != nil {
+		return InputInfo{}, err
+	}
+	info.Columns, info.Rows = firstFrame.width, firstFrame.height
+
+	return info, nil
+}
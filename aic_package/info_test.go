@@ -0,0 +1,185 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"testing"
+	"time"
+)
+
+func TestInspect(t *testing.T) {
+	var still bytes.Buffer
+	if err := png.Encode(&still, image.NewGray(image.Rect(0, 0, 30, 20))); err != nil {
+		t.Fatal(err)
+	}
+
+	// Frames smaller than the canvas, played three times
+	palette := color.Palette{color.Black, color.White}
+	var animation bytes.Buffer
+	err := gif.EncodeAll(&animation, &gif.GIF{
+		Image: []*image.Paletted{
+			image.NewPaletted(image.Rect(0, 0, 40, 10), palette),
+			image.NewPaletted(image.Rect(10, 5, 20, 10), palette),
+			image.NewPaletted(image.Rect(0, 0, 40, 10), palette),
+		},
+		Delay:     []int{10, 25, 5},
+		LoopCount: 2,
+		Config:    image.Config{Width: 40, Height: 10},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		input      []byte
+		dimensions []int
+		width      int
+		want       InputInfo
+	}{
+		{
+			"still image", still.Bytes(), []int{6, 2}, 0,
+			InputInfo{Format: "png", Width: 30, Height: 20, Frames: 1, LoopCount: 1, Columns: 6, Rows: 2},
+		},
+		{
+			"still image by width", still.Bytes(), nil, 8,
+			InputInfo{Format: "png", Width: 30, Height: 20, Frames: 1, LoopCount: 1, Columns: 8, Rows: 2},
+		},
+		{
+			"animation", animation.Bytes(), []int{8, 2}, 0,
+			InputInfo{
+				Format: "gif", Width: 40, Height: 10, Frames: 3, LoopCount: 3,
+				Duration: 400 * time.Millisecond, Columns: 8, Rows: 2,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			flags := DefaultFlags()
+			flags.Dimensions = test.dimensions
+			flags.Width = test.width
+
+			info, err := Inspect(test.input, flags)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info != test.want {
+				t.Errorf("got %+v, want %+v", info, test.want)
+			}
+
+			// The size matches the ascii art actually converted
+			if info.Frames > 1 {
+				var recording bytes.Buffer
+				if err := ConvertAsciicast(&recording, test.input, flags); err != nil {
+					t.Fatal(err)
+				}
+				header, _ := parseAsciicast(t, recording.String())
+				checkAsciicastHeader(t, header, info.Columns, info.Rows)
+				return
+			}
+
+			flags.JsonOutput = true
+			chars, err := ConvertJSON(test.input, flags)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(chars) != info.Rows || len(chars[0]) != info.Columns {
+				t.Errorf("got %vx%v characters converted, want %vx%v", len(chars[0]), len(chars), info.Columns, info.Rows)
+			}
+
+			// JSON output makes no difference to the description
+			if info, err := Inspect(test.input, flags); err != nil || info != test.want {
+				t.Errorf("got %+v, %v with JSON output, want %+v", info, err, test.want)
+			}
+		})
+	}
+}
+
+func TestInspectErrors(t *testing.T) {
+	var animation bytes.Buffer
+	palette := color.Palette{color.Black, color.White}
+	err := gif.EncodeAll(&animation, &gif.GIF{
+		Image: []*image.Paletted{
+			image.NewPaletted(image.Rect(0, 0, 4, 4), palette),
+			image.NewPaletted(image.Rect(0, 0, 4, 4), palette),
+		},
+		Delay:  []int{1, 1},
+		Config: image.Config{ColorModel: palette, Width: 4, Height: 4},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first frame's LZW data, after its image descriptor and minimum code size, is replaced with
+	// invalid codes, keeping the animation's layout intact
+	corrupt := bytes.Clone(animation.Bytes())
+	blockSize := bytes.IndexByte(corrupt, ',') + 11
+	for i := range int(corrupt[blockSize]) {
+		corrupt[blockSize+1+i] = 0xff
+	}
+
+	flags := DefaultFlags()
+	flags.Dimensions = []int{4, 2}
+
+	invalidFlags := flags
+	invalidFlags.Dimensions = []int{0, 2}
+
+	tests := []struct {
+		name   string
+		input  []byte
+		flags  Flags
+		target error
+	}{
+		{"invalid flags", animation.Bytes(), invalidFlags, ErrInvalidDimensions},
+		{"unknown format", []byte("not an image"), flags, ErrUnsupportedFormat},
+		{"corrupt first frame", corrupt, flags, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			info, err := Inspect(test.input, test.flags)
+			if err == nil {
+				t.Fatalf("got %+v, want an error", info)
+			}
+			if info != (InputInfo{}) {
+				t.Errorf("got %+v along with error %v", info, err)
+			}
+
+			if test.target != nil {
+				if !errors.Is(err, test.target) {
+					t.Errorf("got error %v, want %v", err, test.target)
+				}
+				return
+			}
+
+			var decodeErr *DecodeError
+			if !errors.As(err, &decodeErr) {
+				t.Fatalf("got error %v, want a DecodeError", err)
+			}
+			if decodeErr.Format != "gif" {
+				t.Errorf("got format %q, want gif", decodeErr.Format)
+			}
+		})
+	}
+}
@@ -0,0 +1,535 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// Number of frames converted ahead of the one being displayed
+const playbackBufferSize = 16
+
+// Default memory budget of the frames kept for replaying them, see PlaybackOptions.MaxCacheBytes
+const DefaultPlaybackCacheBytes = 64 << 20
+
+// Range of playback speeds reachable with SpeedUp and SlowDown
+const (
+	minPlaybackSpeed = 0.125
+	maxPlaybackSpeed = 8
+)
+
+const (
+	// Moves the cursor to the top left corner, so each frame overwrites the previous one
+	cursorHome = "\033[H"
+
+	// Clears the whole screen, and from the cursor to the end of the screen
+	eraseScreen      = "\033[2J"
+	eraseBelowCursor = "\033[J"
+
+	// Switch to the alternate screen buffer and back, like full screen terminal programs do
+	enterAlternateScreen = "\033[?1049h"
+	exitAlternateScreen  = "\033[?1049l"
+
+	hideCursor = "\033[?25l"
+	showCursor = "\033[?25h"
+)
+
+// PlaybackFrame is a frame of ascii art, displayed by Play() for Delay
+type PlaybackFrame struct {
+	Ascii string
+	Delay time.Duration
+
+	// Renders the frame again once colors or negative have been toggled by a PlaybackControl.
+	// If nil, the frame is always displayed as Ascii
+	Render func(colored, negative bool) (string, error)
+
+	// Approximate number of bytes held by the frame, including what Render needs, counted against
+	// PlaybackOptions.MaxCacheBytes. If 0, the length of Ascii is used
+	Size int
+}
+
+// PlaybackControl is a command changing playback while it's running, see PlaybackOptions.Controls
+type PlaybackControl int
+
+const (
+	// Pause playback, or resume it if paused
+	TogglePause PlaybackControl = iota
+
+	// Pause playback and show the next or previous frame
+	StepForward
+	StepBackward
+
+	// Double or halve the playback speed, up to 8 times faster or slower
+	SpeedUp
+	SlowDown
+
+	// Toggle colors or negative of the ascii art. Frames are rendered again with PlaybackFrame.Render
+	ToggleColor
+	ToggleNegative
+
+	// Stop playback
+	Quit
+)
+
+type PlaybackOptions struct {
+	// Number of times the frames are played, where 0 loops forever
+	LoopCount int
+
+	// Controls applied as they're received while playing, e.g. from keys pressed. If set, every
+	// frame is kept for stepping backwards, and lines end with "\r\n" since the terminal's input
+	// is expected to be in raw mode, which turns off translating "\n"
+	Controls <-chan PlaybackControl
+
+	// Whether PlaybackFrame.Ascii is rendered with colors and negative, which ToggleColor and
+	// ToggleNegative start from
+	Colored  bool
+	Negative bool
+
+	// Memory budget of the frames kept for replaying them, as counted by PlaybackFrame.Size. Once
+	// it's exceeded, the oldest frames are dropped and produced again for the next loop, and
+	// StepBackward only reaches the frames still kept. If 0, DefaultPlaybackCacheBytes is used
+	MaxCacheBytes int
+}
+
+// Returned to a producer by its emit function once playback has stopped
+var errPlaybackStopped = errors.New("playback stopped")
+
+// Returned internally once a Quit control is received
+var errPlaybackQuit = errors.New("playback quit")
+
+/*
+Play() displays frames of ascii art on w in real time, as they're produced.
+
+produce runs in its own goroutine, converting frames and passing them to emit in order. Up to
+playbackBufferSize frames are buffered, beyond which emit blocks until frames have been displayed,
+so conversion runs ahead of playback without holding every frame in memory.
+
+Frames are scheduled against the monotonic clock, so time spent converting and drawing doesn't add
+up to drift. When playback falls behind and newer frames are already waiting, frames whose time has
+passed are dropped. If there's nothing newer to show, the late frame is drawn and the schedule
+restarts from it. Each frame is drawn over the previous one by moving the cursor home.
+
+The frames are played loopCount times, where 0 loops forever. Frames are kept after the first pass
+for replaying them, unless loopCount is 1. If they don't fit in DefaultPlaybackCacheBytes, produce is
+called again to replay them, so it must produce the same frames each time. Play returns once playback
+has finished, or with the first error from produce or from writing to w.
+*/
+func Play(w io.Writer, loopCount int, produce func(emit func(frame PlaybackFrame) error) error) error {
+	return PlayWithOptions(w, PlaybackOptions{LoopCount: loopCount}, produce)
+}
+
+// PlayWithOptions() is like Play(), with playback controlled by options. A Quit control ends
+// playback without an error
+func PlayWithOptions(w io.Writer, options PlaybackOptions, produce func(emit func(frame PlaybackFrame) error) error) error {
+	_, err := play(w, options, produce)
+	return err
+}
+
+// Plays frames like PlayWithOptions(), returning the last frame drawn
+func play(w io.Writer, options PlaybackOptions, produce func(emit func(frame PlaybackFrame) error) error) (string, error) {
+	if options.MaxCacheBytes == 0 {
+		options.MaxCacheBytes = DefaultPlaybackCacheBytes
+	}
+
+	p := &player{
+		w:         w,
+		options:   options,
+		produce:   produce,
+		controls:  options.Controls,
+		keepCache: options.LoopCount != 1 || options.Controls != nil,
+		pos:       -1,
+		drawn:     -1,
+		next:      time.Now(),
+		speed:     1,
+		colored:   options.Colored,
+		negative:  options.Negative,
+	}
+
+	p.start()
+
+	// Stopping the producer unblocks it if playback stops early
+	defer func() {
+		close(p.stop)
+	}()
+
+	err := p.run()
+	if err == errPlaybackQuit {
+		err = nil
+	}
+
+	return p.lastFrame, err
+}
+
+// A frame along with the toggles its ascii art is rendered with
+type playbackEntry struct {
+	PlaybackFrame
+	colored, negative bool
+}
+
+// Returns the number of bytes the entry is counted as in the cache's budget
+func (e *playbackEntry) size() int {
+	if e.Size > 0 {
+		return e.Size
+	}
+	return len(e.Ascii)
+}
+
+// State of a running playback. Frames are indexed from the start of the animation, and
+// received frames are cached from index base onwards
+type player struct {
+	w        io.Writer
+	options  PlaybackOptions
+	produce  func(emit func(frame PlaybackFrame) error) error
+	controls <-chan PlaybackControl
+
+	// Frames from the running producer, its result, and closed to stop it
+	frames   <-chan PlaybackFrame
+	produced <-chan error
+	stop     chan struct{}
+
+	cache      []playbackEntry
+	base       int
+	keepCache  bool
+	cacheBytes int
+
+	// All frames have been received
+	complete bool
+
+	// Index of the last frame played, either drawn or dropped, and of the frame on screen
+	pos   int
+	drawn int
+
+	// Number of loops finished, and when the frame after pos is due
+	loop int
+	next time.Time
+
+	speed             float64
+	paused            bool
+	colored, negative bool
+
+	// Whether a frame has been drawn, after which frames are drawn without clearing the screen
+	started bool
+
+	lastFrame string
+}
+
+// Runs the producer from the first frame, in its own goroutine
+func (p *player) start() {
+	var (
+		frames   = make(chan PlaybackFrame, playbackBufferSize)
+		produced = make(chan error, 1)
+		stop     = make(chan struct{})
+	)
+
+	go func() {
+		defer close(frames)
+
+		produced <- p.produce(func(frame PlaybackFrame) error {
+			select {
+			case frames <- frame:
+				return nil
+			case <-stop:
+				return errPlaybackStopped
+			}
+		})
+	}()
+
+	p.frames, p.produced, p.stop = frames, produced, stop
+}
+
+/*
+Starts the next loop over by producing the frames again, if the first ones were dropped from the
+cache. The frame on screen stays there until the first frame is drawn, but can't be rendered again
+*/
+func (p *player) replay() bool {
+	if !p.complete || p.base == 0 || p.lastLoop() {
+		return false
+	}
+
+	close(p.stop)
+	p.start()
+
+	clear(p.cache)
+	p.cache, p.base, p.cacheBytes, p.complete = p.cache[:0], 0, 0, false
+	p.pos, p.drawn = -1, -1
+	p.loop++
+
+	return true
+}
+
+// Reports whether the loop being played is the last one
+func (p *player) lastLoop() bool {
+	return p.options.LoopCount > 0 && p.loop+1 >= p.options.LoopCount
+}
+
+func (p *player) run() error {
+	for {
+		target, wraps, ok := p.following(p.pos)
+		if !ok && p.replay() {
+			continue
+		}
+		if !ok && p.complete && !p.paused {
+			return nil
+		}
+
+		// Frames are only received once needed, so the producer is held back by the buffer
+		var frames <-chan PlaybackFrame
+		if !ok && !p.complete {
+			frames = p.frames
+		}
+
+		var (
+			timer *time.Timer
+			due   <-chan time.Time
+		)
+		if ok && !p.paused {
+			timer = time.NewTimer(time.Until(p.next))
+			due = timer.C
+		}
+
+		err := p.wait(frames, due, target, wraps)
+		if timer != nil {
+			timer.Stop()
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Handles whichever comes first of the next frame being received, a control, and the next frame being due
+func (p *player) wait(frames <-chan PlaybackFrame, due <-chan time.Time, target int, wraps bool) error {
+	select {
+	case frame, open := <-frames:
+		if !open {
+			p.complete = true
+			return <-p.produced
+		}
+		p.cache = append(p.cache, playbackEntry{frame, p.options.Colored, p.options.Negative})
+		p.cacheBytes += p.cache[len(p.cache)-1].size()
+		p.evict()
+
+	case control, open := <-p.controls:
+		if !open {
+			p.controls, p.paused = nil, false
+			return nil
+		}
+		return p.control(control)
+
+	case <-due:
+		return p.play(target, wraps)
+	}
+
+	return nil
+}
+
+/*
+Returns the index of the frame after i, wrapping around to the first frame for the next loop.
+ok is false if that frame hasn't been received yet, or if playback has finished
+*/
+func (p *player) following(i int) (next int, wraps bool, ok bool) {
+	if i+1 < p.base+len(p.cache) {
+		return i + 1, false, true
+	}
+
+	if !p.complete || p.base > 0 || len(p.cache) == 0 {
+		return 0, false, false
+	}
+
+	if p.lastLoop() {
+		return 0, false, false
+	}
+
+	return 0, true, true
+}
+
+// Moves on to frame i, dropping frames before it unless they're kept
+func (p *player) advance(i int, wraps bool) {
+	if wraps {
+		p.loop++
+	}
+	p.pos = i
+
+	p.evict()
+}
+
+/*
+Drops frames that have been played from the front of the cache, all of them if frames aren't kept for
+replaying, or as many as needed to fit the cache's budget. The frame on screen and the frames yet to be
+played are always kept
+*/
+func (p *player) evict() {
+	keepFrom := p.pos
+	if p.drawn >= 0 {
+		keepFrom = min(keepFrom, p.drawn)
+	}
+
+	for p.base < keepFrom && (!p.keepCache || p.cacheBytes > p.options.MaxCacheBytes) {
+		p.cacheBytes -= p.cache[0].size()
+
+		// Cleared so the dropped frame can be garbage collected
+		p.cache[0] = playbackEntry{}
+		p.cache = p.cache[1:]
+		p.base++
+	}
+}
+
+// Plays the frame that's due, or drops it if its time has passed and newer frames are waiting
+func (p *player) play(i int, wraps bool) error {
+	delay := time.Duration(float64(p.cache[i-p.base].Delay) / p.speed)
+	end := p.next.Add(delay)
+
+	p.advance(i, wraps)
+
+	if now := time.Now(); !now.Before(end) {
+		if _, _, ok := p.following(i); ok || len(p.frames) > 0 {
+			p.next = end
+			return nil
+		}
+
+		// Nothing newer is ready, so show this frame now and schedule from here on
+		end = now.Add(delay)
+	}
+
+	p.next = end
+	return p.draw(i)
+}
+
+func (p *player) control(control PlaybackControl) error {
+	switch control {
+	case TogglePause:
+		p.paused = !p.paused
+
+		// The next frame is shown right away once resumed
+		p.next = time.Now()
+
+	case StepForward:
+		p.paused = true
+
+		if i, wraps, ok := p.following(p.pos); ok {
+			p.advance(i, wraps)
+			return p.draw(i)
+		}
+
+	case StepBackward:
+		p.paused = true
+
+		i := p.drawn - 1
+		if p.drawn == 0 && p.complete && p.keepCache {
+			i = len(p.cache) - 1
+		}
+
+		if i >= p.base && i != p.drawn {
+			p.pos = i
+			return p.draw(i)
+		}
+
+	case SpeedUp:
+		p.speed = min(p.speed*2, maxPlaybackSpeed)
+
+	case SlowDown:
+		p.speed = max(p.speed/2, minPlaybackSpeed)
+
+	case ToggleColor, ToggleNegative:
+		if control == ToggleColor {
+			p.colored = !p.colored
+		} else {
+			p.negative = !p.negative
+		}
+
+		if p.drawn >= p.base {
+			return p.draw(p.drawn)
+		}
+
+	case Quit:
+		return errPlaybackQuit
+	}
+
+	return nil
+}
+
+// Draws frame i over the one on screen, rendering it again if the toggles have changed
+func (p *player) draw(i int) error {
+	entry := &p.cache[i-p.base]
+
+	if entry.Render != nil && (entry.colored != p.colored || entry.negative != p.negative) {
+		ascii, err := entry.Render(p.colored, p.negative)
+		if err != nil {
+			return err
+		}
+		entry.Ascii, entry.colored, entry.negative = ascii, p.colored, p.negative
+	}
+
+	prefix := cursorHome
+	if !p.started {
+		prefix = eraseScreen + cursorHome
+	}
+
+	ascii, newline := entry.Ascii, "\n"
+	if p.options.Controls != nil {
+		ascii, newline = strings.ReplaceAll(ascii, "\n", "\r\n"), "\r\n"
+	}
+
+	if _, err := io.WriteString(p.w, prefix+ascii+newline+eraseBelowCursor); err != nil {
+		return err
+	}
+
+	p.drawn, p.started = i, true
+	p.lastFrame = entry.Ascii
+	return nil
+}
+
+// Maps keys read from a terminal in raw mode onto playback controls. Unknown keys are ignored
+func parsePlaybackKeys(input []byte) []PlaybackControl {
+	var controls []PlaybackControl
+
+	for i := 0; i < len(input); i++ {
+		switch input[i] {
+		case ' ':
+			controls = append(controls, TogglePause)
+		case '+', '=':
+			controls = append(controls, SpeedUp)
+		case '-', '_':
+			controls = append(controls, SlowDown)
+		case 'c', 'C':
+			controls = append(controls, ToggleColor)
+		case 'n', 'N':
+			controls = append(controls, ToggleNegative)
+
+		// Ctrl-C is read as a key in raw mode, rather than interrupting the process
+		case 'q', 'Q', 0x03:
+			controls = append(controls, Quit)
+
+		// Arrow keys are sent as ESC [ C, or ESC O C in application cursor mode
+		case 0x1b:
+			if i+2 < len(input) && (input[i+1] == '[' || input[i+1] == 'O') {
+				switch input[i+2] {
+				case 'C':
+					controls = append(controls, StepForward)
+				case 'D':
+					controls = append(controls, StepBackward)
+				}
+				i += 2
+			}
+		}
+	}
+
+	return controls
+}
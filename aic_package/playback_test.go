@@ -0,0 +1,458 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Ares1605/ascii-image-converter-wasm/image_formats"
+)
+
+// Returns the frames drawn by playback, in order, with lines ending in "\n"
+func drawnFrames(output string) []string {
+	var frames []string
+
+	// Everything before the first cursorHome is the screen being cleared
+	for _, frame := range strings.Split(output, cursorHome)[1:] {
+		frame = strings.ReplaceAll(strings.TrimSuffix(frame, eraseBelowCursor), "\r\n", "\n")
+		frame = strings.TrimSuffix(frame, "\n")
+		frames = append(frames, frame)
+	}
+
+	return frames
+}
+
+// Returns a producer emitting the passed frames, each displayed for delay
+func produceFrames(frames []string, delay time.Duration) func(emit func(frame PlaybackFrame) error) error {
+	return func(emit func(frame PlaybackFrame) error) error {
+		for _, frame := range frames {
+			if err := emit(PlaybackFrame{Ascii: frame, Delay: delay}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// Returns the frame names "0" to "n-1"
+func numberedFrames(n int) []string {
+	frames := make([]string, n)
+	for i := range frames {
+		frames[i] = strconv.Itoa(i)
+	}
+	return frames
+}
+
+// Frames are displayed for long enough that none are dropped
+func TestPlaybackOrder(t *testing.T) {
+	tests := []struct {
+		name      string
+		frames    []string
+		loopCount int
+		want      []string
+	}{
+		{"single pass", []string{"a", "b", "c"}, 1, []string{"a", "b", "c"}},
+		{"two loops", []string{"a", "b", "c"}, 2, []string{"a", "b", "c", "a", "b", "c"}},
+		{"three loops", []string{"a", "b"}, 3, []string{"a", "b", "a", "b", "a", "b"}},
+		{"single frame looped", []string{"a"}, 3, []string{"a", "a", "a"}},
+		{"more frames than the buffer", numberedFrames(playbackBufferSize + 4), 1, numberedFrames(playbackBufferSize + 4)},
+		{"more frames than the buffer looped", numberedFrames(playbackBufferSize + 4), 2, slices.Concat(numberedFrames(playbackBufferSize+4), numberedFrames(playbackBufferSize+4))},
+		{"no frames", nil, 1, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var output bytes.Buffer
+
+			lastFrame, err := play(&output, PlaybackOptions{LoopCount: test.loopCount}, produceFrames(test.frames, 20*time.Millisecond))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := drawnFrames(output.String()); !slices.Equal(got, test.want) {
+				t.Errorf("drew %q, want %q", got, test.want)
+			}
+
+			if len(test.want) > 0 && lastFrame != test.want[len(test.want)-1] {
+				t.Errorf("last frame %q, want %q", lastFrame, test.want[len(test.want)-1])
+			}
+			if len(test.want) > 0 && !strings.HasPrefix(output.String(), eraseScreen+cursorHome) {
+				t.Errorf("output starts with %q, want the screen cleared", output.String()[:min(output.Len(), 8)])
+			}
+		})
+	}
+}
+
+func TestPlaybackSlowProducer(t *testing.T) {
+	const delay = 10 * time.Millisecond
+
+	// The producer stalls for longer than the frames after the stall would be displayed for
+	tests := []struct {
+		name        string
+		stallBefore func(i int) bool
+		frames      int
+		wantDropped bool
+	}{
+		{"frames ready after a stall are dropped until playback catches up", func(i int) bool { return i == 1 }, 6, true},
+		{"late frames are drawn when nothing newer is ready", func(i int) bool { return i > 0 }, 4, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var output bytes.Buffer
+
+			err := Play(&output, 1, func(emit func(frame PlaybackFrame) error) error {
+				for i := 0; i < test.frames; i++ {
+					if test.stallBefore(i) {
+						time.Sleep(10 * delay)
+					}
+					if err := emit(PlaybackFrame{Ascii: strconv.Itoa(i), Delay: delay}); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			drawn := drawnFrames(output.String())
+			if len(drawn) == 0 || drawn[0] != "0" || drawn[len(drawn)-1] != strconv.Itoa(test.frames-1) {
+				t.Fatalf("drew %q, want the first and last frames drawn", drawn)
+			}
+			for i := 1; i < len(drawn); i++ {
+				previous, _ := strconv.Atoi(drawn[i-1])
+				if current, _ := strconv.Atoi(drawn[i]); current <= previous {
+					t.Errorf("drew %q, want frames drawn once each in order", drawn)
+					break
+				}
+			}
+
+			if dropped := len(drawn) < test.frames; dropped != test.wantDropped {
+				t.Errorf("drew %q of %v frames, want frames dropped: %v", drawn, test.frames, test.wantDropped)
+			}
+		})
+	}
+}
+
+func TestPlaybackReplay(t *testing.T) {
+	frames := []string{"a", "b", "c"}
+
+	// Each frame counts as a single byte
+	tests := []struct {
+		name          string
+		loopCount     int
+		maxCacheBytes int
+		wantRuns      int
+	}{
+		{"frames fitting the cache are produced once", 3, 3, 1},
+		{"frames over the budget are produced for each loop", 3, 2, 3},
+		{"a single loop is produced once", 1, 1, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				output bytes.Buffer
+				runs   int
+			)
+
+			options := PlaybackOptions{LoopCount: test.loopCount, MaxCacheBytes: test.maxCacheBytes}
+
+			err := PlayWithOptions(&output, options, func(emit func(frame PlaybackFrame) error) error {
+				runs++
+				return produceFrames(frames, 20*time.Millisecond)(emit)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var want []string
+			for range test.loopCount {
+				want = append(want, frames...)
+			}
+
+			if got := drawnFrames(output.String()); !slices.Equal(got, want) {
+				t.Errorf("drew %q, want %q", got, want)
+			}
+			if runs != test.wantRuns {
+				t.Errorf("produced the frames %v times, want %v", runs, test.wantRuns)
+			}
+			if strings.Count(output.String(), eraseScreen) != 1 {
+				t.Errorf("screen cleared %v times, want once", strings.Count(output.String(), eraseScreen))
+			}
+		})
+	}
+}
+
+// Fails every write, as a closed terminal would
+type failingWriter struct{}
+
+var errWriteFailed = errors.New("write failed")
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errWriteFailed
+}
+
+func TestPlaybackStop(t *testing.T) {
+	errProduce := errors.New("conversion failed")
+
+	quit := make(chan PlaybackControl, 1)
+	quit <- Quit
+
+	tests := []struct {
+		name    string
+		options PlaybackOptions
+		writer  io.Writer
+
+		// Frames emitted before the producer fails, or -1 to emit frames until stopped
+		failAfter   int
+		wantErr     error
+		wantProduce error
+	}{
+		{"quit control", PlaybackOptions{LoopCount: 0, Controls: quit}, &bytes.Buffer{}, -1, nil, errPlaybackStopped},
+		{"write error", PlaybackOptions{LoopCount: 1}, failingWriter{}, -1, errWriteFailed, errPlaybackStopped},
+		{"producer error", PlaybackOptions{LoopCount: 0}, &bytes.Buffer{}, 1, errProduce, errProduce},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			produced := make(chan error, 1)
+
+			produce := func(emit func(frame PlaybackFrame) error) error {
+				err := func() error {
+					for i := 0; test.failAfter < 0 || i < test.failAfter; i++ {
+						if err := emit(PlaybackFrame{Ascii: strconv.Itoa(i), Delay: time.Millisecond}); err != nil {
+							return err
+						}
+					}
+					return errProduce
+				}()
+
+				produced <- err
+				return err
+			}
+
+			err := PlayWithOptions(test.writer, test.options, produce)
+			if !errors.Is(err, test.wantErr) || (test.wantErr == nil && err != nil) {
+				t.Errorf("playback returned %v, want %v", err, test.wantErr)
+			}
+
+			// The producer is blocked on a full buffer unless stopping playback unblocks it
+			select {
+			case err := <-produced:
+				if err != test.wantProduce {
+					t.Errorf("producer returned %v, want %v", err, test.wantProduce)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("producer still blocked after playback stopped")
+			}
+		})
+	}
+}
+
+func TestParsePlaybackKeys(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []PlaybackControl
+	}{
+		{"pause", " ", []PlaybackControl{TogglePause}},
+		{"speed", "+=-_", []PlaybackControl{SpeedUp, SpeedUp, SlowDown, SlowDown}},
+		{"toggles", "cCnN", []PlaybackControl{ToggleColor, ToggleColor, ToggleNegative, ToggleNegative}},
+		{"quit", "q", []PlaybackControl{Quit}},
+		{"quit with ctrl-c", "\x03", []PlaybackControl{Quit}},
+		{"arrow keys", "\x1b[C\x1b[D", []PlaybackControl{StepForward, StepBackward}},
+		{"arrow keys in application cursor mode", "\x1bOC\x1bOD", []PlaybackControl{StepForward, StepBackward}},
+		{"other escape sequences are skipped", "\x1b[A\x1b[B \x1bOA", []PlaybackControl{TogglePause}},
+		{"arrow key letters outside a sequence", "CD", []PlaybackControl{ToggleColor}},
+		{"truncated escape sequence", "\x1b[", nil},
+		{"unknown keys", "xyz\r", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := parsePlaybackKeys([]byte(test.input)); !slices.Equal(got, test.want) {
+				t.Errorf("parsePlaybackKeys(%q) = %v, want %v", test.input, got, test.want)
+			}
+		})
+	}
+}
+
+// Returns a player controlled with options that has received every frame, with nothing drawn yet
+func testPlayer(output *bytes.Buffer, options PlaybackOptions, frames ...PlaybackFrame) *player {
+	options.Controls = make(chan PlaybackControl)
+	if options.MaxCacheBytes == 0 {
+		options.MaxCacheBytes = DefaultPlaybackCacheBytes
+	}
+
+	p := &player{
+		w:         output,
+		options:   options,
+		keepCache: true,
+		complete:  true,
+		pos:       -1,
+		drawn:     -1,
+		next:      time.Now(),
+		speed:     1,
+		colored:   options.Colored,
+		negative:  options.Negative,
+	}
+
+	for _, frame := range frames {
+		p.cache = append(p.cache, playbackEntry{frame, options.Colored, options.Negative})
+		p.cacheBytes += len(frame.Ascii)
+	}
+
+	return p
+}
+
+func TestPlaybackControls(t *testing.T) {
+	tests := []struct {
+		name          string
+		loopCount     int
+		maxCacheBytes int
+		controls      []PlaybackControl
+		want          []string
+		wantPaused    bool
+	}{
+		{"pause", 1, 0, []PlaybackControl{TogglePause}, nil, true},
+		{"resume", 1, 0, []PlaybackControl{TogglePause, TogglePause}, nil, false},
+		{"step forward", 1, 0, []PlaybackControl{StepForward, StepForward}, []string{"a", "b"}, true},
+		{"step forward past the last frame", 1, 0, []PlaybackControl{StepForward, StepForward, StepForward, StepForward}, []string{"a", "b", "c"}, true},
+		{"step forward wraps when looping", 0, 0, []PlaybackControl{StepForward, StepForward, StepForward, StepForward}, []string{"a", "b", "c", "a"}, true},
+		{"step backward", 1, 0, []PlaybackControl{StepForward, StepForward, StepForward, StepBackward, StepBackward}, []string{"a", "b", "c", "b", "a"}, true},
+		{"step backward wraps to the last frame", 1, 0, []PlaybackControl{StepForward, StepBackward}, []string{"a", "c"}, true},
+		{"step backward before anything is drawn", 1, 0, []PlaybackControl{StepBackward}, nil, true},
+		{"step backward only reaches the frames kept", 1, 2, []PlaybackControl{StepForward, StepForward, StepForward, StepBackward, StepBackward}, []string{"a", "b", "c", "b"}, true},
+		{"stepping pauses until resumed", 1, 0, []PlaybackControl{StepForward, TogglePause}, []string{"a"}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var output bytes.Buffer
+			p := testPlayer(&output, PlaybackOptions{LoopCount: test.loopCount, MaxCacheBytes: test.maxCacheBytes}, PlaybackFrame{Ascii: "a"}, PlaybackFrame{Ascii: "b"}, PlaybackFrame{Ascii: "c"})
+
+			for _, control := range test.controls {
+				if err := p.control(control); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if got := drawnFrames(output.String()); !slices.Equal(got, test.want) {
+				t.Errorf("drew %q, want %q", got, test.want)
+			}
+			if p.paused != test.wantPaused {
+				t.Errorf("paused is %v, want %v", p.paused, test.wantPaused)
+			}
+		})
+	}
+
+	t.Run("quit", func(t *testing.T) {
+		if err := testPlayer(&bytes.Buffer{}, PlaybackOptions{LoopCount: 1}).control(Quit); err != errPlaybackQuit {
+			t.Errorf("got %v, want %v", err, errPlaybackQuit)
+		}
+	})
+
+	t.Run("speed is clamped", func(t *testing.T) {
+		p := testPlayer(&bytes.Buffer{}, PlaybackOptions{LoopCount: 1})
+		for range 10 {
+			p.control(SpeedUp)
+		}
+		if p.speed != maxPlaybackSpeed {
+			t.Errorf("speed %v after speeding up, want %v", p.speed, maxPlaybackSpeed)
+		}
+
+		for range 20 {
+			p.control(SlowDown)
+		}
+		if p.speed != minPlaybackSpeed {
+			t.Errorf("speed %v after slowing down, want %v", p.speed, minPlaybackSpeed)
+		}
+	})
+}
+
+// Toggling twice has to draw the frame as it was, with the frames rendered again by the converter
+func TestPlaybackToggles(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 16, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 16; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{uint8(x * 16), uint8(y * 32), 64, 255})
+		}
+	}
+
+	tests := []struct {
+		name             string
+		colored, braille bool
+		control          PlaybackControl
+	}{
+		{"negative", false, false, ToggleNegative},
+		{"negative with colors", true, false, ToggleNegative},
+		{"negative braille", false, true, ToggleNegative},
+		{"negative braille with colors", true, true, ToggleNegative},
+		{"colors", false, false, ToggleColor},
+		{"colors braille", false, true, ToggleColor},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			flags := DefaultFlags()
+			flags.Dimensions = []int{16, 8}
+			flags.Colored = test.colored
+			flags.Braille = test.braille
+
+			c, err := newConverter(flags)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			frame, err := c.convertAnimationFrame(image_formats.Frame{Image: img, Delay: time.Millisecond})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var output bytes.Buffer
+			p := testPlayer(&output, PlaybackOptions{LoopCount: 1, Colored: test.colored}, frame.PlaybackFrame)
+
+			for _, control := range []PlaybackControl{StepForward, test.control, test.control} {
+				if err := p.control(control); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			drawn := drawnFrames(output.String())
+			if len(drawn) != 3 {
+				t.Fatalf("drew %v frames, want 3", len(drawn))
+			}
+			if drawn[1] == frame.Ascii {
+				t.Errorf("toggling didn't change the frame drawn")
+			}
+			if drawn[2] != frame.Ascii {
+				t.Errorf("toggling twice drew\n%s\nwant the frame as it was\n%s", drawn[2], frame.Ascii)
+			}
+		})
+	}
+}
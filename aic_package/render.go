@@ -0,0 +1,265 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"html"
+	"image/color"
+	"strings"
+
+	imgManip "github.com/Ares1605/ascii-image-converter-wasm/image_manipulation"
+	"github.com/fogleman/gg"
+	"github.com/golang/freetype/truetype"
+)
+
+var (
+	//go:embed Hack-Regular.ttf
+	asciiFontBytes []byte
+
+	//go:embed DejaVuSans-Oblique.ttf
+	brailleFontBytes []byte
+)
+
+// Font size in points for rendering ascii art into a PNG image
+const pngFontSize = 14
+
+// charColor returns the RGB color a character is displayed with, following the same
+// precedence as flattenToAscii. The second value is false if the character is uncolored
+func (c *converter) charColor(char imgManip.AsciiChar, colored bool) ([3]uint8, bool) {
+	if c.transparentEmpty && char.Transparent {
+		return [3]uint8{}, false
+	} else if colored {
+		return [3]uint8{char.OriginalColorRGB[0], char.OriginalColorRGB[1], char.OriginalColorRGB[2]}, true
+	} else if c.fontColor != [3]int{255, 255, 255} {
+		return [3]uint8{char.SetColorRGB[0], char.SetColorRGB[1], char.SetColorRGB[2]}, true
+	}
+	return [3]uint8{}, false
+}
+
+// charText returns the character to display, which is a space for empty transparent characters
+func (c *converter) charText(char imgManip.AsciiChar) string {
+	if c.transparentEmpty && char.Transparent {
+		return " "
+	}
+	return char.Simple
+}
+
+// A run of consecutive characters on a line that are displayed with the same color
+type colorRun struct {
+	text     string
+	rgb      [3]uint8
+	hasColor bool
+
+	// Column of the run's first character, and its length in characters
+	column, length int
+}
+
+// colorRuns splits a line of characters into runs of the same color, following charColor
+func (c *converter) colorRuns(line []imgManip.AsciiChar, colored bool) []colorRun {
+	var runs []colorRun
+
+	for j := 0; j < len(line); {
+		rgb, hasColor := c.charColor(line[j], colored)
+
+		// Extend the run while the following characters share this color
+		k := j + 1
+		for k < len(line) {
+			nextRgb, nextHasColor := c.charColor(line[k], colored)
+			if nextHasColor != hasColor || nextRgb != rgb {
+				break
+			}
+			k++
+		}
+
+		var text strings.Builder
+		for _, char := range line[j:k] {
+			text.WriteString(c.charText(char))
+		}
+
+		runs = append(runs, colorRun{text: text.String(), rgb: rgb, hasColor: hasColor, column: j, length: k - j})
+		j = k
+	}
+
+	return runs
+}
+
+// flattenToHTML flattens a two-dimensional grid of ascii characters into a <pre> element.
+// Consecutive characters of the same color are grouped into a single styled <span>
+func (c *converter) flattenToHTML(asciiSet [][]imgManip.AsciiChar, colored bool) string {
+	var sb strings.Builder
+
+	property := "color"
+	if c.colorBg {
+		property = "background-color"
+	}
+
+	sb.WriteString(`<pre class="ascii-art">`)
+
+	for i, line := range asciiSet {
+		if i != 0 {
+			sb.WriteString("\n")
+		}
+
+		for _, run := range c.colorRuns(line, colored) {
+			if run.hasColor {
+				fmt.Fprintf(&sb, `<span style="%v:rgb(%v,%v,%v)">%v</span>`, property, run.rgb[0], run.rgb[1], run.rgb[2], html.EscapeString(run.text))
+			} else {
+				sb.WriteString(html.EscapeString(run.text))
+			}
+		}
+	}
+
+	sb.WriteString("</pre>")
+
+	return sb.String()
+}
+
+// Font size and character cell size of SVG output, in pixels. Cells are sized like the advance
+// and line height of common monospaced fonts, and each line is stretched to fill its cells exactly
+const (
+	svgFontSize   = 15
+	svgCellWidth  = 9
+	svgCellHeight = 18
+)
+
+// flattenToSVG flattens a two-dimensional grid of ascii characters into an SVG image on a black
+// background, with a <text> element per line. Consecutive characters of the same color are grouped
+// into a single <tspan>, or drawn over a rectangle of their color if Flags.CharBackgroundColor is set
+func (c *converter) flattenToSVG(asciiSet [][]imgManip.AsciiChar, colored bool) string {
+	var sb strings.Builder
+
+	columns := 0
+	for _, line := range asciiSet {
+		columns = max(columns, len(line))
+	}
+	width, height := columns*svgCellWidth, len(asciiSet)*svgCellHeight
+
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%v" height="%v" viewBox="0 0 %v %v" font-family="monospace" font-size="%v">`, width, height, width, height, svgFontSize)
+	sb.WriteString(`<rect width="100%" height="100%" fill="black"/>`)
+
+	for i, line := range asciiSet {
+		if len(line) == 0 {
+			continue
+		}
+
+		y := i * svgCellHeight
+		runs := c.colorRuns(line, colored)
+
+		textColor := "white"
+		if c.colorBg {
+			textColor = "black"
+
+			for _, run := range runs {
+				if run.hasColor {
+					fmt.Fprintf(&sb, `<rect x="%v" y="%v" width="%v" height="%v" fill="rgb(%v,%v,%v)"/>`, run.column*svgCellWidth, y, run.length*svgCellWidth, svgCellHeight, run.rgb[0], run.rgb[1], run.rgb[2])
+				}
+			}
+		}
+
+		// The baseline sits a font size below the top of the cell, leaving room for descenders
+		fmt.Fprintf(&sb, `<text x="0" y="%v" fill="%v" textLength="%v" lengthAdjust="spacing" xml:space="preserve">`, y+svgFontSize, textColor, len(line)*svgCellWidth)
+
+		for _, run := range runs {
+			if run.hasColor && !c.colorBg {
+				fmt.Fprintf(&sb, `<tspan fill="rgb(%v,%v,%v)">%v</tspan>`, run.rgb[0], run.rgb[1], run.rgb[2], html.EscapeString(run.text))
+			} else {
+				sb.WriteString(html.EscapeString(run.text))
+			}
+		}
+
+		sb.WriteString("</text>")
+	}
+
+	sb.WriteString("</svg>")
+
+	return sb.String()
+}
+
+// renderPNG draws a two-dimensional grid of ascii characters onto a black PNG image,
+// one monospaced cell per character. Braille art uses a font that supports braille patterns
+func (c *converter) renderPNG(asciiSet [][]imgManip.AsciiChar, colored bool) ([]byte, error) {
+	fontBytes := asciiFontBytes
+	if c.braille {
+		fontBytes = brailleFontBytes
+	}
+
+	parsedFont, err := truetype.Parse(fontBytes)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse font: %v", err)
+	}
+
+	face := truetype.NewFace(parsedFont, &truetype.Options{Size: pngFontSize})
+	defer face.Close()
+
+	metrics := face.Metrics()
+	ascent := float64(metrics.Ascent.Ceil())
+	cellHeight := float64(metrics.Height.Ceil())
+
+	advance, ok := face.GlyphAdvance('W')
+	if c.braille {
+		advance, ok = face.GlyphAdvance('⣿')
+	}
+	if !ok {
+		return nil, fmt.Errorf("font has no glyph for measuring character width")
+	}
+	cellWidth := float64(advance.Ceil())
+
+	columns := 0
+	for _, line := range asciiSet {
+		columns = max(columns, len(line))
+	}
+
+	dc := gg.NewContext(max(int(cellWidth)*columns, 1), max(int(cellHeight)*len(asciiSet), 1))
+	dc.SetFontFace(face)
+	dc.SetColor(color.Black)
+	dc.Clear()
+
+	for i, line := range asciiSet {
+		for j, char := range line {
+			x := float64(j) * cellWidth
+			y := float64(i) * cellHeight
+
+			textColor := color.Color(color.White)
+
+			if rgb, hasColor := c.charColor(char, colored); hasColor {
+				cellColor := color.RGBA{rgb[0], rgb[1], rgb[2], 255}
+
+				if c.colorBg {
+					dc.SetColor(cellColor)
+					dc.DrawRectangle(x, y, cellWidth, cellHeight)
+					dc.Fill()
+					textColor = color.Black
+				} else {
+					textColor = cellColor
+				}
+			}
+
+			dc.SetColor(textColor)
+			dc.DrawString(c.charText(char), x, y+ascent)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := dc.EncodePNG(&buf); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
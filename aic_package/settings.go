@@ -0,0 +1,287 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	imgManip "github.com/Ares1605/ascii-image-converter-wasm/image_manipulation"
+)
+
+// Version of the settings format. Settings of a newer version are rejected, since they may hold fields
+// this version doesn't know about
+const SettingsVersion = 1
+
+/*
+Settings is a portable form of Flags, which can be stored and passed around as JSON, e.g. between the
+CLI, the WASM module and a server. Field names are stable and match the WASM module's options.
+
+Fields left out keep their value from the Flags the settings are applied to, so settings only need to
+hold what they change. A settings blob can start from a built-in preset, which its other fields
+override. Input limits (Flags.MaxInputBytes and Flags.MaxPixels), Flags.JsonOutput and
+Flags.FrameRate aren't part of the settings, since they're up to the host rather than how the ascii
+art looks.
+*/
+type Settings struct {
+	// Version of the settings format, which must be set. See SettingsVersion
+	Version int `json:"version"`
+
+	// Name of a built-in preset applied before the other fields, see Presets()
+	Preset string `json:"preset,omitempty"`
+
+	Dimensions          []int                    `json:"dimensions,omitempty"`
+	Width               *int                     `json:"width,omitempty"`
+	Height              *int                     `json:"height,omitempty"`
+	FitMode             *imgManip.FitMode        `json:"fitMode,omitempty"`
+	Complex             *bool                    `json:"complex,omitempty"`
+	Negative            *bool                    `json:"negative,omitempty"`
+	Colored             *bool                    `json:"colored,omitempty"`
+	CharBackgroundColor *bool                    `json:"charBackgroundColor,omitempty"`
+	Grayscale           *bool                    `json:"grayscale,omitempty"`
+	CustomMap           *string                  `json:"customMap,omitempty"`
+	FlipX               *bool                    `json:"flipX,omitempty"`
+	FlipY               *bool                    `json:"flipY,omitempty"`
+	FontColor           *[3]int                  `json:"fontColor,omitempty"`
+	Braille             *bool                    `json:"braille,omitempty"`
+	Threshold           *int                     `json:"threshold,omitempty"`
+	Dither              *bool                    `json:"dither,omitempty"`
+	ColorLevel          *imgManip.ColorLevel     `json:"colorLevel,omitempty"`
+	Brightness          *float64                 `json:"brightness,omitempty"`
+	Contrast            *float64                 `json:"contrast,omitempty"`
+	Gamma               *float64                 `json:"gamma,omitempty"`
+	AutoLevels          *bool                    `json:"autoLevels,omitempty"`
+	Equalize            *bool                    `json:"equalize,omitempty"`
+	Crop                []float64                `json:"crop,omitempty"`
+	CropPercent         *bool                    `json:"cropPercent,omitempty"`
+	Rotate              *float64                 `json:"rotate,omitempty"`
+	RotateBackground    *[3]int                  `json:"rotateBackground,omitempty"`
+	IgnoreExif          *bool                    `json:"ignoreExif,omitempty"`
+	AlphaBackground     []int                    `json:"alphaBackground,omitempty"`
+	TransparentEmpty    *bool                    `json:"transparentEmpty,omitempty"`
+	ResampleFilter      *imgManip.ResampleFilter `json:"resampleFilter,omitempty"`
+	CellAspect          *float64                 `json:"cellAspect,omitempty"`
+	CellWidth           *float64                 `json:"cellWidth,omitempty"`
+	CellHeight          *float64                 `json:"cellHeight,omitempty"`
+}
+
+// Returns a pointer to a copy of value, for filling in Settings
+func ptr[T any](value T) *T {
+	return &value
+}
+
+// Built-in presets, which Settings.Preset refers to by name
+var presets = map[string]Settings{
+	// Green phosphor on black, like an old monochrome terminal
+	"retro-green": {
+		Version:   SettingsVersion,
+		Colored:   ptr(false),
+		Grayscale: ptr(false),
+		FontColor: &[3]int{51, 255, 51},
+	},
+
+	// Dithered braille with original colors, for the most detail per character
+	"hi-fi-braille": {
+		Version:        SettingsVersion,
+		Braille:        ptr(true),
+		Dither:         ptr(true),
+		Colored:        ptr(true),
+		AutoLevels:     ptr(true),
+		ResampleFilter: ptr(imgManip.Lanczos),
+	},
+
+	// Logos and icons with transparent backgrounds, which are left empty on any background
+	"logo": {
+		Version:          SettingsVersion,
+		Colored:          ptr(true),
+		Complex:          ptr(true),
+		TransparentEmpty: ptr(true),
+		ResampleFilter:   ptr(imgManip.CellMean),
+	},
+
+	// Crisp pixel art, with each character's background filled with its color
+	"pixel-art": {
+		Version:             SettingsVersion,
+		Colored:             ptr(true),
+		CharBackgroundColor: ptr(true),
+		ResampleFilter:      ptr(imgManip.NearestNeighbor),
+	},
+}
+
+// Presets() returns the names of the built-in presets, sorted
+func Presets() []string {
+	var names []string
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Preset() returns the settings of the built-in preset with the given name
+func Preset(name string) (Settings, error) {
+	preset, ok := presets[name]
+	if !ok {
+		return Settings{}, fmt.Errorf("%w: unknown preset %q, must be one of %v", ErrInvalidSettings, name, Presets())
+	}
+
+	return preset, nil
+}
+
+/*
+ParseSettings() parses settings from JSON. Unknown fields are rejected rather than ignored, so typos
+don't go unnoticed, and so are settings without a version or of a newer version than SettingsVersion.
+The settings' values themselves are checked once they're applied, by Flags.Validate()
+*/
+func ParseSettings(data []byte) (Settings, error) {
+	var settings Settings
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(&settings); err != nil {
+		return Settings{}, fmt.Errorf("%w: %v", ErrInvalidSettings, err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return Settings{}, fmt.Errorf("%w: unexpected data after the settings object", ErrInvalidSettings)
+	}
+
+	if settings.Version == 0 {
+		return Settings{}, fmt.Errorf("%w: version is missing, the current version is %v", ErrInvalidSettings, SettingsVersion)
+	} else if settings.Version < 0 || settings.Version > SettingsVersion {
+		return Settings{}, fmt.Errorf("%w: version %v is unsupported, the current version is %v", ErrInvalidSettings, settings.Version, SettingsVersion)
+	}
+
+	if settings.Preset != "" {
+		if _, err := Preset(settings.Preset); err != nil {
+			return Settings{}, err
+		}
+	}
+
+	return settings, nil
+}
+
+// Apply() returns flags with the settings applied on top. The preset named by Settings.Preset is
+// applied first, then every field that's set
+func (settings Settings) Apply(flags Flags) (Flags, error) {
+	if settings.Preset != "" {
+		preset, err := Preset(settings.Preset)
+		if err != nil {
+			return flags, err
+		}
+
+		flags, err = preset.Apply(flags)
+		if err != nil {
+			return flags, err
+		}
+	}
+
+	set(&flags.Width, settings.Width)
+	set(&flags.Height, settings.Height)
+	set(&flags.FitMode, settings.FitMode)
+	set(&flags.Complex, settings.Complex)
+	set(&flags.Negative, settings.Negative)
+	set(&flags.Colored, settings.Colored)
+	set(&flags.CharBackgroundColor, settings.CharBackgroundColor)
+	set(&flags.Grayscale, settings.Grayscale)
+	set(&flags.CustomMap, settings.CustomMap)
+	set(&flags.FlipX, settings.FlipX)
+	set(&flags.FlipY, settings.FlipY)
+	set(&flags.FontColor, settings.FontColor)
+	set(&flags.Braille, settings.Braille)
+	set(&flags.Threshold, settings.Threshold)
+	set(&flags.Dither, settings.Dither)
+	set(&flags.ColorLevel, settings.ColorLevel)
+	set(&flags.Brightness, settings.Brightness)
+	set(&flags.Contrast, settings.Contrast)
+	set(&flags.Gamma, settings.Gamma)
+	set(&flags.AutoLevels, settings.AutoLevels)
+	set(&flags.Equalize, settings.Equalize)
+	set(&flags.CropPercent, settings.CropPercent)
+	set(&flags.Rotate, settings.Rotate)
+	set(&flags.RotateBackground, settings.RotateBackground)
+	set(&flags.IgnoreExif, settings.IgnoreExif)
+	set(&flags.TransparentEmpty, settings.TransparentEmpty)
+	set(&flags.ResampleFilter, settings.ResampleFilter)
+	set(&flags.CellAspect, settings.CellAspect)
+	set(&flags.CellWidth, settings.CellWidth)
+	set(&flags.CellHeight, settings.CellHeight)
+
+	if settings.Dimensions != nil {
+		flags.Dimensions = settings.Dimensions
+	}
+	if settings.Crop != nil {
+		flags.Crop = settings.Crop
+	}
+	if settings.AlphaBackground != nil {
+		flags.AlphaBackground = settings.AlphaBackground
+	}
+
+	return flags, nil
+}
+
+// Sets *field to *value, unless value is nil
+func set[T any](field *T, value *T) {
+	if value != nil {
+		*field = *value
+	}
+}
+
+// Settings() returns the flags' settings with every field set, so applying them reproduces the flags'
+// ascii art. Slices that are nil, such as an unset Flags.Dimensions, are left out
+func (flags Flags) Settings() Settings {
+	return Settings{
+		Version:             SettingsVersion,
+		Dimensions:          flags.Dimensions,
+		Width:               ptr(flags.Width),
+		Height:              ptr(flags.Height),
+		FitMode:             ptr(flags.FitMode),
+		Complex:             ptr(flags.Complex),
+		Negative:            ptr(flags.Negative),
+		Colored:             ptr(flags.Colored),
+		CharBackgroundColor: ptr(flags.CharBackgroundColor),
+		Grayscale:           ptr(flags.Grayscale),
+		CustomMap:           ptr(flags.CustomMap),
+		FlipX:               ptr(flags.FlipX),
+		FlipY:               ptr(flags.FlipY),
+		FontColor:           ptr(flags.FontColor),
+		Braille:             ptr(flags.Braille),
+		Threshold:           ptr(flags.Threshold),
+		Dither:              ptr(flags.Dither),
+		ColorLevel:          ptr(flags.ColorLevel),
+		Brightness:          ptr(flags.Brightness),
+		Contrast:            ptr(flags.Contrast),
+		Gamma:               ptr(flags.Gamma),
+		AutoLevels:          ptr(flags.AutoLevels),
+		Equalize:            ptr(flags.Equalize),
+		Crop:                flags.Crop,
+		CropPercent:         ptr(flags.CropPercent),
+		Rotate:              ptr(flags.Rotate),
+		RotateBackground:    ptr(flags.RotateBackground),
+		IgnoreExif:          ptr(flags.IgnoreExif),
+		AlphaBackground:     flags.AlphaBackground,
+		TransparentEmpty:    ptr(flags.TransparentEmpty),
+		ResampleFilter:      ptr(flags.ResampleFilter),
+		CellAspect:          ptr(flags.CellAspect),
+		CellWidth:           ptr(flags.CellWidth),
+		CellHeight:          ptr(flags.CellHeight),
+	}
+}
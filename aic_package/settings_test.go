@@ -0,0 +1,207 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	imgManip "github.com/Ares1605/ascii-image-converter-wasm/image_manipulation"
+)
+
+func TestParseSettings(t *testing.T) {
+	tests := []struct {
+		name  string
+		json  string
+		valid bool
+	}{
+		{"version only", `{"version":1}`, true},
+		{"fields and a preset", `{"version":1,"preset":"logo","width":40,"fontColor":[1,2,3]}`, true},
+		{"surrounding whitespace", " \n{\"version\":1}\n", true},
+		{"missing version", `{"width":40}`, false},
+		{"newer version", `{"version":2}`, false},
+		{"negative version", `{"version":-1}`, false},
+		{"unknown field", `{"version":1,"widht":40}`, false},
+		{"field left out of settings", `{"version":1,"maxPixels":0}`, false},
+		{"wrong type", `{"version":1,"width":"40"}`, false},
+		{"unknown preset", `{"version":1,"preset":"neon"}`, false},
+		{"data after the object", `{"version":1}{"version":1}`, false},
+		{"not an object", `[1]`, false},
+		{"empty", ``, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseSettings([]byte(test.json))
+
+			if test.valid && err != nil {
+				t.Errorf("got error %v for valid settings", err)
+			} else if !test.valid && !errors.Is(err, ErrInvalidSettings) {
+				t.Errorf("got error %v, want ErrInvalidSettings", err)
+			}
+		})
+	}
+}
+
+func TestSettingsApply(t *testing.T) {
+	base := DefaultFlags()
+	base.Width = 80
+	base.Negative = true
+	base.Colored = true
+
+	tests := []struct {
+		name   string
+		json   string
+		modify func(flags *Flags)
+	}{
+		{"nothing set", `{"version":1}`, func(flags *Flags) {}},
+		{"fields set", `{"version":1,"width":40,"negative":false,"crop":[1,2,3,4]}`, func(flags *Flags) {
+			flags.Width, flags.Negative, flags.Crop = 40, false, []float64{1, 2, 3, 4}
+		}},
+		{"preset", `{"version":1,"preset":"retro-green"}`, func(flags *Flags) {
+			flags.Colored, flags.FontColor = false, [3]int{51, 255, 51}
+		}},
+		{"fields override the preset", `{"version":1,"preset":"retro-green","fontColor":[1,2,3],"colored":true}`, func(flags *Flags) {
+			flags.FontColor = [3]int{1, 2, 3}
+		}},
+		{"fields override the preset regardless of their order", `{"fontColor":[1,2,3],"preset":"retro-green","version":1}`, func(flags *Flags) {
+			flags.Colored, flags.FontColor = false, [3]int{1, 2, 3}
+		}},
+		{"preset field turned off", `{"version":1,"preset":"hi-fi-braille","dither":false}`, func(flags *Flags) {
+			flags.Braille, flags.AutoLevels, flags.ResampleFilter = true, true, imgManip.Lanczos
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			settings, err := ParseSettings([]byte(test.json))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := settings.Apply(base)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := base
+			test.modify(&want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got flags\n%+v\nwant\n%+v", got, want)
+			}
+		})
+	}
+}
+
+func TestPresetsAreValid(t *testing.T) {
+	for _, name := range Presets() {
+		t.Run(name, func(t *testing.T) {
+			flags, err := Settings{Version: SettingsVersion, Preset: name}.Apply(DefaultFlags())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			flags.Width = 80
+			if err := flags.Validate(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestSettingsRoundTrip(t *testing.T) {
+	// Every field differs from DefaultFlags(), so a field Settings misses can't go unnoticed
+	flags := Flags{
+		Dimensions:          []int{40, 20},
+		Width:               30,
+		Height:              15,
+		FitMode:             imgManip.FitFill,
+		Complex:             true,
+		Negative:            true,
+		Colored:             true,
+		CharBackgroundColor: true,
+		Grayscale:           true,
+		CustomMap:           " .#",
+		FlipX:               true,
+		FlipY:               true,
+		FontColor:           [3]int{1, 2, 3},
+		Braille:             true,
+		Threshold:           100,
+		Dither:              true,
+		ColorLevel:          imgManip.Hundreds,
+		Brightness:          10,
+		Contrast:            -10,
+		Gamma:               1.5,
+		AutoLevels:          true,
+		Equalize:            true,
+		Crop:                []float64{10, 10, 50, 50},
+		CropPercent:         true,
+		Rotate:              90,
+		RotateBackground:    [3]int{4, 5, 6},
+		IgnoreExif:          true,
+		AlphaBackground:     []int{7, 8, 9},
+		TransparentEmpty:    true,
+		ResampleFilter:      imgManip.NearestNeighbor,
+		CellAspect:          0.45,
+		CellWidth:           9,
+		CellHeight:          20,
+	}
+
+	// Fields of the host rather than the settings keep their values from the flags applied to
+	defaults := DefaultFlags()
+	flags.JsonOutput = defaults.JsonOutput
+	flags.MaxInputBytes = defaults.MaxInputBytes
+	flags.MaxPixels = defaults.MaxPixels
+	flags.FrameRate = defaults.FrameRate
+
+	excluded := map[string]bool{"JsonOutput": true, "MaxInputBytes": true, "MaxPixels": true, "FrameRate": true}
+	value, defaultValue := reflect.ValueOf(flags), reflect.ValueOf(defaults)
+	for i := range value.NumField() {
+		name := value.Type().Field(i).Name
+		if !excluded[name] && reflect.DeepEqual(value.Field(i).Interface(), defaultValue.Field(i).Interface()) {
+			t.Fatalf("field %v of the test flags is the default, set it to test its round trip", name)
+		}
+	}
+
+	marshalled, err := json.Marshal(flags.Settings())
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings, err := ParseSettings(marshalled)
+	if err != nil {
+		t.Fatalf("settings %s don't parse: %v", marshalled, err)
+	}
+
+	got, err := settings.Apply(DefaultFlags())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, flags) {
+		t.Errorf("got flags\n%+v\nwant\n%+v", got, flags)
+	}
+
+	// Default flags round trip without their nil slices turning into empty ones
+	got, err = DefaultFlags().Settings().Apply(DefaultFlags())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, DefaultFlags()) {
+		t.Errorf("got flags\n%+v\nwant\n%+v", got, DefaultFlags())
+	}
+}
@@ -0,0 +1,170 @@
+//go:build !js && !wasip1
+
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"golang.org/x/term"
+)
+
+/*
+EnterPlaybackScreen() prepares the terminal f for playing frames with Play(). The alternate screen
+buffer is entered, so playback doesn't scroll away the terminal's contents, and the cursor is hidden.
+On Windows, processing of ANSI escape codes is enabled for the console.
+
+The returned function restores the terminal, and must be called once playback has finished. The
+terminal is also restored if the process is interrupted by SIGINT or SIGTERM, after which it exits.
+Nothing is done if f isn't a terminal, e.g. when output is redirected to a file.
+*/
+func EnterPlaybackScreen(f *os.File) (restore func()) {
+	if !term.IsTerminal(int(f.Fd())) {
+		return func() {}
+	}
+
+	return enterPlaybackScreen(f, func() {})
+}
+
+/*
+EnterInteractivePlayback() prepares the terminal f like EnterPlaybackScreen(), and reads keys pressed
+on the controlling terminal as controls for PlayWithOptions(). Keys are read from the terminal itself
+rather than stdin, which is usually piped input, with the terminal's input in raw mode:
+
+	Space                Pause or resume
+	Left, Right arrows   Step a frame backward or forward
+	+, -                 Speed up or slow down
+	c, n                 Toggle colors or negative
+	q, Ctrl-C            Quit
+
+The returned function restores the terminal's screen and input mode. An error is returned if f isn't
+a terminal or the controlling terminal can't be opened
+*/
+func EnterInteractivePlayback(f *os.File) (controls <-chan PlaybackControl, restore func(), err error) {
+	if !term.IsTerminal(int(f.Fd())) {
+		return nil, nil, errors.New("output is not a terminal")
+	}
+
+	tty, err := openTerminalInput()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The file descriptor is only used through its raw connection, since calling Fd() would
+	// put the file in blocking mode and closing it wouldn't interrupt reading keys
+	conn, err := tty.SyscallConn()
+	if err != nil {
+		tty.Close()
+		return nil, nil, err
+	}
+
+	var state *term.State
+	if controlErr := conn.Control(func(fd uintptr) {
+		state, err = term.MakeRaw(int(fd))
+	}); controlErr != nil {
+		err = controlErr
+	}
+	if err != nil {
+		tty.Close()
+		return nil, nil, err
+	}
+
+	keys := make(chan PlaybackControl, 16)
+
+	go func() {
+		defer close(keys)
+
+		buffer := make([]byte, 64)
+		for {
+			n, err := tty.Read(buffer)
+
+			for _, control := range parsePlaybackKeys(buffer[:n]) {
+				// Keys pressed faster than they're handled are dropped
+				select {
+				case keys <- control:
+				default:
+				}
+			}
+
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	restore = enterPlaybackScreen(f, func() {
+		conn.Control(func(fd uintptr) {
+			term.Restore(int(fd), state)
+		})
+		tty.Close()
+	})
+
+	return keys, restore, nil
+}
+
+// Enters the alternate screen on the terminal f, returning a function restoring it along with
+// restoreInput, which is called on SIGINT and SIGTERM as well
+func enterPlaybackScreen(f *os.File, restoreInput func()) (restore func()) {
+	restoreConsole := enableVirtualTerminal(f)
+	io.WriteString(f, enterAlternateScreen+hideCursor)
+
+	var once sync.Once
+	restoreScreen := func() {
+		once.Do(func() {
+			io.WriteString(f, showCursor+exitAlternateScreen)
+			restoreConsole()
+			restoreInput()
+		})
+	}
+
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case sig := <-signals:
+			restoreScreen()
+
+			// Exit statuses of processes killed by a signal are 128 plus the signal's number
+			status := 130
+			if sig == syscall.SIGTERM {
+				status = 143
+			}
+			os.Exit(status)
+
+		case <-done:
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
+		restoreScreen()
+	}
+}
+
+func IsInputFromPipe() bool {
+	fileInfo, _ := os.Stdin.Stat()
+	return fileInfo.Mode()&os.ModeCharDevice == 0
+}
@@ -0,0 +1,33 @@
+//go:build !windows && !js && !wasip1
+
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"os"
+)
+
+// Terminals other than the Windows console process ANSI escape codes as is
+func enableVirtualTerminal(f *os.File) (restore func()) {
+	return func() {}
+}
+
+// Opens the controlling terminal for reading keys, even when stdin is piped
+func openTerminalInput() (*os.File, error) {
+	return os.OpenFile("/dev/tty", os.O_RDWR, 0)
+}
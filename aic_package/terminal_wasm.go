@@ -0,0 +1,46 @@
+//go:build js || wasip1
+
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// EnterPlaybackScreen() prepares the terminal f for playing frames with Play(), entering the
+// alternate screen buffer and hiding the cursor. WASM hosts can't deliver signals, so the returned
+// function, which restores the terminal, is only called once playback has finished
+func EnterPlaybackScreen(f *os.File) (restore func()) {
+	io.WriteString(f, enterAlternateScreen+hideCursor)
+
+	return func() {
+		io.WriteString(f, showCursor+exitAlternateScreen)
+	}
+}
+
+// EnterInteractivePlayback() always returns an error, since WASM hosts have no terminal to read keys from
+func EnterInteractivePlayback(f *os.File) (controls <-chan PlaybackControl, restore func(), err error) {
+	return nil, nil, errors.New("interactive playback is not supported")
+}
+
+// WASM hosts don't report stdin as a character device, so input is always read from it
+func IsInputFromPipe() bool {
+	return true
+}
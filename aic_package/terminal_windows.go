@@ -0,0 +1,49 @@
+//go:build windows
+
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// Enables processing of ANSI escape codes by the Windows console f, which older consoles don't
+// do by default. Returns a function restoring the console's previous mode
+func enableVirtualTerminal(f *os.File) (restore func()) {
+	handle := windows.Handle(f.Fd())
+
+	var mode uint32
+	if err := windows.GetConsoleMode(handle, &mode); err != nil {
+		return func() {}
+	}
+
+	if err := windows.SetConsoleMode(handle, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING); err != nil {
+		return func() {}
+	}
+
+	return func() {
+		windows.SetConsoleMode(handle, mode)
+	}
+}
+
+// Opens the console's input for reading keys, even when stdin is piped
+func openTerminalInput() (*os.File, error) {
+	return os.OpenFile("CONIN$", os.O_RDWR, 0)
+}
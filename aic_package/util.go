@@ -17,11 +17,8 @@ limitations under the License.
 package aic_package
 
 import (
-	"fmt"
-	"os"
+	"image"
 	"strings"
-	"os/exec"
-	"runtime"
 
 	gookitColor "github.com/gookit/color"
 	imgManip "github.com/Ares1605/ascii-image-converter-wasm/image_manipulation"
@@ -29,16 +26,18 @@ import (
 
 // flattenToAscii flattens a two-dimensional grid of ascii characters into a string
 // of ascii, with ANSI color codes
-func flattenToAscii(asciiSet [][]imgManip.AsciiChar, colored bool) string {
+func (c *converter) flattenToAscii(asciiSet [][]imgManip.AsciiChar, colored bool) string {
 	var ascii []string
 
 	for _, line := range asciiSet {
 		var tempAscii string
 
 		for _, char := range line {
-			if colored {
+			if c.transparentEmpty && char.Transparent {
+				tempAscii += " "
+			} else if colored {
 				tempAscii += char.OriginalColor
-			} else if fontColor != [3]int{255, 255, 255} {
+			} else if c.fontColor != [3]int{255, 255, 255} {
 				tempAscii += char.SetColor
 			} else {
 				tempAscii += char.Simple
@@ -58,19 +57,24 @@ type ColoredChar struct {
 
 // flattenToJSONable flattens the asciiSet by simplifying the set to only what's required in understanding
 // each character and it's respective color
-func flattenToJSONable(asciiSet [][]imgManip.AsciiChar, colored bool) [][]ColoredChar {
+func (c *converter) flattenToJSONable(asciiSet [][]imgManip.AsciiChar, colored bool) [][]ColoredChar {
 	simplified := make([][]ColoredChar, len(asciiSet))
 
 	for i, line := range asciiSet {
 		simplifiedLine := make([]ColoredChar, len(asciiSet[i]))
 
 		for i, char := range line {
-			if colored {
+			if c.transparentEmpty && char.Transparent {
+				simplifiedLine[i] = ColoredChar{
+					Char:     " ",
+					RGBColor: nil,
+				}
+			} else if colored {
 				simplifiedLine[i] = ColoredChar{
 					Char: char.Simple,
 					RGBColor: &char.OriginalColorRGB,
 				}
-			} else if fontColor != [3]int{255, 255, 255} {
+			} else if c.fontColor != [3]int{255, 255, 255} {
 				simplifiedLine[i] = ColoredChar{
 					Char: char.Simple,
 					RGBColor: &char.SetColorRGB,
@@ -92,35 +96,20 @@ func flattenToJSONable(asciiSet [][]imgManip.AsciiChar, colored bool) [][]Colore
 	return simplified
 }
 
-// Following is for clearing screen when showing gif
-var clear map[string]func()
-
-func init() {
-	clear = make(map[string]func())
-	clear["linux"] = func() {
-		cmd := exec.Command("clear")
-		cmd.Stdout = os.Stdout
-		cmd.Run()
+// prepareImage runs the transform (crop, rotate) and tonal adjustment stages on a decoded
+// image, ahead of it being resized and sampled for ascii conversion
+func (c *converter) prepareImage(img image.Image) (image.Image, error) {
+	if c.alphaBg != nil {
+		img = imgManip.CompositeOnto(img, [3]int{c.alphaBg[0], c.alphaBg[1], c.alphaBg[2]})
 	}
-	clear["windows"] = func() {
-		cmd := exec.Command("cmd", "/c", "cls")
-		cmd.Stdout = os.Stdout
-		cmd.Run()
-	}
-	clear["darwin"] = clear["linux"]
-}
 
-func clearScreen() {
-	value, ok := clear[runtime.GOOS]
-	if ok {
-		value()
-	} else {
-		fmt.Println("Error: your platform is unsupported, terminal can't be cleared")
-		os.Exit(0)
+	img, err := imgManip.CropImage(img, c.crop, c.cropPercent)
+	if err != nil {
+		return nil, err
 	}
-}
 
-func IsInputFromPipe() bool {
-	fileInfo, _ := os.Stdin.Stat()
-	return fileInfo.Mode()&os.ModeCharDevice == 0
+	img = imgManip.RotateImage(img, c.rotate, c.rotateBg)
+	img = imgManip.AdjustImage(img, c.brightness, c.contrast, c.gamma, c.autoLevels, c.equalize)
+
+	return img, nil
 }
@@ -0,0 +1,187 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"unicode/utf8"
+
+	imgManip "github.com/Ares1605/ascii-image-converter-wasm/image_manipulation"
+)
+
+/*
+Validate() checks the flags for invalid or conflicting values, without looking at any input.
+It returns nil if they're valid, and otherwise every problem found, joined with errors.Join().
+Each of them is a *FlagError, which can be retrieved with errors.As().
+
+All Convert functions call Validate() before converting, so calling it beforehand is only
+needed to report errors early, e.g. when parsing user input.
+*/
+func (flags Flags) Validate() error {
+	var errs []error
+
+	invalid := func(field string, err error) {
+		errs = append(errs, &FlagError{Field: field, Err: err})
+	}
+
+	if flags.Dimensions != nil {
+		if len(flags.Dimensions) != 2 {
+			invalid("Dimensions", fmt.Errorf("%w: requires 2 values (width, height), got %v", ErrInvalidDimensions, len(flags.Dimensions)))
+		} else if flags.Dimensions[0] < 1 || flags.Dimensions[1] < 1 {
+			invalid("Dimensions", fmt.Errorf("%w: values must be greater than 0", ErrInvalidDimensions))
+		}
+	} else {
+		if flags.Width < 0 {
+			invalid("Width", fmt.Errorf("%w: can't be negative", ErrInvalidDimensions))
+		}
+		if flags.Height < 0 {
+			invalid("Height", fmt.Errorf("%w: can't be negative", ErrInvalidDimensions))
+		}
+
+		switch flags.FitMode {
+		case imgManip.FitNone:
+			if flags.Width == 0 && flags.Height == 0 {
+				invalid("Dimensions", fmt.Errorf("%w: either dimensions, width, or height must be set", ErrInvalidDimensions))
+			} else if flags.Width != 0 && flags.Height != 0 {
+				invalid("Width", fmt.Errorf("%w: width and height can't both be set without a fit mode", ErrInvalidDimensions))
+			}
+		case imgManip.FitWithin, imgManip.FitFill:
+			if flags.Width == 0 || flags.Height == 0 {
+				invalid("FitMode", fmt.Errorf("%w: requires both width and height to be set", ErrInvalidDimensions))
+			}
+		default:
+			invalid("FitMode", fmt.Errorf("unknown fit mode %q", flags.FitMode))
+		}
+	}
+
+	if flags.CustomMap != "" && utf8.RuneCountInString(flags.CustomMap) < 2 {
+		invalid("CustomMap", errors.New("requires at least 2 characters"))
+	}
+
+	if err := validateRGB(flags.FontColor[:]); err != nil {
+		invalid("FontColor", err)
+	}
+
+	if flags.Threshold < 0 || flags.Threshold > 255 {
+		invalid("Threshold", errors.New("must be between 0 and 255"))
+	}
+
+	if flags.Dither && !flags.Braille {
+		invalid("Dither", errors.New("only supported with braille art"))
+	}
+
+	if flags.ColorLevel != 0 && flags.ColorLevel != imgManip.Millions && flags.ColorLevel != imgManip.Hundreds {
+		invalid("ColorLevel", fmt.Errorf("%w: %d-bit", ErrUnsupportedColorLevel, flags.ColorLevel))
+	}
+
+	if !inRange(flags.Brightness, -100, 100) {
+		invalid("Brightness", errors.New("must be between -100 and 100"))
+	}
+
+	if !inRange(flags.Contrast, -100, 100) {
+		invalid("Contrast", errors.New("must be between -100 and 100"))
+	}
+
+	if !inRange(flags.Gamma, 0, math.MaxFloat64) {
+		invalid("Gamma", errors.New("can't be negative"))
+	}
+
+	if flags.Crop != nil {
+		if len(flags.Crop) != 4 {
+			invalid("Crop", fmt.Errorf("requires 4 values (x, y, width, height), got %v", len(flags.Crop)))
+		} else if !inRange(flags.Crop[0], 0, math.MaxFloat64) || !inRange(flags.Crop[1], 0, math.MaxFloat64) || !(flags.Crop[2] > 0) || !(flags.Crop[3] > 0) {
+			invalid("Crop", errors.New("x and y can't be negative, and width and height must be greater than 0"))
+		} else if flags.CropPercent && (flags.Crop[0]+flags.Crop[2] > 100 || flags.Crop[1]+flags.Crop[3] > 100) {
+			invalid("Crop", errors.New("region percentages can't exceed 100"))
+		}
+	}
+
+	if math.IsNaN(flags.Rotate) || math.IsInf(flags.Rotate, 0) {
+		invalid("Rotate", errors.New("must be a finite number"))
+	}
+
+	if err := validateRGB(flags.RotateBackground[:]); err != nil {
+		invalid("RotateBackground", err)
+	}
+
+	if flags.AlphaBackground != nil {
+		if err := validateRGB(flags.AlphaBackground); err != nil {
+			invalid("AlphaBackground", err)
+		}
+	}
+
+	if flags.ResampleFilter != "" && !isResampleFilter(flags.ResampleFilter) {
+		invalid("ResampleFilter", fmt.Errorf("unknown resample filter %q", flags.ResampleFilter))
+	}
+
+	if !inRange(flags.CellAspect, 0, math.MaxFloat64) {
+		invalid("CellAspect", errors.New("can't be negative"))
+	}
+
+	if !inRange(flags.CellWidth, 0, math.MaxFloat64) {
+		invalid("CellWidth", errors.New("can't be negative"))
+	}
+
+	if !inRange(flags.CellHeight, 0, math.MaxFloat64) {
+		invalid("CellHeight", errors.New("can't be negative"))
+	}
+
+	if flags.MaxInputBytes < 0 {
+		invalid("MaxInputBytes", errors.New("can't be negative"))
+	}
+
+	if flags.MaxPixels < 0 {
+		invalid("MaxPixels", errors.New("can't be negative"))
+	}
+
+	if !inRange(flags.FrameRate, 0, math.MaxFloat64) {
+		invalid("FrameRate", errors.New("can't be negative"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Checks that rgb has 3 values between 0 and 255
+func validateRGB(rgb []int) error {
+	if len(rgb) != 3 {
+		return fmt.Errorf("requires 3 values for RGB, got %v", len(rgb))
+	}
+
+	for _, value := range rgb {
+		if value < 0 || value > 255 {
+			return errors.New("RGB values must be between 0 and 255")
+		}
+	}
+
+	return nil
+}
+
+// Reports whether value lies within [min, max]. NaN is never in range
+func inRange(value, min, max float64) bool {
+	return value >= min && value <= max
+}
+
+func isResampleFilter(filter imgManip.ResampleFilter) bool {
+	for _, supportedFilter := range imgManip.ResampleFilters() {
+		if filter == supportedFilter {
+			return true
+		}
+	}
+	return false
+}
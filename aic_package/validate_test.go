@@ -0,0 +1,161 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aic_package
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+
+	imgManip "github.com/Ares1605/ascii-image-converter-wasm/image_manipulation"
+)
+
+// Returns the fields named by the FlagErrors joined in err, failing if any error isn't one
+func invalidFields(t *testing.T, err error) []string {
+	t.Helper()
+
+	if err == nil {
+		return nil
+	}
+
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+
+	var fields []string
+	for _, err := range errs {
+		var flagErr *FlagError
+		if !errors.As(err, &flagErr) {
+			t.Fatalf("got error %v, want a *FlagError", err)
+		}
+		fields = append(fields, flagErr.Field)
+	}
+	return fields
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(flags *Flags)
+		field  string
+		err    error
+	}{
+		{"default flags with a width", func(flags *Flags) {}, "", nil},
+		{"height only", func(flags *Flags) { flags.Width, flags.Height = 0, 40 }, "", nil},
+		{"smallest dimensions", func(flags *Flags) { flags.Dimensions = []int{1, 1} }, "", nil},
+		{"fit within width and height", func(flags *Flags) { flags.Height, flags.FitMode = 40, imgManip.FitWithin }, "", nil},
+		{"color level 0 for the default", func(flags *Flags) { flags.ColorLevel = 0 }, "", nil},
+		{"256 colors", func(flags *Flags) { flags.ColorLevel = imgManip.Hundreds }, "", nil},
+		{"2 rune map of multibyte characters", func(flags *Flags) { flags.CustomMap = "░█" }, "", nil},
+		{"brightness of 100", func(flags *Flags) { flags.Brightness = 100 }, "", nil},
+		{"brightness of -100", func(flags *Flags) { flags.Brightness = -100 }, "", nil},
+		{"contrast of -100", func(flags *Flags) { flags.Contrast = -100 }, "", nil},
+		{"threshold of 0", func(flags *Flags) { flags.Threshold = 0 }, "", nil},
+		{"threshold of 255", func(flags *Flags) { flags.Threshold = 255 }, "", nil},
+		{"gamma of 0", func(flags *Flags) { flags.Gamma = 0 }, "", nil},
+		{"dither with braille", func(flags *Flags) { flags.Braille, flags.Dither = true, true }, "", nil},
+		{"crop covering 100 percent", func(flags *Flags) { flags.Crop, flags.CropPercent = []float64{25, 0, 75, 100}, true }, "", nil},
+		{"rotation past a full turn", func(flags *Flags) { flags.Rotate = -450 }, "", nil},
+		{"no resample filter", func(flags *Flags) { flags.ResampleFilter = "" }, "", nil},
+
+		{"no size", func(flags *Flags) { flags.Width = 0 }, "Dimensions", ErrInvalidDimensions},
+		{"1 dimension", func(flags *Flags) { flags.Dimensions = []int{80} }, "Dimensions", ErrInvalidDimensions},
+		{"dimension of 0", func(flags *Flags) { flags.Dimensions = []int{80, 0} }, "Dimensions", ErrInvalidDimensions},
+		{"negative width", func(flags *Flags) { flags.Width = -1 }, "Width", ErrInvalidDimensions},
+		{"negative height", func(flags *Flags) { flags.Width, flags.Height = 0, -1 }, "Height", ErrInvalidDimensions},
+		{"width and height without a fit mode", func(flags *Flags) { flags.Height = 40 }, "Width", ErrInvalidDimensions},
+		{"fit mode without a height", func(flags *Flags) { flags.FitMode = imgManip.FitFill }, "FitMode", ErrInvalidDimensions},
+		{"unknown fit mode", func(flags *Flags) { flags.FitMode = "stretch" }, "FitMode", nil},
+		{"1 rune map", func(flags *Flags) { flags.CustomMap = "█" }, "CustomMap", nil},
+		{"font color over 255", func(flags *Flags) { flags.FontColor = [3]int{0, 256, 0} }, "FontColor", nil},
+		{"threshold over 255", func(flags *Flags) { flags.Threshold = 256 }, "Threshold", nil},
+		{"negative threshold", func(flags *Flags) { flags.Threshold = -1 }, "Threshold", nil},
+		{"dither without braille", func(flags *Flags) { flags.Dither = true }, "Dither", nil},
+		{"unsupported color level", func(flags *Flags) { flags.ColorLevel = 4 }, "ColorLevel", ErrUnsupportedColorLevel},
+		{"brightness over 100", func(flags *Flags) { flags.Brightness = 100.5 }, "Brightness", nil},
+		{"brightness under -100", func(flags *Flags) { flags.Brightness = -100.5 }, "Brightness", nil},
+		{"NaN brightness", func(flags *Flags) { flags.Brightness = math.NaN() }, "Brightness", nil},
+		{"contrast over 100", func(flags *Flags) { flags.Contrast = 101 }, "Contrast", nil},
+		{"negative gamma", func(flags *Flags) { flags.Gamma = -1 }, "Gamma", nil},
+		{"crop of 3 values", func(flags *Flags) { flags.Crop = []float64{0, 0, 10} }, "Crop", nil},
+		{"crop of width 0", func(flags *Flags) { flags.Crop = []float64{0, 0, 0, 10} }, "Crop", nil},
+		{"negative crop offset", func(flags *Flags) { flags.Crop = []float64{-1, 0, 10, 10} }, "Crop", nil},
+		{"crop past 100 percent", func(flags *Flags) { flags.Crop, flags.CropPercent = []float64{50, 0, 51, 100}, true }, "Crop", nil},
+		{"infinite rotation", func(flags *Flags) { flags.Rotate = math.Inf(1) }, "Rotate", nil},
+		{"negative rotate background", func(flags *Flags) { flags.RotateBackground = [3]int{-1, 0, 0} }, "RotateBackground", nil},
+		{"alpha background of 2 values", func(flags *Flags) { flags.AlphaBackground = []int{0, 0} }, "AlphaBackground", nil},
+		{"unknown resample filter", func(flags *Flags) { flags.ResampleFilter = "bicubic" }, "ResampleFilter", nil},
+		{"negative cell aspect", func(flags *Flags) { flags.CellAspect = -0.5 }, "CellAspect", nil},
+		{"negative cell width", func(flags *Flags) { flags.CellWidth = -1 }, "CellWidth", nil},
+		{"negative cell height", func(flags *Flags) { flags.CellHeight = -1 }, "CellHeight", nil},
+		{"negative input limit", func(flags *Flags) { flags.MaxInputBytes = -1 }, "MaxInputBytes", nil},
+		{"negative pixel limit", func(flags *Flags) { flags.MaxPixels = -1 }, "MaxPixels", nil},
+		{"negative frame rate", func(flags *Flags) { flags.FrameRate = -1 }, "FrameRate", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			flags := DefaultFlags()
+			flags.Width = 80
+			test.modify(&flags)
+
+			err := flags.Validate()
+
+			fields := invalidFields(t, err)
+			if test.field == "" {
+				if err != nil {
+					t.Fatalf("got error %v for valid flags", err)
+				}
+				return
+			}
+			if !slices.Equal(fields, []string{test.field}) {
+				t.Fatalf("got errors for %v, want only %v: %v", fields, test.field, err)
+			}
+
+			if test.err != nil && !errors.Is(err, test.err) {
+				t.Errorf("got error %v, want it to wrap %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestValidateReportsEveryField(t *testing.T) {
+	flags := DefaultFlags()
+	flags.Dimensions = []int{0, 0}
+	flags.Threshold = 300
+	flags.Dither = true
+	flags.MaxPixels = -1
+
+	err := flags.Validate()
+
+	want := []string{"Dimensions", "Threshold", "Dither", "MaxPixels"}
+	if fields := invalidFields(t, err); !slices.Equal(fields, want) {
+		t.Errorf("got errors for %v, want %v", fields, want)
+	}
+
+	// The joined error still matches the sentinel wrapped by one of its errors
+	if !errors.Is(err, ErrInvalidDimensions) {
+		t.Errorf("got error %v, want it to wrap ErrInvalidDimensions", err)
+	}
+
+	// Converting with invalid flags fails with the same errors before reading the input
+	if _, convertErr := Convert(nil, flags); convertErr == nil || convertErr.Error() != err.Error() {
+		t.Errorf("Convert() returned %v, want %v", convertErr, err)
+	}
+}
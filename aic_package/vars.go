@@ -27,13 +27,20 @@ type Flags struct {
 	Dimensions []int
 
 	// Set width of ascii art while calculating height from aspect ratio.
-	// Setting this along with Flags.Height will throw an error
+	// Setting this along with Flags.Height will throw an error, unless Flags.FitMode is set
 	Width int
 
 	// Set height of ascii art while calculating width from aspect ratio.
-	// Setting this along with Flags.Width will throw an error
+	// Setting this along with Flags.Width will throw an error, unless Flags.FitMode is set
 	Height int
 
+	// Decide how the image is sized when both Flags.Width and Flags.Height are set.
+	// image_conversions.FitWithin treats them as a maximum box and scales the art to the
+	// largest size that keeps the aspect ratio. image_conversions.FitFill covers the whole
+	// box, cropping the image around its center.
+	// This is ignored if Flags.Dimensions is set
+	FitMode image_conversions.FitMode
+
 	// Use set of 69 characters instead of the default 10
 	Complex bool
 	// Invert ascii art character mapping as well as colors
@@ -69,7 +76,7 @@ type Flags struct {
 	// ANSI escape codes are stripped as "ASCII" characters.
 	//
 	// e.g. "--json"
-	// [{ "char": "-", "col": [255, 255, 255] }, { "char": "+", "col": [0, 255, 255] }] 
+	// [{ "char": "-", "col": [255, 255, 255] }, { "char": "+", "col": [0, 255, 255] }]
 	JsonOutput bool
 
 	// Font RGB color for terminal display.
@@ -86,30 +93,135 @@ type Flags struct {
 	Threshold int
 
 	// Apply FloydSteinberg dithering on an image before ascii conversion. This option
-	// is meant for braille art. Therefore, it will be ignored if Flags.Braille is false
+	// is meant for braille art. Therefore, it requires Flags.Braille to be set
 	Dither bool
 
-	// The color level (8-bit or 24-bit) that we're targetting
+	// The color level (8-bit or 24-bit) that we're targetting. 0 uses 24-bit
 	ColorLevel image_conversions.ColorLevel
+
+	// Adjust brightness of the image before conversion. Value provided must be
+	// between -100 and 100. 0 leaves the image unchanged
+	Brightness float64
+
+	// Adjust contrast of the image before conversion. Value provided must be
+	// between -100 and 100. 0 leaves the image unchanged
+	Contrast float64
+
+	// Apply gamma correction before conversion. Values below 1 darken the image
+	// and values above 1 lighten it. 0 and 1 leave the image unchanged
+	Gamma float64
+
+	// Stretch the image's levels so that its 1st and 99th luminance percentiles
+	// span the full range. Useful for dark or washed out images
+	AutoLevels bool
+
+	// Apply contrast limited adaptive histogram equalization, which brings out
+	// detail in dark and bright regions separately
+	Equalize bool
+
+	// Crop the image to a region before conversion. Accepts a slice of 4 values,
+	// x, y, width and height, e.g. []float64{10, 10, 200, 100}.
+	// Values are in pixels unless Flags.CropPercent is set
+	Crop []float64
+
+	// Treat the values of Flags.Crop as percentages (0-100) of the image's
+	// width and height instead of pixels
+	CropPercent bool
+
+	// Rotate the image clockwise by the given degrees before conversion.
+	// Multiples of 90 are rotated losslessly
+	Rotate float64
+
+	// RGB color used to fill the corners uncovered when rotating by an angle
+	// that isn't a multiple of 90
+	RotateBackground [3]int
+
+	// Ignore the EXIF orientation tag of JPEG and TIFF input. By default, the
+	// image is rotated and flipped to be displayed upright, like photo viewers do
+	IgnoreExif bool
+
+	// Composite transparent and translucent areas of the image onto an RGB
+	// background color before conversion, e.g. []int{255, 255, 255}.
+	// If nil, transparent areas are read as black
+	AlphaBackground []int
+
+	// Treat characters computed from fully transparent pixels as empty. They're
+	// displayed as a space with no color, and with a null color in JSON output.
+	// This has no effect if Flags.AlphaBackground is set
+	TransparentEmpty bool
+
+	// Filter used when shrinking the image to the size of the ascii art.
+	// Defaults to image_conversions.Lanczos if empty. image_conversions.NearestNeighbor
+	// keeps pixel art crisp, while image_conversions.CellMean averages the exact source
+	// pixels each character covers and is several times faster than Lanczos on huge inputs
+	ResampleFilter image_conversions.ResampleFilter
+
+	// Width to height ratio of a character cell in the font the ascii art is displayed
+	// with. Used to keep the image's aspect ratio when only Flags.Width or Flags.Height
+	// is set. If 0, it's computed from Flags.CellWidth and Flags.CellHeight when both are
+	// set, or else image_conversions.DefaultCellAspect of 0.5 is used, since most terminal
+	// fonts are about twice as tall as they're wide. Any other value is used as is
+	CellAspect float64
+
+	// Font metrics of a single character cell, in any unit (e.g. pixels measured on a
+	// browser canvas). If both are set and Flags.CellAspect is 0, the cell aspect ratio
+	// is computed from them
+	CellWidth  float64
+	CellHeight float64
+
+	// Maximum size of input read by ConvertReader() and ReadInput(), in bytes, or of
+	// each MJPEG frame read by ConvertVideo(). 0 means no limit
+	MaxInputBytes int64
+
+	// Maximum number of pixels (width times height) of the input image, or of all frames of an
+	// animation combined, each counted at the canvas' size. It's checked from the image header
+	// before decoding the whole image, to protect against decompression bombs. 0 means no limit
+	MaxPixels int
+
+	// Frame rate of video input read by ConvertVideo(), in frames per second. If 0, the
+	// rate in the stream's header is used, falling back to DefaultFrameRate
+	// for MJPEG streams, which have no header
+	FrameRate float64
 }
 
-var (
-	dimensions    []int
-	width         int
-	height        int
-	complex       bool
-	grayscale     bool
-	negative      bool
-	colored       bool
-	colorBg       bool
-	customMap     string
-	flipX         bool
-	flipY         bool
-	jsonOutput    bool
-	fontColor     [3]int
-	braille       bool
-	threshold     int
-	dither        bool
-	inputIsGif    bool
-	colorLevel    image_conversions.ColorLevel
-)
+/*
+Conversion settings parsed from Flags, along with what's detected about the input. A converter is
+created for each call, so conversions can run concurrently with different flags
+*/
+type converter struct {
+	dimensions       []int
+	width            int
+	height           int
+	complex          bool
+	grayscale        bool
+	negative         bool
+	colored          bool
+	colorBg          bool
+	customMap        string
+	flipX            bool
+	flipY            bool
+	jsonOutput       bool
+	fontColor        [3]int
+	braille          bool
+	threshold        int
+	dither           bool
+	inputIsAnimated  bool
+	colorLevel       image_conversions.ColorLevel
+	brightness       float64
+	contrast         float64
+	gamma            float64
+	autoLevels       bool
+	equalize         bool
+	crop             []float64
+	cropPercent      bool
+	rotate           float64
+	rotateBg         [3]int
+	ignoreExif       bool
+	alphaBg          []int
+	transparentEmpty bool
+	resampleFilter   image_conversions.ResampleFilter
+	cellAspect       float64
+	fitMode          image_conversions.FitMode
+	maxPixels        int
+	frameRate        float64
+}
@@ -0,0 +1,57 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/Ares1605/ascii-image-converter-wasm/aic_package"
+
+	"github.com/spf13/cobra"
+)
+
+var animateCmd = &cobra.Command{
+	Use:   "animate -",
+	Short: "Play a piped animation on the terminal",
+	Long:  "Plays a piped GIF, APNG or animated WebP as ascii art on the terminal, in real time.\nPlayback can be controlled with the keyboard, see the README for the keys.",
+	Args:  cobra.ArbitraryArgs,
+
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if checkInputAndFlags(args) {
+			return
+		}
+
+		flags, ok := buildFlags(cmd.Flags())
+		if !ok {
+			return
+		}
+
+		inputBytes, ok := readStdin(flags.MaxInputBytes)
+		if !ok {
+			return
+		}
+
+		if err := aic_package.PlayAnimation(inputBytes, flags); err != nil {
+			fmt.Printf("Error: %v\n", err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(animateCmd)
+}
@@ -0,0 +1,277 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/Ares1605/ascii-image-converter-wasm/aic_package"
+	"github.com/Ares1605/ascii-image-converter-wasm/image_formats"
+)
+
+// A single input read in batch mode, named after its tar entry or line. err is set if the input
+// couldn't be read
+type batchItem struct {
+	index int
+	name  string
+	data  []byte
+	err   error
+}
+
+// Output of a single input in batch mode, as written in the JSON array
+type batchResult struct {
+	Index int                         `json:"index"`
+	Name  string                      `json:"name,omitempty"`
+	Chars [][]aic_package.ColoredChar `json:"chars,omitempty"`
+	Error string                      `json:"error,omitempty"`
+
+	ascii string
+}
+
+/*
+Converts every input in the batch read from r, which is either a tar archive or a list of base64
+encoded images, one per line. Inputs are converted concurrently by a pool of jobs workers, and their
+outputs are written to w in input order as soon as they're ready.
+
+Inputs that fail are reported along with the others rather than stopping the batch. Each output is
+preceded by a header naming its input, or with --json, all outputs are written as a single JSON array
+*/
+func runBatch(w io.Writer, r io.Reader, flags aic_package.Flags, jobs int) error {
+	type job struct {
+		item   batchItem
+		result chan batchResult
+	}
+
+	var (
+		items = make(chan batchItem)
+		queue = make(chan job)
+
+		// Results in input order. The capacity limits how far reading gets ahead of writing
+		pending = make(chan chan batchResult, jobs*2)
+	)
+
+	go func() {
+		defer close(items)
+		readBatch(r, flags.MaxInputBytes, items)
+	}()
+
+	go func() {
+		defer close(pending)
+		defer close(queue)
+
+		for item := range items {
+			result := make(chan batchResult, 1)
+			pending <- result
+			queue <- job{item, result}
+		}
+	}()
+
+	for i := 0; i < jobs; i++ {
+		go func() {
+			for job := range queue {
+				job.result <- convertBatchItem(job.item, flags)
+			}
+		}()
+	}
+
+	out := bufio.NewWriter(w)
+	defer out.Flush()
+
+	if flags.JsonOutput {
+		out.WriteString("[")
+	}
+
+	written := 0
+
+	for result := range pending {
+		result := <-result
+
+		if flags.JsonOutput {
+			marshalled, err := json.Marshal(result)
+			if err != nil {
+				return err
+			}
+
+			if written > 0 {
+				out.WriteString(",")
+			}
+			out.Write(marshalled)
+		} else {
+			if written > 0 {
+				out.WriteString("\n")
+			}
+			fmt.Fprintf(out, "==> %v <==\n", result.Name)
+
+			if result.Error != "" {
+				fmt.Fprintf(out, "Error: %v\n", result.Error)
+			} else {
+				fmt.Fprintf(out, "%v\n", result.ascii)
+			}
+		}
+		written++
+
+		if err := out.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if flags.JsonOutput {
+		out.WriteString("]\n")
+	}
+
+	return nil
+}
+
+func convertBatchItem(item batchItem, flags aic_package.Flags) batchResult {
+	result := batchResult{Index: item.index, Name: item.name}
+
+	err := item.err
+	if err == nil && image_formats.IsAnimated(item.data) {
+		// Animations would be played on the terminal, which doesn't work for a batch
+		err = fmt.Errorf("%w in batch mode", aic_package.ErrAnimationUnsupported)
+	}
+
+	if err == nil {
+		if flags.JsonOutput {
+			result.Chars, err = aic_package.ConvertJSON(item.data, flags)
+		} else {
+			result.ascii, err = aic_package.Convert(item.data, flags)
+		}
+	}
+
+	if err != nil {
+		result.Chars = nil
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+/*
+Reads the inputs of a batch from r into items. A tar archive is told apart from a list of base64
+images by its header. Inputs over maxBytes bytes (unlimited if 0 or less) are reported as errors. If
+reading the batch itself fails, the error is reported as a final item
+*/
+func readBatch(r io.Reader, maxBytes int64, items chan<- batchItem) {
+	buffered := bufio.NewReader(r)
+
+	// Tar headers are 512 bytes long, with a magic string at offset 257
+	header, _ := buffered.Peek(512)
+
+	index := 0
+	if len(header) == 512 && bytes.HasPrefix(header[257:], []byte("ustar")) {
+		readTarBatch(buffered, maxBytes, &index, items)
+	} else {
+		readBase64Batch(buffered, maxBytes, &index, items)
+	}
+}
+
+func readTarBatch(r io.Reader, maxBytes int64, index *int, items chan<- batchItem) {
+	archive := tar.NewReader(r)
+
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return
+		} else if err != nil {
+			items <- batchItem{index: *index, err: fmt.Errorf("unable to read tar archive: %v", err)}
+			return
+		}
+
+		// Directories, links and other entries hold no images
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		item := batchItem{index: *index, name: header.Name}
+		*index++
+
+		if maxBytes > 0 && header.Size > maxBytes {
+			item.err = fmt.Errorf("%w: input exceeds the limit of %v bytes", aic_package.ErrInputTooLarge, maxBytes)
+		} else {
+			item.data, item.err = io.ReadAll(archive)
+		}
+
+		items <- item
+	}
+}
+
+func readBase64Batch(r *bufio.Reader, maxBytes int64, index *int, items chan<- batchItem) {
+	// Lines longer than the encoding of the largest input allowed aren't held in memory
+	maxLine := -1
+	if maxBytes > 0 {
+		maxLine = base64.StdEncoding.EncodedLen(int(maxBytes))
+	}
+
+	for lineNumber := 1; ; lineNumber++ {
+		line, tooLong, err := readBatchLine(r, maxLine)
+		if err != nil && err != io.EOF {
+			items <- batchItem{index: *index, err: fmt.Errorf("unable to read input: %v", err)}
+			return
+		}
+
+		if line = bytes.TrimSpace(line); len(line) > 0 || tooLong {
+			item := batchItem{index: *index, name: fmt.Sprintf("line %v", lineNumber)}
+			*index++
+
+			if !tooLong {
+				if item.data, item.err = base64.StdEncoding.DecodeString(string(line)); item.err != nil {
+					item.err = fmt.Errorf("invalid base64: %v", item.err)
+				}
+			}
+
+			// Encodings are padded, so a line within maxLine can still decode to a little more than maxBytes
+			if tooLong || (maxBytes > 0 && int64(len(item.data)) > maxBytes) {
+				item.data = nil
+				item.err = fmt.Errorf("%w: input exceeds the limit of %v bytes", aic_package.ErrInputTooLarge, maxBytes)
+			}
+
+			items <- item
+		}
+
+		if err == io.EOF {
+			return
+		}
+	}
+}
+
+// Reads a line, without its line ending. If it's longer than maxLength (unlimited if negative),
+// the rest of the line is skipped and tooLong is set
+func readBatchLine(r *bufio.Reader, maxLength int) (line []byte, tooLong bool, err error) {
+	for {
+		chunk, err := r.ReadSlice('\n')
+
+		if !tooLong {
+			line = append(line, bytes.TrimSuffix(chunk, []byte("\n"))...)
+
+			// Leading and trailing whitespace is allowed on top of the encoded input
+			if maxLength >= 0 && len(bytes.TrimSpace(line)) > maxLength {
+				line, tooLong = nil, true
+			}
+		}
+
+		if err != bufio.ErrBufferFull {
+			return line, tooLong, err
+		}
+	}
+}
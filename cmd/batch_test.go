@@ -0,0 +1,351 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Ares1605/ascii-image-converter-wasm/aic_package"
+)
+
+// Returns a PNG of a single shade of gray
+func testShadePNG(t *testing.T, width, height int, shade uint8) []byte {
+	t.Helper()
+
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = shade
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// Returns a two frame GIF
+func testAnimatedGIF(t *testing.T) []byte {
+	t.Helper()
+
+	palette := color.Palette{color.Black, color.White}
+	var buf bytes.Buffer
+	err := gif.EncodeAll(&buf, &gif.GIF{
+		Image: []*image.Paletted{
+			image.NewPaletted(image.Rect(0, 0, 4, 4), palette),
+			image.NewPaletted(image.Rect(0, 0, 4, 4), palette),
+		},
+		Delay: []int{1, 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// An entry of a test tar archive
+type testTarEntry struct {
+	name     string
+	typeflag byte
+	data     []byte
+}
+
+func testTar(t *testing.T, entries ...testTarEntry) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	archive := tar.NewWriter(&buf)
+	for _, entry := range entries {
+		header := &tar.Header{Name: entry.name, Typeflag: entry.typeflag, Mode: 0o644, Size: int64(len(entry.data))}
+		if entry.typeflag == tar.TypeDir {
+			header.Mode = 0o755
+		}
+		if err := archive.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := archive.Write(entry.data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// Returns every item read from a batch
+func readBatchItems(r *bytes.Reader, maxBytes int64) []batchItem {
+	items := make(chan batchItem)
+	go func() {
+		defer close(items)
+		readBatch(r, maxBytes, items)
+	}()
+
+	var read []batchItem
+	for item := range items {
+		read = append(read, item)
+	}
+	return read
+}
+
+func TestReadBatch(t *testing.T) {
+	encode := base64.StdEncoding.EncodeToString
+
+	tarBatch := testTar(t,
+		testTarEntry{"images/", tar.TypeDir, nil},
+		testTarEntry{"images/a.png", tar.TypeReg, []byte("first")},
+		testTarEntry{"images/link.png", tar.TypeSymlink, nil},
+		testTarEntry{"images/b.png", tar.TypeReg, []byte("too large")},
+		testTarEntry{"images/c.png", tar.TypeReg, []byte("third")},
+	)
+
+	type want struct {
+		name    string
+		data    string
+		invalid bool
+	}
+
+	tests := []struct {
+		name     string
+		input    []byte
+		maxBytes int64
+		want     []want
+	}{
+		{
+			"tar archive", tarBatch, 8,
+			[]want{{"images/a.png", "first", false}, {"images/b.png", "", true}, {"images/c.png", "third", false}},
+		},
+		{
+			"tar archive without limit", tarBatch, 0,
+			[]want{{"images/a.png", "first", false}, {"images/b.png", "too large", false}, {"images/c.png", "third", false}},
+		},
+		{
+			"truncated tar archive", tarBatch[:3*512+100], 0,
+			[]want{{"images/a.png", "first", false}, {"", "", true}},
+		},
+		{
+			"base64 lines", []byte(encode([]byte("first")) + "\n\n  " + encode([]byte("second")) + "\t\r\n" + encode([]byte("third"))), 0,
+			[]want{{"line 1", "first", false}, {"line 3", "second", false}, {"line 4", "third", false}},
+		},
+		{
+			"invalid base64", []byte(encode([]byte("first")) + "\nnot base64!\n" + encode([]byte("third")) + "\n"), 0,
+			[]want{{"line 1", "first", false}, {"line 2", "", true}, {"line 3", "third", false}},
+		},
+		{
+			"base64 line over limit", []byte(encode([]byte("first")) + "\n" + encode([]byte("too large")) + "\n" + encode([]byte("third")) + "\n"), 8,
+			[]want{{"line 1", "first", false}, {"line 2", "", true}, {"line 3", "third", false}},
+		},
+		{"empty batch", nil, 0, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			items := readBatchItems(bytes.NewReader(test.input), test.maxBytes)
+			if len(items) != len(test.want) {
+				t.Fatalf("got %v items, want %v: %+v", len(items), len(test.want), items)
+			}
+
+			for i, item := range items {
+				want := test.want[i]
+				if item.index != i {
+					t.Errorf("item %v has index %v", i, item.index)
+				}
+				if item.name != want.name {
+					t.Errorf("item %v is named %q, want %q", i, item.name, want.name)
+				}
+				if want.invalid {
+					if item.err == nil {
+						t.Errorf("item %v has no error", i)
+					}
+					continue
+				}
+				if item.err != nil {
+					t.Errorf("item %v: %v", i, item.err)
+				} else if string(item.data) != want.data {
+					t.Errorf("item %v holds %q, want %q", i, item.data, want.data)
+				}
+			}
+		})
+	}
+
+	t.Run("input over limit", func(t *testing.T) {
+		// The line is short enough, but its 9 bytes decoded aren't
+		for _, input := range [][]byte{tarBatch, []byte(encode([]byte("too large")))} {
+			tooLarge := 0
+			for _, item := range readBatchItems(bytes.NewReader(input), 8) {
+				if errors.Is(item.err, aic_package.ErrInputTooLarge) {
+					tooLarge++
+				} else if item.err != nil {
+					t.Errorf("got error %v, want ErrInputTooLarge", item.err)
+				}
+			}
+			if tooLarge != 1 {
+				t.Errorf("got %v inputs over the limit, want 1", tooLarge)
+			}
+		}
+	})
+}
+
+// Indices of the inputs in testMixedBatch() that fail: invalid base64, an unknown format and an animation
+var failingBatchItems = map[int]bool{2: true, 4: true, 5: true}
+
+// Returns a batch of base64 lines mixing convertible images with inputs that fail, along with the
+// images it holds, which are nil for invalid base64
+func testMixedBatch(t *testing.T) ([]byte, [][]byte) {
+	t.Helper()
+
+	// The first image is by far the largest, so later ones are converted before it
+	images := [][]byte{
+		testShadePNG(t, 1000, 1000, 0),
+		testShadePNG(t, 4, 4, 60),
+		nil,
+		testShadePNG(t, 4, 4, 120),
+		[]byte("not an image"),
+		testAnimatedGIF(t),
+		testShadePNG(t, 4, 4, 255),
+	}
+
+	var lines []string
+	for _, data := range images {
+		if data == nil {
+			lines = append(lines, "not base64!")
+		} else {
+			lines = append(lines, base64.StdEncoding.EncodeToString(data))
+		}
+	}
+	return []byte(strings.Join(lines, "\n") + "\n"), images
+}
+
+func TestRunBatchJSON(t *testing.T) {
+	batch, images := testMixedBatch(t)
+
+	flags := aic_package.DefaultFlags()
+	flags.Dimensions = []int{4, 2}
+	flags.JsonOutput = true
+
+	for _, jobs := range []int{1, 4} {
+		t.Run(fmt.Sprintf("%v jobs", jobs), func(t *testing.T) {
+			var out bytes.Buffer
+			if err := runBatch(&out, bytes.NewReader(batch), flags, jobs); err != nil {
+				t.Fatal(err)
+			}
+
+			var results []batchResult
+			if err := json.Unmarshal(out.Bytes(), &results); err != nil {
+				t.Fatalf("invalid output %s: %v", out.String(), err)
+			}
+			if len(results) != len(images) {
+				t.Fatalf("got %v results, want %v", len(results), len(images))
+			}
+
+			for i, result := range results {
+				if result.Index != i {
+					t.Errorf("result %v has index %v", i, result.Index)
+				}
+				if want := fmt.Sprintf("line %v", i+1); result.Name != want {
+					t.Errorf("result %v is named %q, want %q", i, result.Name, want)
+				}
+
+				if failingBatchItems[i] {
+					if result.Error == "" || result.Chars != nil {
+						t.Errorf("result %v = %+v, want only an error", i, result)
+					}
+					continue
+				}
+
+				if result.Error != "" {
+					t.Errorf("result %v: %v", i, result.Error)
+					continue
+				}
+				want, err := aic_package.ConvertJSON(images[i], flags)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(result.Chars, want) {
+					t.Errorf("result %v = %v, want %v", i, result.Chars, want)
+				}
+			}
+
+			if want := aic_package.ErrAnimationUnsupported.Error(); !strings.Contains(results[5].Error, want) {
+				t.Errorf("got error %q for an animation, want %q", results[5].Error, want)
+			}
+		})
+	}
+
+	t.Run("empty batch", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := runBatch(&out, bytes.NewReader(nil), flags, 2); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != "[]\n" {
+			t.Errorf("got %q, want an empty array", out.String())
+		}
+	})
+}
+
+func TestRunBatchText(t *testing.T) {
+	batch, images := testMixedBatch(t)
+
+	flags := aic_package.DefaultFlags()
+	flags.Dimensions = []int{4, 2}
+
+	var out bytes.Buffer
+	if err := runBatch(&out, bytes.NewReader(batch), flags, 4); err != nil {
+		t.Fatal(err)
+	}
+
+	var want []string
+	for i, data := range images {
+		if i > 0 {
+			want = append(want, "")
+		}
+		want = append(want, fmt.Sprintf("==> line %v <==", i+1))
+
+		if failingBatchItems[i] {
+			want = append(want, "Error: ")
+			continue
+		}
+		ascii, err := aic_package.Convert(data, flags)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, strings.Split(ascii, "\n")...)
+	}
+
+	// Errors are matched by their position, not their message
+	got := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(got) != len(want) {
+		t.Fatalf("got %v lines, want %v:\n%v", len(got), len(want), out.String())
+	}
+	for i := range got {
+		if got[i] != want[i] && !(want[i] == "Error: " && strings.HasPrefix(got[i], want[i])) {
+			t.Errorf("line %v is %q, want %q", i+1, got[i], want[i])
+		}
+	}
+}
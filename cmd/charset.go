@@ -0,0 +1,100 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Ares1605/ascii-image-converter-wasm/aic_package"
+	image_conversions "github.com/Ares1605/ascii-image-converter-wasm/image_manipulation"
+
+	"github.com/spf13/cobra"
+)
+
+// Number of characters in the ramps previewing a character map
+const rampLength = 60
+
+// Only print the sorted character map, with the --sort flag
+var sortOnly bool
+
+var charsetCmd = &cobra.Command{
+	Use:   "charset",
+	Short: "Preview a character map, or sort it by how much ink each character shows",
+	Long:  "Previews the character map ascii art is mapped against, set with --map or --complex, as a ramp from\ndarkest to lightest, and measures how much of its cell each character covers. A map reads best when\ncoverage increases along it, so the map is also shown sorted by coverage.",
+	Example: "  ascii-image-converter-wasm charset -m \" .:-=+*#%@\"\n" +
+		"  [piped input] | ascii-image-converter-wasm -m \"$(ascii-image-converter-wasm charset --sort -m \"@#. \")\" -",
+	Args: cobra.NoArgs,
+
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if braille {
+			fmt.Printf("Error: braille art doesn't use a character map\n\n")
+			return
+		}
+
+		charMap := image_conversions.CharMap(complex, customMap)
+
+		coverages, err := aic_package.MeasureCharMap(charMap)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		sorted, err := aic_package.SortCharMap(charMap)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		if sortOnly {
+			fmt.Println(sorted)
+			return
+		}
+
+		fmt.Printf("Map:     %q\n", charMap)
+		fmt.Printf("Sorted:  %q\n\n", sorted)
+
+		fmt.Printf("Ramp:    %v\n", charRamp(charMap))
+		fmt.Printf("Sorted:  %v\n\n", charRamp(sorted))
+
+		fmt.Printf("Coverage of each character, from darkest to lightest:\n\n")
+		for _, coverage := range coverages {
+			fmt.Printf("  %-6q %5.1f%%  %v\n", coverage.Char, coverage.Coverage*100, strings.Repeat("#", int(coverage.Coverage*40+0.5)))
+		}
+		fmt.Println()
+	},
+}
+
+// Spreads the characters of a map over rampLength characters, like a gradient from black to white
+// converted into ascii art
+func charRamp(charMap string) string {
+	chars := []rune(charMap)
+
+	var ramp strings.Builder
+	for i := 0; i < rampLength; i++ {
+		ramp.WriteRune(chars[i*len(chars)/rampLength])
+	}
+
+	return ramp.String()
+}
+
+func init() {
+	charsetCmd.Flags().BoolVar(&sortOnly, "sort", false, "Only print the character map sorted by coverage,\nready to be passed to --map\n")
+
+	rootCmd.AddCommand(charsetCmd)
+}
@@ -0,0 +1,127 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/Ares1605/ascii-image-converter-wasm/aic_package"
+	"github.com/Ares1605/ascii-image-converter-wasm/image_formats"
+
+	"github.com/spf13/cobra"
+)
+
+var convertCmd = &cobra.Command{
+	Use:   "convert -",
+	Short: "Convert piped input into ascii art and display it",
+	Long:  "Converts piped images, animations and video into ascii art and displays them on the terminal.\nThis is what running without a command does.",
+	Args:  cobra.ArbitraryArgs,
+	Run:   runConvert,
+}
+
+// Converts piped input, as done by the convert command and by running without a command
+func runConvert(cmd *cobra.Command, args []string) {
+
+	if checkInputAndFlags(args) || checkConvertFlags() {
+		return
+	}
+
+	flags, ok := buildFlags(cmd.Flags())
+	if !ok {
+		return
+	}
+
+	// Check file/data type of piped input
+	if !aic_package.IsInputFromPipe() {
+		fmt.Printf("there is no input being piped to stdin\n")
+		return
+	}
+
+	if batch {
+		if err := runBatch(os.Stdout, os.Stdin, flags, jobs); err != nil {
+			fmt.Printf("Error: %v\n", err)
+		}
+		return
+	}
+
+	video, inputBytes, err := readPipedInput(os.Stdin, flags.MaxInputBytes)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	if video != nil {
+		if outputFormat == "asciicast" {
+			fmt.Printf("Error: --format asciicast doesn't support video input\n")
+			return
+		}
+
+		// Frames may already have been written, so errors go to stderr rather than being mixed into them
+		if err := playVideo(video, flags); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if outputFormat == "asciicast" {
+		if err := aic_package.ConvertAsciicast(os.Stdout, inputBytes, flags); err != nil {
+			fmt.Printf("Error: %v\n", err)
+		}
+		return
+	}
+
+	if err = printAscii(inputBytes, flags); err != nil {
+		fmt.Printf("%v\n", err)
+	}
+}
+
+func printAscii(inputBytes []byte, flags aic_package.Flags) error {
+	if flags.JsonOutput {
+		if asciiArt, err := aic_package.ConvertJSON(inputBytes, flags); err == nil {
+			marshalled, err := json.Marshal(asciiArt)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+			}
+			fmt.Printf("%s", marshalled)
+		} else {
+			fmt.Printf("Error: %v\n", err)
+		}
+	} else if image_formats.IsAnimated(inputBytes) {
+		// Animations are played back with keyboard controls
+		if err := aic_package.PlayAnimation(inputBytes, flags); err != nil {
+			fmt.Printf("Error: %v\n", err)
+		}
+	} else {
+		if asciiArt, err := aic_package.Convert(inputBytes, flags); err == nil {
+			fmt.Printf("%s", asciiArt)
+		} else {
+			fmt.Printf("Error: %v\n", err)
+		}
+	}
+	fmt.Println()
+	return nil
+}
+
+func init() {
+	convertCmd.Flags().SortFlags = false
+	addConvertFlags(convertCmd.Flags())
+
+	rootCmd.AddCommand(convertCmd)
+}
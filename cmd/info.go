@@ -0,0 +1,89 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Ares1605/ascii-image-converter-wasm/aic_package"
+
+	"github.com/spf13/cobra"
+)
+
+var infoCmd = &cobra.Command{
+	Use:   "info -",
+	Short: "Describe piped input and the ascii art it converts into",
+	Long:  "Displays the format, size and frames of a piped image or animation, along with the size in\ncharacters of the ascii art it's converted into with the passed flags.",
+	Args:  cobra.ArbitraryArgs,
+
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if checkInputAndFlags(args) {
+			return
+		}
+
+		flags, ok := buildFlags(cmd.Flags())
+		if !ok {
+			return
+		}
+
+		inputBytes, ok := readStdin(flags.MaxInputBytes)
+		if !ok {
+			return
+		}
+
+		info, err := aic_package.Inspect(inputBytes, flags)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		if jsonOutput {
+			marshalled, err := json.Marshal(info)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			fmt.Printf("%s\n", marshalled)
+			return
+		}
+
+		fmt.Printf("Format:     %v\n", info.Format)
+		fmt.Printf("Size:       %vx%v pixels\n", info.Width, info.Height)
+
+		if info.Frames > 1 || info.Duration > 0 {
+			loops := "loops forever"
+			if info.LoopCount == 1 {
+				loops = "plays once"
+			} else if info.LoopCount > 1 {
+				loops = fmt.Sprintf("plays %v times", info.LoopCount)
+			}
+			fmt.Printf("Frames:     %v, lasting %v, %v\n", info.Frames, info.Duration, loops)
+		} else {
+			fmt.Printf("Frames:     %v\n", info.Frames)
+		}
+
+		fmt.Printf("Ascii art:  %vx%v characters\n", info.Columns, info.Rows)
+	},
+}
+
+func init() {
+	infoCmd.Flags().BoolVarP(&jsonOutput, "json", "J", false, "Output the description as JSON\n")
+
+	rootCmd.AddCommand(infoCmd)
+}
@@ -0,0 +1,99 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Ares1605/ascii-image-converter-wasm/aic_package"
+
+	"github.com/spf13/cobra"
+)
+
+// Format ascii art is rendered to with the --to flag
+var renderFormat string
+
+var renderCmd = &cobra.Command{
+	Use:   "render -",
+	Short: "Render piped input as ascii art to a PNG, SVG or HTML document",
+	Long:  "Converts a piped image into ascii art and renders it as a PNG image, an SVG image or an HTML\n<pre> element, which is written to stdout.",
+	Example: "  [piped input] | ascii-image-converter-wasm render --to png - > art.png\n" +
+		"  [piped input] | ascii-image-converter-wasm render --to svg -C - > art.svg",
+	Args: cobra.ArbitraryArgs,
+
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if checkInputAndFlags(args) {
+			return
+		}
+
+		if renderFormat != "png" && renderFormat != "svg" && renderFormat != "html" {
+			fmt.Printf("Error: --to must be one of png, svg or html, got %q\n\n", renderFormat)
+			return
+		}
+
+		// PNG images would garble the terminal
+		if renderFormat == "png" && stdoutIsTerminal() {
+			fmt.Printf("Error: PNG output can't be written to a terminal, redirect it to a file instead\n\n")
+			return
+		}
+
+		flags, ok := buildFlags(cmd.Flags())
+		if !ok {
+			return
+		}
+
+		inputBytes, ok := readStdin(flags.MaxInputBytes)
+		if !ok {
+			return
+		}
+
+		var (
+			rendered []byte
+			err      error
+		)
+
+		switch renderFormat {
+		case "png":
+			rendered, err = aic_package.ConvertPNG(inputBytes, flags)
+		case "svg":
+			var svg string
+			svg, err = aic_package.ConvertSVG(inputBytes, flags)
+			rendered = []byte(svg + "\n")
+		case "html":
+			var html string
+			html, err = aic_package.ConvertHTML(inputBytes, flags)
+			rendered = []byte(html + "\n")
+		}
+
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		if _, err := os.Stdout.Write(rendered); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+	},
+}
+
+func init() {
+	renderCmd.Flags().StringVar(&renderFormat, "to", "png", "Format to render ascii art to\nOne of png, svg or html\ne.g. --to svg\n")
+
+	rootCmd.AddCommand(renderCmd)
+}
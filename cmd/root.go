@@ -19,14 +19,14 @@ package cmd
 
 import (
 	"fmt"
-	"io/ioutil"
 	"os"
-	"encoding/json"
+	"strings"
 
 	"github.com/Ares1605/ascii-image-converter-wasm/aic_package"
 	image_conversions "github.com/Ares1605/ascii-image-converter-wasm/image_manipulation"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var (
@@ -35,6 +35,8 @@ var (
 	dimensions    []int
 	width         int
 	height        int
+	fit           bool
+	fill          bool
 	negative      bool
 	formatsTrue   bool
 	colored       bool
@@ -49,86 +51,46 @@ var (
 	braille       bool
 	threshold     int
 	dither        bool
+	brightness    float64
+	contrast      float64
+	gamma         float64
+	autoLevels    bool
+	equalize      bool
+	cropRegion    []string
+	rotate        float64
+	rotateBg      []int
+	ignoreExif    bool
+	alphaBg       []int
+	emptyAlpha    bool
+	filter        string
+	cellAspect    float64
+	cellWidth     float64
+	cellHeight    float64
+	maxInputSize  int64
+	maxPixels     int
+	frameRate     float64
+	outputFormat  string
+	batch         bool
+	jobs          int
+	presetName    string
+	settingsJSON  string
 
 	// Root commands
 	rootCmd = &cobra.Command{
-		Use:     "[piped input] | ascii-image-converter -",
+		Use:     "ascii-image-converter-wasm [command] -",
 		Short:   "Converts images and gifs into ascii art",
 		Version: "1.13.1",
-		Long:    "This tool converts images into ascii art and prints them on the terminal.\nFurther configuration can be managed with flags.",
+		Long:    "This tool converts images into ascii art and prints them on the terminal.\nFurther configuration can be managed with flags.\n\nWithout a command, piped input is converted like with the convert command.",
+		Example: "  [piped input] | ascii-image-converter-wasm -\n  [piped input] | ascii-image-converter-wasm render --to svg - > art.svg",
+
+		// Piped input is passed as "-", which isn't a command
+		Args: cobra.ArbitraryArgs,
 
 		// Not RunE since help text is getting larger and seeing it for every error impacts user experience
-		Run: func(cmd *cobra.Command, args []string) {
-
-			if checkInputAndFlags(args) {
-				return
-			}
-
-			flags := aic_package.Flags{
-				Complex:             complex,
-				Dimensions:          dimensions,
-				Width:               width,
-				Height:              height,
-				Negative:            negative,
-				Colored:             colored,
-				CharBackgroundColor: colorBg,
-				Grayscale:           grayscale,
-				CustomMap:           customMap,
-				FlipX:               flipX,
-				FlipY:               flipY,
-				JsonOutput:          jsonOutput,
-				FontColor:           [3]int{fontColor[0], fontColor[1], fontColor[2]},
-				Braille:             braille,
-				Threshold:           threshold,
-				Dither:              dither,
-				// By default, color level is set to true (24-bit) color
-				ColorLevel:          image_conversions.Millions,
-			}
-			if hundredsColor {
-				flags.ColorLevel = image_conversions.Hundreds
-			}
-
-			// Check file/data type of piped input
-			if !aic_package.IsInputFromPipe() {
-				fmt.Printf("there is no input being piped to stdin\n")
-				return
-			}
-
-			inputBytes, err := ioutil.ReadAll(os.Stdin)
-			if err != nil {
-				fmt.Printf("unable to read piped input: %v\n", err)
-				return
-			}
-
-			if err = printAscii(inputBytes, flags); err != nil {
-				fmt.Printf("%v\n", err)
-			}
-		},
+		Run: runConvert,
 	}
 )
 
-func printAscii(inputBytes []byte, flags aic_package.Flags) error {
-	if flags.JsonOutput {
-		if asciiArt, err := aic_package.ConvertJSON(inputBytes, flags); err == nil {
-			marshalled, err := json.Marshal(asciiArt)
-			if err != nil {
-				fmt.Printf("Error: %v\n", err)
-			}
-			fmt.Printf("%s", marshalled)
-		} else {
-			fmt.Printf("Error: %v\n", err)
-		}
-	} else {
-		if asciiArt, err := aic_package.Convert(inputBytes, flags); err == nil {
-			fmt.Printf("%s", asciiArt)
-		} else {
-			fmt.Printf("Error: %v\n", err)
-		}
-	}
-	fmt.Println()
-	return nil
-}
-
 // Cobra configuration from here on
 
 func Execute() {
@@ -144,22 +106,44 @@ func init() {
 
 	rootCmd.PersistentFlags().BoolVarP(&colored, "color", "C", false, "Display ascii art with original colors\nIf 24-bit colors aren't supported, uses 8-bit\n(Inverts with --negative flag)\n(Overrides --grayscale and --font-color flags)\n")
 	rootCmd.PersistentFlags().BoolVar(&colorBg, "color-bg", false, "If some color flag is passed, use that color\non character background instead of foreground\n(Inverts with --negative flag)\n(Only applicable for terminal display)\n")
-	rootCmd.PersistentFlags().IntSliceVarP(&dimensions, "dimensions", "d", nil, "Set width and height for ascii art in CHARACTER length\ne.g. -d 60,30 (defaults to terminal height)\n(Overrides --width and --height flags)\n")
+	rootCmd.PersistentFlags().IntSliceVarP(&dimensions, "dimensions", "d", nil, "Set width and height for ascii art in CHARACTER length\ne.g. -d 60,30 (defaults to fitting the terminal)\n(Overrides --width and --height flags)\n")
 	rootCmd.PersistentFlags().IntVarP(&width, "width", "W", 0, "Set width for ascii art in CHARACTER length\nHeight is kept to aspect ratio\ne.g. -W 60\n")
 	rootCmd.PersistentFlags().IntVarP(&height, "height", "H", 0, "Set height for ascii art in CHARACTER length\nWidth is kept to aspect ratio\ne.g. -H 60\n")
+	rootCmd.PersistentFlags().BoolVar(&fit, "fit", false, "Treat --width and --height as a maximum box\nand fit the art inside it, keeping aspect ratio\ne.g. -W 80 -H 40 --fit\n")
+	rootCmd.PersistentFlags().BoolVar(&fill, "fill", false, "Cover the whole --width and --height box,\ncropping the image to keep aspect ratio\ne.g. -W 80 -H 40 --fill\n")
+	rootCmd.PersistentFlags().Float64Var(&cellAspect, "cell-aspect", 0, "Set width to height ratio of a character\ncell in your font, used with --width or --height\ne.g. --cell-aspect 0.45\n(Defaults to --cell-width / --cell-height, or 0.5)\n")
+	rootCmd.PersistentFlags().Float64Var(&cellWidth, "cell-width", 0, "Set width of a character cell in your font,\nin any unit, to compute the cell aspect ratio\nfrom along with --cell-height\ne.g. --cell-width 9 --cell-height 19\n")
+	rootCmd.PersistentFlags().Float64Var(&cellHeight, "cell-height", 0, "Set height of a character cell in your font,\nin any unit, to compute the cell aspect ratio\nfrom along with --cell-width\ne.g. --cell-width 9 --cell-height 19\n")
 	rootCmd.PersistentFlags().StringVarP(&customMap, "map", "m", "", "Give custom ascii characters to map against\nOrdered from darkest to lightest\ne.g. -m \" .-+#@\" (Quotation marks excluded from map)\n(Overrides --complex flag)\n")
 	rootCmd.PersistentFlags().BoolVarP(&braille, "braille", "b", false, "Use braille characters instead of ascii\nTerminal must support braille patterns properly\n(Overrides --complex and --map flags)\n")
 	rootCmd.PersistentFlags().IntVar(&threshold, "threshold", 0, "Threshold for braille art\nValue between 0-255 is accepted\ne.g. --threshold 170\n(Defaults to 128)\n")
 	rootCmd.PersistentFlags().BoolVar(&dither, "dither", false, "Apply dithering on image for braille\nart conversion\n(Only applicable with --braille flag)\n(Negates --threshold flag)\n")
+	rootCmd.PersistentFlags().StringSliceVar(&cropRegion, "crop", nil, "Crop image to a region before conversion\nPass x,y,width,height in pixels, or in\npercentages by suffixing each value with %\ne.g. --crop 10,10,200,100 or --crop 25%,0%,50%,100%\n")
+	rootCmd.PersistentFlags().Float64Var(&rotate, "rotate", 0, "Rotate image clockwise by given degrees\ne.g. --rotate 90\n")
+	rootCmd.PersistentFlags().IntSliceVar(&rotateBg, "rotate-bg", nil, "Set RGB color to fill corners with when\nrotating by angles other than multiples of 90\ne.g. --rotate-bg 255,255,255\n(Defaults to 0,0,0)\n")
+	rootCmd.PersistentFlags().BoolVar(&ignoreExif, "ignore-exif", false, "Ignore EXIF orientation of JPEG and TIFF input\ninstead of displaying the image upright\n")
+	rootCmd.PersistentFlags().IntSliceVar(&alphaBg, "alpha-bg", nil, "Composite transparent areas of the image\nonto an RGB background color\ne.g. --alpha-bg 255,255,255\n(Transparent areas are black by default)\n")
+	rootCmd.PersistentFlags().BoolVar(&emptyAlpha, "transparent-empty", false, "Display fully transparent areas of the image\nas empty spaces with no color\n(Overridden by --alpha-bg flag)\n")
+	rootCmd.PersistentFlags().Float64Var(&brightness, "brightness", 0, "Adjust image brightness before conversion\nValue between -100 and 100 is accepted\ne.g. --brightness 20\n")
+	rootCmd.PersistentFlags().Float64Var(&contrast, "contrast", 0, "Adjust image contrast before conversion\nValue between -100 and 100 is accepted\ne.g. --contrast 30\n")
+	rootCmd.PersistentFlags().Float64Var(&gamma, "gamma", 1, "Apply gamma correction before conversion\nValues below 1 darken and above 1 lighten\ne.g. --gamma 1.8\n")
+	rootCmd.PersistentFlags().BoolVar(&autoLevels, "auto-levels", false, "Stretch image levels to the full range\n(1st to 99th luminance percentile)\n")
+	rootCmd.PersistentFlags().BoolVar(&equalize, "equalize", false, "Apply adaptive histogram equalization\nBrings out detail in dark and bright regions\n")
+	rootCmd.PersistentFlags().StringVar(&filter, "filter", string(image_conversions.Lanczos), "Set resampling filter for shrinking the image\nnearest keeps pixel art crisp, cell-mean\naverages each character's pixels exactly\ne.g. --filter nearest\n(Use --formats to list all filters)\n")
 	rootCmd.PersistentFlags().BoolVarP(&grayscale, "grayscale", "g", false, "Display grayscale ascii art\n(Inverts with --negative flag)\n(Overrides --font-color flag)\n")
 	rootCmd.PersistentFlags().BoolVarP(&complex, "complex", "c", false, "Display ascii characters in a larger range\nMay result in higher quality\n")
 	rootCmd.PersistentFlags().BoolVarP(&negative, "negative", "n", false, "Display ascii art in negative colors\n")
 	rootCmd.PersistentFlags().BoolVarP(&flipX, "flipX", "x", false, "Flip ascii art horizontally\n")
 	rootCmd.PersistentFlags().BoolVarP(&flipY, "flipY", "y", false, "Flip ascii art vertically\n")
-	rootCmd.PersistentFlags().BoolVarP(&jsonOutput, "json", "J", false, "Output ASCII image with JSON.\nFor programmable iteration where ANSI escape codes are not supported.\n")
 	rootCmd.PersistentFlags().BoolVar(&hundredsColor, "256-color", false, "If some color flag is passed, sets the color output to 256 (8-bit) color, as opposed to true (24-bit) color.\nWeb APIs virtually exclusively support true (24-bit) color, however this color level exists to support mundane color, or environments incompatible with true (24-bit) color.\n")
 	rootCmd.PersistentFlags().IntSliceVar(&fontColor, "font-color", nil, "Set font color for terminal\nPass an RGB value\ne.g. --font-color 0,0,0\n(Defaults to 255,255,255)\n")
-	rootCmd.PersistentFlags().BoolVar(&formatsTrue, "formats", false, "Display supported input formats\n")
+	rootCmd.PersistentFlags().Int64Var(&maxInputSize, "max-input-size", 0, "Set maximum size of piped input in bytes\ne.g. --max-input-size 10485760\n(Defaults to no limit)\n")
+	rootCmd.PersistentFlags().IntVar(&maxPixels, "max-pixels", 0, "Set maximum number of pixels (width x height)\nof the input image, or of all animation frames\ncombined, checked before decoding it\ne.g. --max-pixels 40000000\n(Defaults to no limit)\n")
+	rootCmd.PersistentFlags().StringVar(&presetName, "preset", "", "Start from a built-in preset, which flags\npassed explicitly override\ne.g. --preset retro-green\n(One of "+strings.Join(aic_package.Presets(), ", ")+")\n")
+	rootCmd.PersistentFlags().StringVar(&settingsJSON, "settings-json", "", "Apply settings passed as inline JSON, after\n--preset and before flags passed explicitly\ne.g. --settings-json '{\"version\":1,\"colored\":true}'\n")
+
+	addConvertFlags(rootCmd.Flags())
+	rootCmd.Flags().BoolVar(&formatsTrue, "formats", false, "Display supported input formats\nand resampling filters\n")
 
 	rootCmd.PersistentFlags().BoolP("help", "h", false, "Help for "+rootCmd.Name()+"\n")
 	rootCmd.PersistentFlags().BoolP("version", "v", false, "Version for "+rootCmd.Name())
@@ -172,3 +156,12 @@ func init() {
 		"Distributed under the Apache License Version 2.0 (Apache-2.0)\n" +
 		"For further details, visit https://github.com/Ares1065/ascii-image-converter-wasm\n")
 }
+
+// Adds the flags only used for converting input, shared by the root and convert commands
+func addConvertFlags(flags *pflag.FlagSet) {
+	flags.BoolVarP(&jsonOutput, "json", "J", false, "Output ASCII image with JSON.\nFor programmable iteration where ANSI escape codes are not supported.\n")
+	flags.Float64Var(&frameRate, "frame-rate", 0, "Set frame rate of piped video (Y4M or MJPEG)\ne.g. --frame-rate 29.97\n(Defaults to the Y4M header's rate, or 25)\n")
+	flags.StringVar(&outputFormat, "format", "", "Write ascii art in the given format instead\nof displaying it on the terminal\ne.g. --format asciicast > art.cast\n(asciicast is the only format supported)\n")
+	flags.BoolVar(&batch, "batch", false, "Convert a batch of images read from stdin, either\na tar archive or base64 encoded images, one per line\ne.g. tar -c *.png | ascii-image-converter - --batch\n")
+	flags.IntVar(&jobs, "jobs", 0, "Set number of images converted concurrently\nin batch mode\ne.g. --jobs 4\n(Defaults to the number of CPUs)\n")
+}
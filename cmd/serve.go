@@ -0,0 +1,138 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Ares1605/ascii-image-converter-wasm/server"
+
+	"github.com/spf13/cobra"
+)
+
+// Flags of the serve command
+var (
+	serveAddr        string
+	serveTimeout     time.Duration
+	serveConcurrency int
+)
+
+// Time given to conversions in progress to finish when the server is stopped
+const shutdownTimeout = 10 * time.Second
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve conversions over HTTP",
+	Long: "Listens for images posted to /convert, as the raw request body or the image field of a multipart form,\n" +
+		"and responds with their ascii art as text, JSON, HTML, SVG or PNG, picked with the Accept header or the\n" +
+		"output query parameter. Options are passed as query parameters named after the settings JSON fields,\n" +
+		"e.g. ?width=80&colored=true, or as settings JSON. Flags passed to serve are the defaults requests start\n" +
+		"from. Request bodies are limited to --max-input-size, 10MB by default. GET /healthz responds with ok\n" +
+		"while the server is up.",
+	Example: "  ascii-image-converter-wasm serve --addr 127.0.0.1:8080 -C\n" +
+		"  curl --data-binary @image.png -H \"Accept: text/html\" \"http://127.0.0.1:8080/convert?width=80\"",
+	Args: cobra.NoArgs,
+
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if checkFlags() {
+			return
+		}
+
+		if serveConcurrency < 0 {
+			fmt.Printf("Error: --concurrency can't be negative\n\n")
+			return
+		}
+		if serveTimeout < 0 {
+			fmt.Printf("Error: --timeout can't be negative\n\n")
+			return
+		}
+
+		flags, ok := commandLineFlags(cmd.Flags())
+		if !ok {
+			return
+		}
+
+		if flags.MaxInputBytes == 0 {
+			flags.MaxInputBytes = server.DefaultMaxBodyBytes
+		}
+
+		// Requests that don't set a size are given one by the server
+		sized := flags
+		if sized.Dimensions == nil && sized.Width == 0 && sized.Height == 0 {
+			sized.Width = server.DefaultWidth
+		}
+
+		if err := sized.Validate(); err != nil {
+			printFlagErrors(err)
+			return
+		}
+
+		httpServer := &http.Server{
+			Addr: serveAddr,
+			Handler: server.New(server.Config{
+				Flags:         flags,
+				MaxBodyBytes:  flags.MaxInputBytes,
+				Timeout:       serveTimeout,
+				MaxConcurrent: serveConcurrency,
+			}),
+			ReadHeaderTimeout: 10 * time.Second,
+			IdleTimeout:       time.Minute,
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		served := make(chan error, 1)
+		go func() {
+			served <- httpServer.ListenAndServe()
+		}()
+
+		fmt.Printf("Serving on http://%v\n", serveAddr)
+
+		select {
+		case err := <-served:
+			fmt.Printf("Error: %v\n", err)
+			return
+		case <-ctx.Done():
+		}
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		if err := httpServer.Shutdown(shutdownCtx); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+			fmt.Printf("Error: %v\n", err)
+		}
+	},
+}
+
+func init() {
+	serveCmd.Flags().SortFlags = false
+
+	serveCmd.Flags().StringVar(&serveAddr, "addr", "127.0.0.1:8080", "Address to listen on\ne.g. --addr :8080\n")
+	serveCmd.Flags().DurationVar(&serveTimeout, "timeout", server.DefaultTimeout, "Maximum time a conversion can take, including\nwaiting for a free slot\ne.g. --timeout 10s\n")
+	serveCmd.Flags().IntVar(&serveConcurrency, "concurrency", 0, "Maximum number of conversions running at once\n(Defaults to the number of CPUs)\n")
+
+	rootCmd.AddCommand(serveCmd)
+}
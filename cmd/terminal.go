@@ -0,0 +1,49 @@
+//go:build !js && !wasip1
+
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"os"
+
+	"golang.org/x/term"
+)
+
+// Width of the ascii art when no size is passed and no terminal is attached, e.g. with output
+// redirected to a file from a cron job
+const fallbackWidth = 80
+
+// Returns the width and height of the terminal the ascii art is printed to, in characters. Output
+// is often redirected and input piped in, so stderr and stdin are tried after stdout
+func terminalSize() (int, int, error) {
+	var err error
+
+	for _, file := range []*os.File{os.Stdout, os.Stderr, os.Stdin} {
+		var width, height int
+		if width, height, err = term.GetSize(int(file.Fd())); err == nil {
+			return width, height, nil
+		}
+	}
+
+	return 0, 0, err
+}
+
+// Reports whether stdout is a terminal, which binary output shouldn't be written to
+func stdoutIsTerminal() bool {
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
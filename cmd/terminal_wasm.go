@@ -0,0 +1,32 @@
+//go:build js || wasip1
+
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import "errors"
+
+// WASM hosts don't expose a terminal, so sizes must always be passed explicitly
+const fallbackWidth = 0
+
+func terminalSize() (int, int, error) {
+	return 0, 0, errors.New("terminal size is unavailable in WASM builds")
+}
+
+func stdoutIsTerminal() bool {
+	return false
+}
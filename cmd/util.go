@@ -17,121 +17,122 @@ limitations under the License.
 package cmd
 
 import (
+	"errors"
 	"fmt"
-	"path"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/Ares1605/ascii-image-converter-wasm/aic_package"
+	"github.com/Ares1605/ascii-image-converter-wasm/image_formats"
+	image_conversions "github.com/Ares1605/ascii-image-converter-wasm/image_manipulation"
+
+	"github.com/spf13/pflag"
+)
+
+var (
+	// Parsed from the --crop flag's string values
+	crop        []float64
+	cropPercent bool
 )
 
 // Check input and flag values for detecting errors or invalid inputs
 func checkInputAndFlags(args []string) bool {
 
-	gifCount := 0
-	gifPresent := false
-	nonGifPresent := false
-	pipeCharPresent := false
-
-	for _, arg := range args {
-		extension := path.Ext(arg)
-
-		if extension == ".gif" {
-			gifPresent = true
-			gifCount++
-		} else {
-			nonGifPresent = true
+	if formatsTrue {
+		fmt.Printf("Supported input formats:\n\n")
+		for _, format := range image_formats.Formats() {
+			fmt.Printf("%-22v %v\n", format.Description, strings.Join(format.Extensions, " "))
 		}
+		fmt.Println()
 
-		if arg == "-" {
-			pipeCharPresent = true
+		fmt.Printf("Supported resampling filters:\n\n")
+		for _, supportedFilter := range image_conversions.ResampleFilters() {
+			fmt.Printf("%v\n", supportedFilter)
 		}
-	}
-
-	if gifPresent && nonGifPresent {
-		fmt.Printf("Error: There are other inputs along with GIFs\nDue to the potential looping nature of GIFs, non-GIFs must not be supplied alongside\n\n")
+		fmt.Println()
 		return true
 	}
 
-	if gifCount > 1 {
-		fmt.Printf("Error: There are multiple GIFs supplied\nDue to the potential looping nature of GIFs, only one GIF per command is supported\n\n")
+	if len(args) < 1 {
+		fmt.Printf("Error: Need at least 1 input for piped input\nUse the -h flag for more info\n\n")
 		return true
 	}
 
-	if formatsTrue {
-		fmt.Printf("Supported input formats:\n\n" +
-			"JPEG/JPG\n" +
-			"PNG\n" +
-			"WEBP\n" +
-			"BMP\n" +
-			"TIFF/TIF\n" +
-			"GIF\n\n")
+	// Only stdin is read, so several images are passed in as a batch instead
+	if len(args) > 1 || args[0] != "-" {
+		fmt.Printf("Error: Only piped input is supported, pass - as the only input\nTo convert multiple images, pipe them in as a batch with --batch\n\n")
 		return true
 	}
 
-	if len(args) < 1 {
-		fmt.Printf("Error: Need at least 1 input for piped input\nUse the -h flag for more info\n\n")
+	return checkFlags()
+}
+
+// Check values of the conversion flags shared by every command, filling in defaults
+func checkFlags() bool {
+
+	if fit && fill {
+		fmt.Printf("Error: --fit and --fill can't be used together\n\n")
 		return true
 	}
 
-	if len(args) > 1 && pipeCharPresent {
-		fmt.Printf("Error: You cannot pass in piped input alongside other inputs\n\n")
+	if cellAspect < 0 {
+		fmt.Printf("Error: --cell-aspect can't be negative\n\n")
 		return true
 	}
 
-	if customMap != "" && len(customMap) < 2 {
-		fmt.Printf("Need at least 2 characters for --map flag\n\n")
+	if (cellWidth > 0) != (cellHeight > 0) {
+		fmt.Printf("Error: --cell-width and --cell-height must be passed together\n\n")
 		return true
 	}
 
-	if dimensions != nil {
-
-		numberOfDimensions := len(dimensions)
-		if numberOfDimensions != 2 {
-			fmt.Printf("Error: requires 2 dimensions, got %v\n\n", numberOfDimensions)
-			return true
-		}
-
-		if dimensions[0] < 1 || dimensions[1] < 1 {
-			fmt.Printf("Error: invalid values for dimensions\n\n")
+	if fontColor == nil {
+		fontColor = []int{255, 255, 255}
+	} else {
+		fontColorValues := len(fontColor)
+		if fontColorValues != 3 {
+			fmt.Printf("Error: --font-color requires 3 values for RGB, got %v\n\n", fontColorValues)
 			return true
 		}
 	}
 
-	if width != 0 || height != 0 {
-
-		if width != 0 && height != 0 {
-			fmt.Printf("Error: both --width and --height can't be set. Use --dimensions instead\n\n")
+	if cropRegion != nil {
+		if len(cropRegion) != 4 {
+			fmt.Printf("Error: --crop requires 4 values for x, y, width and height, got %v\n\n", len(cropRegion))
 			return true
+		}
 
-		} else {
+		percentages := 0
+		crop = make([]float64, 4)
 
-			if width < 0 {
-				fmt.Printf("Error: invalid value for width\n\n")
-				return true
+		for i, value := range cropRegion {
+			if strings.HasSuffix(value, "%") {
+				percentages++
+				value = strings.TrimSuffix(value, "%")
 			}
 
-			if height < 0 {
-				fmt.Printf("Error: invalid value for height\n\n")
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				fmt.Printf("Error: invalid value for --crop: %v\n\n", cropRegion[i])
 				return true
 			}
-
-		}
-
-	}
-
-	if fontColor == nil {
-		fontColor = []int{255, 255, 255}
-	} else {
-		fontColorValues := len(fontColor)
-		if fontColorValues != 3 {
-			fmt.Printf("Error: --font-color requires 3 values for RGB, got %v\n\n", fontColorValues)
-			return true
+			crop[i] = parsed
 		}
 
-		if fontColor[0] < 0 || fontColor[1] < 0 || fontColor[2] < 0 {
-			fmt.Printf("Error: RBG values must be between 0 and 255\n\n")
+		if percentages != 0 && percentages != 4 {
+			fmt.Printf("Error: --crop values must either all be pixels or all be percentages\n\n")
 			return true
 		}
+		cropPercent = percentages == 4
+	}
 
-		if fontColor[0] > 255 || fontColor[1] > 255 || fontColor[2] > 255 {
-			fmt.Printf("Error: RBG values must be between 0 and 255\n\n")
+	if rotateBg == nil {
+		rotateBg = []int{0, 0, 0}
+	} else {
+		rotateBgValues := len(rotateBg)
+		if rotateBgValues != 3 {
+			fmt.Printf("Error: --rotate-bg requires 3 values for RGB, got %v\n\n", rotateBgValues)
 			return true
 		}
 	}
@@ -140,15 +141,304 @@ func checkInputAndFlags(args []string) bool {
 		threshold = 128
 	}
 
-	if threshold < 0 || threshold > 255 {
-		fmt.Printf("Error: threshold must be between 0 and 255\n\n")
+	return false
+}
+
+// Check values of the flags only used for converting input, see addConvertFlags()
+func checkConvertFlags() bool {
+
+	if outputFormat != "" && outputFormat != "asciicast" {
+		fmt.Printf("Error: --format must be asciicast, got %q\n\n", outputFormat)
+		return true
+	}
+
+	if batch && outputFormat != "" {
+		fmt.Printf("Error: --batch and --format can't be used together\n\n")
+		return true
+	}
+
+	if jobs < 0 {
+		fmt.Printf("Error: --jobs must be greater than 0\n\n")
 		return true
+	} else if jobs == 0 {
+		jobs = runtime.NumCPU()
 	}
 
-	if dither && !braille {
-		fmt.Printf("Error: image dithering is only reserved for --braille flag\n\n")
+	if outputFormat != "" && jsonOutput {
+		fmt.Printf("Error: --format and --json can't be used together\n\n")
 		return true
 	}
 
 	return false
 }
+
+/*
+Builds aic_package.Flags from the command line flags, on top of --preset and --settings-json if passed.
+Defaults to fitting the ascii art inside the terminal if no size is set, or to fallbackWidth if no
+terminal is attached. Errors are printed, in which case ok is false
+*/
+func buildFlags(cmdFlags *pflag.FlagSet) (flags aic_package.Flags, ok bool) {
+
+	flags, ok = commandLineFlags(cmdFlags)
+	if !ok {
+		return flags, false
+	}
+
+	// Default to fitting the ascii art inside the terminal
+	if flags.Dimensions == nil && flags.Width == 0 && flags.Height == 0 {
+		termWidth, termHeight, err := terminalSize()
+
+		switch {
+		case err == nil:
+			// Leave a line for the prompt
+			flags.Width, flags.Height = termWidth, max(termHeight-1, 1)
+			flags.FitMode = image_conversions.FitWithin
+		case fallbackWidth > 0:
+			flags.Width = fallbackWidth
+		default:
+			fmt.Printf("Error: unable to determine terminal size: %v\nUse --dimensions, --width or --height instead\n\n", err)
+			return flags, false
+		}
+	}
+
+	if err := flags.Validate(); err != nil {
+		printFlagErrors(err)
+		return flags, false
+	}
+
+	return flags, true
+}
+
+// Builds aic_package.Flags from the command line flags, on top of --preset and --settings-json if
+// passed, without validating them. Errors are printed, in which case ok is false
+func commandLineFlags(cmdFlags *pflag.FlagSet) (flags aic_package.Flags, ok bool) {
+
+	flags = aic_package.Flags{
+		Complex:             complex,
+		Dimensions:          dimensions,
+		Width:               width,
+		Height:              height,
+		FitMode:             image_conversions.FitNone,
+		Negative:            negative,
+		Colored:             colored,
+		CharBackgroundColor: colorBg,
+		Grayscale:           grayscale,
+		CustomMap:           customMap,
+		FlipX:               flipX,
+		FlipY:               flipY,
+		JsonOutput:          jsonOutput,
+		FontColor:           [3]int{fontColor[0], fontColor[1], fontColor[2]},
+		Braille:             braille,
+		Threshold:           threshold,
+		Dither:              dither,
+		Brightness:          brightness,
+		Contrast:            contrast,
+		Gamma:               gamma,
+		AutoLevels:          autoLevels,
+		Equalize:            equalize,
+		Crop:                crop,
+		CropPercent:         cropPercent,
+		Rotate:              rotate,
+		RotateBackground:    [3]int{rotateBg[0], rotateBg[1], rotateBg[2]},
+		IgnoreExif:          ignoreExif,
+		AlphaBackground:     alphaBg,
+		TransparentEmpty:    emptyAlpha,
+		ResampleFilter:      image_conversions.ResampleFilter(filter),
+		CellAspect:          cellAspect,
+		CellWidth:           cellWidth,
+		CellHeight:          cellHeight,
+		MaxInputBytes:       maxInputSize,
+		MaxPixels:           maxPixels,
+		FrameRate:           frameRate,
+		// By default, color level is set to true (24-bit) color
+		ColorLevel: image_conversions.Millions,
+	}
+	if fit {
+		flags.FitMode = image_conversions.FitWithin
+	} else if fill {
+		flags.FitMode = image_conversions.FitFill
+	}
+	if hundredsColor {
+		flags.ColorLevel = image_conversions.Hundreds
+	}
+
+	if presetName != "" || settingsJSON != "" {
+		var err error
+		if flags, err = applySettings(flags, cmdFlags); err != nil {
+			fmt.Printf("Error: %v\n\n", err)
+			return flags, false
+		}
+	}
+
+	return flags, true
+}
+
+// Copies the fields of aic_package.Flags set by the named command line flag from cli, so that flags
+// passed explicitly take precedence over --preset and --settings-json
+func copyFlag(name string, flags *aic_package.Flags, cli aic_package.Flags) {
+	switch name {
+	case "color":
+		flags.Colored = cli.Colored
+	case "color-bg":
+		flags.CharBackgroundColor = cli.CharBackgroundColor
+	case "dimensions":
+		flags.Dimensions = cli.Dimensions
+	case "width":
+		flags.Width = cli.Width
+	case "height":
+		flags.Height = cli.Height
+	case "fit", "fill":
+		flags.FitMode = cli.FitMode
+	case "cell-aspect":
+		flags.CellAspect = cli.CellAspect
+	case "cell-width":
+		flags.CellWidth = cli.CellWidth
+	case "cell-height":
+		flags.CellHeight = cli.CellHeight
+	case "map":
+		flags.CustomMap = cli.CustomMap
+	case "braille":
+		flags.Braille = cli.Braille
+	case "threshold":
+		flags.Threshold = cli.Threshold
+	case "dither":
+		flags.Dither = cli.Dither
+	case "crop":
+		flags.Crop, flags.CropPercent = cli.Crop, cli.CropPercent
+	case "rotate":
+		flags.Rotate = cli.Rotate
+	case "rotate-bg":
+		flags.RotateBackground = cli.RotateBackground
+	case "ignore-exif":
+		flags.IgnoreExif = cli.IgnoreExif
+	case "alpha-bg":
+		flags.AlphaBackground = cli.AlphaBackground
+	case "transparent-empty":
+		flags.TransparentEmpty = cli.TransparentEmpty
+	case "brightness":
+		flags.Brightness = cli.Brightness
+	case "contrast":
+		flags.Contrast = cli.Contrast
+	case "gamma":
+		flags.Gamma = cli.Gamma
+	case "auto-levels":
+		flags.AutoLevels = cli.AutoLevels
+	case "equalize":
+		flags.Equalize = cli.Equalize
+	case "filter":
+		flags.ResampleFilter = cli.ResampleFilter
+	case "grayscale":
+		flags.Grayscale = cli.Grayscale
+	case "complex":
+		flags.Complex = cli.Complex
+	case "negative":
+		flags.Negative = cli.Negative
+	case "flipX":
+		flags.FlipX = cli.FlipX
+	case "flipY":
+		flags.FlipY = cli.FlipY
+	case "256-color":
+		flags.ColorLevel = cli.ColorLevel
+	case "font-color":
+		flags.FontColor = cli.FontColor
+	}
+}
+
+/*
+Applies --preset and then --settings-json to flags built from the command line, keeping the values of
+the flags that were passed explicitly
+*/
+func applySettings(cli aic_package.Flags, cmdFlags *pflag.FlagSet) (aic_package.Flags, error) {
+	flags := cli
+
+	if presetName != "" {
+		preset, err := aic_package.Preset(presetName)
+		if err != nil {
+			return flags, err
+		}
+
+		if flags, err = preset.Apply(flags); err != nil {
+			return flags, err
+		}
+	}
+
+	if settingsJSON != "" {
+		settings, err := aic_package.ParseSettings([]byte(settingsJSON))
+		if err != nil {
+			return flags, fmt.Errorf("--settings-json: %w", err)
+		}
+
+		if flags, err = settings.Apply(flags); err != nil {
+			return flags, fmt.Errorf("--settings-json: %w", err)
+		}
+	}
+
+	cmdFlags.Visit(func(flag *pflag.Flag) {
+		copyFlag(flag.Name, &flags, cli)
+	})
+
+	return flags, nil
+}
+
+// Reads piped input, up to maxInputBytes bytes. Errors are printed, in which case ok is false
+func readStdin(maxInputBytes int64) (inputBytes []byte, ok bool) {
+
+	// Check file/data type of piped input
+	if !aic_package.IsInputFromPipe() {
+		fmt.Printf("there is no input being piped to stdin\n")
+		return nil, false
+	}
+
+	inputBytes, err := aic_package.ReadInput(os.Stdin, maxInputBytes)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return nil, false
+	}
+
+	return inputBytes, true
+}
+
+// Command line flag each field of aic_package.Flags is set from, for reporting validation errors
+var flagNames = map[string]string{
+	"Dimensions":       "--dimensions",
+	"Width":            "--width",
+	"Height":           "--height",
+	"FitMode":          "--fit/--fill",
+	"CustomMap":        "--map",
+	"FontColor":        "--font-color",
+	"Threshold":        "--threshold",
+	"Dither":           "--dither",
+	"ColorLevel":       "--256-color",
+	"Brightness":       "--brightness",
+	"Contrast":         "--contrast",
+	"Gamma":            "--gamma",
+	"Crop":             "--crop",
+	"Rotate":           "--rotate",
+	"RotateBackground": "--rotate-bg",
+	"AlphaBackground":  "--alpha-bg",
+	"ResampleFilter":   "--filter",
+	"CellAspect":       "--cell-aspect",
+	"CellWidth":        "--cell-width",
+	"CellHeight":       "--cell-height",
+	"MaxInputBytes":    "--max-input-size",
+	"MaxPixels":        "--max-pixels",
+	"FrameRate":        "--frame-rate",
+}
+
+// Prints every error returned by aic_package.Flags.Validate(), named after its command line flag
+func printFlagErrors(err error) {
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+
+	for _, err := range errs {
+		var flagErr *aic_package.FlagError
+		if errors.As(err, &flagErr) {
+			fmt.Printf("Error: %v: %v\n", flagNames[flagErr.Field], flagErr.Err)
+		} else {
+			fmt.Printf("Error: %v\n", err)
+		}
+	}
+	fmt.Println()
+}
@@ -0,0 +1,139 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Ares1605/ascii-image-converter-wasm/aic_package"
+	"github.com/Ares1605/ascii-image-converter-wasm/image_formats"
+)
+
+/*
+Reads piped input, telling still images apart from video streams. Y4M input is always a video
+stream, while JPEG input is only an MJPEG stream if another JPEG follows the first one. Exactly one
+of the returned video stream and image bytes is set
+*/
+func readPipedInput(stdin io.Reader, maxInputBytes int64) (io.Reader, []byte, error) {
+	buffered := bufio.NewReader(stdin)
+
+	header, err := buffered.Peek(len("YUV4MPEG2 "))
+	if err != nil && err != io.EOF {
+		return nil, nil, fmt.Errorf("unable to read input: %v", err)
+	}
+
+	format, _ := image_formats.Sniff(header)
+
+	switch format.Name {
+	case "y4m":
+		return buffered, nil, nil
+
+	case "jpeg":
+		// The limit applies to the first frame whether it turns out to be a still image or not, and
+		// ConvertVideo() applies it to the frames following it
+		firstFrame, err := image_formats.ReadJPEGFrame(buffered, maxInputBytes)
+		if errors.Is(err, image_formats.ErrFrameTooLarge) {
+			return nil, nil, fmt.Errorf("%w: input exceeds the limit of %v bytes", aic_package.ErrInputTooLarge, maxInputBytes)
+		} else if err != nil {
+			return nil, nil, fmt.Errorf("unable to read input: %v", err)
+		}
+
+		if next, _ := buffered.Peek(2); bytes.Equal(next, []byte{0xff, 0xd8}) {
+			return io.MultiReader(bytes.NewReader(firstFrame), buffered), nil, nil
+		}
+
+		return nil, firstFrame, nil
+	}
+
+	inputBytes, err := aic_package.ReadInput(buffered, maxInputBytes)
+	return nil, inputBytes, err
+}
+
+/*
+Converts a video stream frame by frame. Frames are played back on the terminal as they're converted,
+paced to the stream's frame rate, dropping frames if conversion falls behind. With --json, a single JSON document holding the frame rate and
+every frame is written instead, with frames written as they're converted
+*/
+func playVideo(video io.Reader, flags aic_package.Flags) error {
+	if flags.JsonOutput {
+		return writeVideoDocument(os.Stdout, video, flags)
+	}
+
+	restore := aic_package.EnterPlaybackScreen(os.Stdout)
+
+	// The last frame is printed again once the terminal's screen is restored, so it stays visible
+	var lastFrame string
+
+	err := aic_package.Play(os.Stdout, 1, func(emit func(frame aic_package.PlaybackFrame) error) error {
+		return aic_package.ConvertVideo(video, flags, func(frame aic_package.VideoFrame) error {
+			lastFrame = frame.Ascii
+			return emit(aic_package.PlaybackFrame{Ascii: frame.Ascii, Delay: frame.Delay})
+		})
+	})
+
+	restore()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(lastFrame)
+	return nil
+}
+
+/*
+Writes the JSON document of a video stream to w. If conversion fails, the document is left
+unterminated, so a stream that broke off can't be mistaken for a complete one
+*/
+func writeVideoDocument(w io.Writer, video io.Reader, flags aic_package.Flags) error {
+	out := bufio.NewWriter(w)
+	defer out.Flush()
+
+	written := 0
+
+	err := aic_package.ConvertVideo(video, flags, func(frame aic_package.VideoFrame) error {
+		marshalled, err := json.Marshal(frame.Chars)
+		if err != nil {
+			return err
+		}
+
+		if frame.Index == 0 {
+			fmt.Fprintf(out, `{"frameRate":%v,"frames":[`, frame.FrameRate)
+		} else {
+			out.WriteString(",")
+		}
+		out.Write(marshalled)
+		written++
+
+		return out.Flush()
+	})
+	if err != nil {
+		return err
+	}
+
+	if written == 0 {
+		out.WriteString(`{"frameRate":0,"frames":[`)
+	}
+	out.WriteString("]}\n")
+
+	return out.Flush()
+}
@@ -0,0 +1,82 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Ares1605/ascii-image-converter-wasm/aic_package"
+)
+
+func TestWriteVideoDocument(t *testing.T) {
+	header := "YUV4MPEG2 W4 H2 F10:1 Cmono\n"
+	frame := "FRAME\n" + strings.Repeat("\x80", 4*2)
+
+	tests := []struct {
+		name     string
+		input    string
+		frames   int
+		complete bool
+	}{
+		{"complete stream", header + frame + frame, 2, true},
+		{"empty stream", header, 0, true},
+		{"stream broken off mid-frame", header + frame + frame[:len(frame)-1], 1, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			flags := aic_package.DefaultFlags()
+			flags.Dimensions = []int{4, 2}
+			flags.JsonOutput = true
+
+			var out bytes.Buffer
+			err := writeVideoDocument(&out, strings.NewReader(test.input), flags)
+
+			var document struct {
+				FrameRate float64
+				Frames    [][][]aic_package.ColoredChar
+			}
+			decodeErr := json.Unmarshal(out.Bytes(), &document)
+
+			if !test.complete {
+				if err == nil {
+					t.Error("got no error for a broken stream")
+				}
+				if decodeErr == nil {
+					t.Errorf("got a complete document for a broken stream: %s", out.String())
+				}
+				if want := `{"frameRate":10,"frames":[`; !strings.HasPrefix(out.String(), want) {
+					t.Errorf("got %q, want the frames converted before the error after %q", out.String(), want)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decodeErr != nil {
+				t.Fatalf("invalid document %s: %v", out.String(), decodeErr)
+			}
+			if len(document.Frames) != test.frames {
+				t.Errorf("got %v frames, want %v", len(document.Frames), test.frames)
+			}
+		})
+	}
+}
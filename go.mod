@@ -1,6 +1,6 @@
 module github.com/Ares1605/ascii-image-converter-wasm
 
-go 1.25
+go 1.25.0
 
 require (
 	github.com/disintegration/imaging v1.6.2
@@ -9,12 +9,14 @@ require (
 	github.com/gookit/color v1.4.2
 	github.com/makeworld-the-better-one/dither/v2 v2.2.0
 	github.com/spf13/cobra v1.1.3
+	github.com/spf13/pflag v1.0.5
+	github.com/tetratelabs/wazero v1.12.0
 	golang.org/x/image v0.0.0-20210628002857-a66eb6448b8d
+	golang.org/x/sys v0.44.0
+	golang.org/x/term v0.38.0
 )
 
 require (
 	github.com/inconshreveable/mousetrap v1.0.0 // indirect
-	github.com/spf13/pflag v1.0.5 // indirect
 	github.com/xo/terminfo v0.0.0-20210125001918-ca9a967f8778 // indirect
-	golang.org/x/sys v0.0.0-20210601080250-7ecdf8ef093b // indirect
 )
@@ -0,0 +1,245 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image_formats
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"time"
+)
+
+// Frame is a single frame of an animation, already composited onto the full canvas
+type Frame struct {
+	Image image.Image
+
+	// How long the frame is displayed for
+	Delay time.Duration
+}
+
+// Animation holds the frames of an animated image, ready to be displayed one after another
+type Animation struct {
+	Frames []Frame
+
+	// Number of times the animation is played. 0 means it loops forever
+	LoopCount int
+}
+
+/*
+IsAnimated reports whether data holds an animation: any GIF, a PNG with an acTL chunk (APNG), or a
+WebP with the animation flag set. Stills in those formats are decoded with image.Decode() instead
+*/
+func IsAnimated(data []byte) bool {
+	format, ok := Sniff(data)
+	if !ok {
+		return false
+	}
+
+	switch format.Name {
+	case "gif":
+		return true
+	case "png":
+		return isAPNG(data)
+	case "webp":
+		return isAnimatedWebP(data)
+	}
+
+	return false
+}
+
+/*
+DecodeAnimation decodes every frame of a GIF, APNG or animated WebP. Frames only covering part of
+the canvas are blended and disposed of as their format specifies, so each returned frame is a
+complete image of the canvas' size.
+
+Every frame is held in memory at once. NewAnimationDecoder() decodes them one at a time instead
+*/
+func DecodeAnimation(data []byte) (*Animation, error) {
+	decoder, err := NewAnimationDecoder(data)
+	if err != nil {
+		return nil, err
+	}
+
+	animation := &Animation{Frames: make([]Frame, 0, decoder.Frames()), LoopCount: decoder.LoopCount}
+
+	for {
+		frame, err := decoder.Next()
+		if err == io.EOF {
+			return animation, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		animation.Frames = append(animation.Frames, frame)
+	}
+}
+
+/*
+AnimationDecoder decodes the frames of a GIF, APNG or animated WebP one at a time, in order. Only the
+frames' positions in the input are read up front, so the number of frames, their delays and the size
+of the canvas are known before any frame is decoded
+*/
+type AnimationDecoder struct {
+	// Size of the canvas every frame is composited onto
+	Width, Height int
+
+	// Number of times the animation is played. 0 means it loops forever
+	LoopCount int
+
+	frames []animationFrame
+	next   int
+	canvas *compositor
+}
+
+// A frame yet to be decoded, along with how it's drawn onto the canvas
+type animationFrame struct {
+	// Decodes the frame, with its bounds set to the area of the canvas it covers
+	decode func() (image.Image, error)
+
+	delay   time.Duration
+	blend   bool
+	dispose disposal
+}
+
+// NewAnimationDecoder() reads the layout of an animation in a format listed by IsAnimated()
+func NewAnimationDecoder(data []byte) (*AnimationDecoder, error) {
+	format, _ := Sniff(data)
+
+	switch format.Name {
+	case "gif":
+		return readGIFAnimation(data)
+	case "png":
+		return readAPNG(data)
+	case "webp":
+		return readAnimatedWebP(data)
+	}
+
+	return nil, FormatError("input isn't an animation")
+}
+
+// Frames() returns the number of frames in the animation
+func (d *AnimationDecoder) Frames() int {
+	return len(d.frames)
+}
+
+// Delays() returns how long each frame is displayed for
+func (d *AnimationDecoder) Delays() []time.Duration {
+	delays := make([]time.Duration, len(d.frames))
+	for i, frame := range d.frames {
+		delays[i] = frame.delay
+	}
+	return delays
+}
+
+// Next() decodes the next frame and composites it onto the canvas. It returns io.EOF once every
+// frame has been decoded
+func (d *AnimationDecoder) Next() (Frame, error) {
+	if d.next >= len(d.frames) {
+		return Frame{}, io.EOF
+	}
+	frame := d.frames[d.next]
+
+	frameImage, err := frame.decode()
+	if err != nil {
+		return Frame{}, err
+	}
+	d.next++
+
+	if d.canvas == nil {
+		d.canvas = newCompositor(d.Width, d.Height)
+	}
+
+	return Frame{Image: d.canvas.drawFrame(frameImage, frame.blend, frame.dispose), Delay: frame.delay}, nil
+}
+
+// How the area of a frame is treated once the frame has been displayed
+type disposal int
+
+const (
+	// Leave the frame on the canvas
+	disposeNone disposal = iota
+
+	// Clear the frame's area to transparent
+	disposeBackground
+
+	// Restore the frame's area to what it was before the frame was drawn
+	disposePrevious
+)
+
+/*
+Composites partial frames onto a canvas. Each call to drawFrame() draws a frame in its area and
+returns a snapshot of the whole canvas, after disposing of the previous frame's area
+*/
+type compositor struct {
+	canvas *image.NRGBA
+
+	// Disposal of the last drawn frame, applied before the next one is drawn
+	pending     disposal
+	pendingRect image.Rectangle
+	previous    *image.NRGBA
+}
+
+func newCompositor(width, height int) *compositor {
+	return &compositor{canvas: image.NewNRGBA(image.Rect(0, 0, width, height))}
+}
+
+// Draws frame with its top left corner at the frame's bounds, replacing the area if blend is false
+func (c *compositor) drawFrame(frame image.Image, blend bool, dispose disposal) image.Image {
+	switch c.pending {
+	case disposeBackground:
+		draw.Draw(c.canvas, c.pendingRect, image.Transparent, image.Point{}, draw.Src)
+	case disposePrevious:
+		draw.Draw(c.canvas, c.pendingRect, c.previous, c.pendingRect.Min, draw.Src)
+	}
+
+	rect := frame.Bounds().Intersect(c.canvas.Rect)
+
+	if dispose == disposePrevious {
+		if c.previous == nil {
+			c.previous = image.NewNRGBA(c.canvas.Rect)
+		}
+		draw.Draw(c.previous, rect, c.canvas, rect.Min, draw.Src)
+	}
+
+	op := draw.Src
+	if blend {
+		op = draw.Over
+	}
+	draw.Draw(c.canvas, rect, frame, rect.Min, op)
+
+	c.pending, c.pendingRect = dispose, rect
+
+	snapshot := image.NewNRGBA(c.canvas.Rect)
+	copy(snapshot.Pix, c.canvas.Pix)
+
+	return snapshot
+}
+
+// Moves img so that its bounds start at (x, y), without copying pixels
+type offsetImage struct {
+	image.Image
+	offset image.Point
+}
+
+func (o offsetImage) Bounds() image.Rectangle {
+	return o.Image.Bounds().Sub(o.Image.Bounds().Min).Add(o.offset)
+}
+
+func (o offsetImage) At(x, y int) color.Color {
+	return o.Image.At(x-o.offset.X+o.Image.Bounds().Min.X, y-o.offset.Y+o.Image.Bounds().Min.Y)
+}
@@ -0,0 +1,141 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image_formats
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"io"
+	"slices"
+	"testing"
+	"time"
+)
+
+// A frame of a generated APNG or animated WebP, of a single color
+type testFrame struct {
+	x, y, width, height int
+	fill                color.NRGBA
+	delay               time.Duration
+	dispose             disposal
+	blend               bool
+}
+
+// Colors of the generated animations' frames
+var (
+	transparent = color.NRGBA{}
+	red         = color.NRGBA{255, 0, 0, 255}
+	green       = color.NRGBA{0, 255, 0, 255}
+	halfBlue    = color.NRGBA{0, 0, 255, 128}
+)
+
+// Returns src alpha blended over dst
+func over(src, dst color.Color) color.Color {
+	pixel := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	pixel.Set(0, 0, dst)
+	draw.Draw(pixel, pixel.Rect, image.NewUniform(src), image.Point{}, draw.Over)
+	return pixel.At(0, 0)
+}
+
+// Decodes every frame of an animation, checking each against the canvas it should show and its delay
+func checkAnimation(t *testing.T, data []byte, loopCount int, want [][][]color.Color, delays []time.Duration) {
+	t.Helper()
+
+	decoder, err := NewAnimationDecoder(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoder.Frames() != len(want) || decoder.LoopCount != loopCount {
+		t.Fatalf("got %v frames and a loop count of %v, want %v and %v", decoder.Frames(), decoder.LoopCount, len(want), loopCount)
+	}
+	if got := decoder.Delays(); !slices.Equal(got, delays) {
+		t.Errorf("got delays %v, want %v", got, delays)
+	}
+
+	for i, canvas := range want {
+		frame, err := decoder.Next()
+		if err != nil {
+			t.Fatalf("frame %v: %v", i, err)
+		}
+
+		t.Run(fmt.Sprintf("frame %v", i), func(t *testing.T) {
+			checkPixels(t, frame.Image, canvas)
+		})
+	}
+
+	if _, err := decoder.Next(); err != io.EOF {
+		t.Errorf("got %v after the last frame, want io.EOF", err)
+	}
+}
+
+// Encodes a GIF of the passed frames, each covering the whole 4x4 canvas in a single color
+func testGIF(t *testing.T, loopCount int, delays []int, disposals []byte, indices ...uint8) []byte {
+	t.Helper()
+
+	palette := color.Palette{color.Transparent, color.RGBA{255, 0, 0, 255}, color.RGBA{0, 255, 0, 255}, color.RGBA{0, 0, 255, 255}}
+
+	animation := &gif.GIF{LoopCount: loopCount, Delay: delays, Disposal: disposals}
+	for _, index := range indices {
+		frame := image.NewPaletted(image.Rect(0, 0, 4, 4), palette)
+		for i := range frame.Pix {
+			frame.Pix[i] = index
+		}
+		animation.Image = append(animation.Image, frame)
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, animation); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestAnimationDecoderIsLazy(t *testing.T) {
+	data := testGIF(t, 0, []int{10, 20}, nil, 1, 2)
+
+	// A third frame whose image data can't be decoded replaces the trailer
+	data = append(data[:len(data)-1], gifImageDescriptor, 0, 0, 0, 0, 1, 0, 1, 0, 0, 2, 1, 0xff, 0, gifTrailer)
+
+	decoder, err := NewAnimationDecoder(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if decoder.Width != 4 || decoder.Height != 4 || decoder.Frames() != 3 || decoder.LoopCount != 0 {
+		t.Errorf("got a %vx%v canvas, %v frames and a loop count of %v, want 4x4, 3 and 0", decoder.Width, decoder.Height, decoder.Frames(), decoder.LoopCount)
+	}
+	if delays := decoder.Delays(); !slices.Equal(delays, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 0}) {
+		t.Errorf("delays %v, want 100ms, 200ms and 0", delays)
+	}
+
+	// Frames are only decoded once they're asked for
+	for i := 0; i < 2; i++ {
+		if _, err := decoder.Next(); err != nil {
+			t.Fatalf("frame %v: %v", i, err)
+		}
+	}
+	if _, err := decoder.Next(); err == nil || err == io.EOF {
+		t.Errorf("decoding the corrupt frame returned %v, want an error", err)
+	}
+
+	if _, err := DecodeAnimation(data); err == nil {
+		t.Error("DecodeAnimation succeeded, want the corrupt frame's error")
+	}
+}
@@ -0,0 +1,284 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image_formats
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"io"
+	"time"
+)
+
+/*
+Animated PNGs, see https://wiki.mozilla.org/APNG_Specification. Each frame's image data is stored
+in IDAT or fdAT chunks following an fcTL chunk. Frames are decoded by rebuilding them as standalone
+PNGs, which share the animation's header chunks, such as its palette
+*/
+
+const pngSignature = "\x89PNG\r\n\x1a\n"
+
+type pngChunk struct {
+	kind string
+	data []byte
+}
+
+// Splits a PNG into its chunks, stopping at IEND. Checksums are left to the png package
+func readPNGChunks(data []byte) ([]pngChunk, error) {
+	if !bytes.HasPrefix(data, []byte(pngSignature)) {
+		return nil, FormatError("png: invalid signature")
+	}
+	data = data[len(pngSignature):]
+
+	var chunks []pngChunk
+
+	for len(data) > 0 {
+		if len(data) < 12 {
+			return nil, unexpectedEOF("png", io.EOF)
+		}
+
+		length := binary.BigEndian.Uint32(data)
+		if uint64(length)+12 > uint64(len(data)) {
+			return nil, unexpectedEOF("png", io.EOF)
+		}
+
+		chunk := pngChunk{kind: string(data[4:8]), data: data[8 : 8+length]}
+		if chunk.kind == "IEND" {
+			break
+		}
+
+		chunks = append(chunks, chunk)
+		data = data[12+length:]
+	}
+
+	return chunks, nil
+}
+
+// An APNG's acTL chunk must come before its first IDAT chunk
+func isAPNG(data []byte) bool {
+	if !bytes.HasPrefix(data, []byte(pngSignature)) {
+		return false
+	}
+	data = data[len(pngSignature):]
+
+	for len(data) >= 8 {
+		length := binary.BigEndian.Uint32(data)
+
+		switch string(data[4:8]) {
+		case "acTL":
+			return true
+		case "IDAT":
+			return false
+		}
+
+		if uint64(length)+12 > uint64(len(data)) {
+			return false
+		}
+		data = data[12+length:]
+	}
+
+	return false
+}
+
+func writePNGChunk(buf *bytes.Buffer, kind string, data []byte) {
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(data)))
+	buf.Write(length[:])
+
+	start := buf.Len()
+	buf.WriteString(kind)
+	buf.Write(data)
+
+	var crc [4]byte
+	binary.BigEndian.PutUint32(crc[:], crc32.ChecksumIEEE(buf.Bytes()[start:]))
+	buf.Write(crc[:])
+}
+
+// Frame control chunk, describing a frame's region, delay and how it's blended and disposed of
+type apngFrameControl struct {
+	width, height int
+	x, y          int
+	delay         time.Duration
+	dispose       disposal
+	blend         bool
+}
+
+func readFrameControl(data []byte) (apngFrameControl, error) {
+	if len(data) != 26 {
+		return apngFrameControl{}, FormatError("png: invalid fcTL chunk")
+	}
+
+	fc := apngFrameControl{
+		width:  int(binary.BigEndian.Uint32(data[4:])),
+		height: int(binary.BigEndian.Uint32(data[8:])),
+		x:      int(binary.BigEndian.Uint32(data[12:])),
+		y:      int(binary.BigEndian.Uint32(data[16:])),
+		blend:  data[25] == 1,
+	}
+
+	// The delay is a fraction of a second, where a denominator of 0 stands for 100
+	numerator, denominator := binary.BigEndian.Uint16(data[20:]), binary.BigEndian.Uint16(data[22:])
+	if denominator == 0 {
+		denominator = 100
+	}
+	fc.delay = time.Duration(numerator) * time.Second / time.Duration(denominator)
+
+	switch data[24] {
+	case 1:
+		fc.dispose = disposeBackground
+	case 2:
+		fc.dispose = disposePrevious
+	}
+
+	return fc, nil
+}
+
+func readAPNG(data []byte) (*AnimationDecoder, error) {
+	chunks, err := readPNGChunks(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(chunks) == 0 || chunks[0].kind != "IHDR" || len(chunks[0].data) != 13 {
+		return nil, FormatError("png: missing IHDR chunk")
+	}
+	ihdr := chunks[0].data
+
+	canvasWidth := int(binary.BigEndian.Uint32(ihdr[0:]))
+	canvasHeight := int(binary.BigEndian.Uint32(ihdr[4:]))
+	if err := checkDimensions("png", uint64(canvasWidth), uint64(canvasHeight), 4); err != nil {
+		return nil, err
+	}
+
+	decoder := &AnimationDecoder{Width: canvasWidth, Height: canvasHeight}
+
+	var (
+		// Chunks before the image data, other than animation chunks, are shared by every frame
+		shared    []pngChunk
+		seenIDAT  bool
+		control   *apngFrameControl
+		imageData [][]byte
+		frames    []apngFrameControl
+		images    [][][]byte
+	)
+
+	// Finishes the frame whose data has been collected so far
+	endFrame := func() {
+		if control != nil && len(imageData) > 0 {
+			frames = append(frames, *control)
+			images = append(images, imageData)
+		}
+		control, imageData = nil, nil
+	}
+
+	for _, chunk := range chunks[1:] {
+		switch chunk.kind {
+		case "acTL":
+			if len(chunk.data) != 8 {
+				return nil, FormatError("png: invalid acTL chunk")
+			}
+			decoder.LoopCount = int(binary.BigEndian.Uint32(chunk.data[4:]))
+
+		case "fcTL":
+			endFrame()
+
+			fc, err := readFrameControl(chunk.data)
+			if err != nil {
+				return nil, err
+			}
+			control = &fc
+
+		case "IDAT":
+			seenIDAT = true
+			// The default image is only part of the animation if an fcTL chunk precedes it
+			if control != nil {
+				imageData = append(imageData, chunk.data)
+			}
+
+		case "fdAT":
+			if len(chunk.data) < 4 {
+				return nil, FormatError("png: invalid fdAT chunk")
+			}
+			if control != nil {
+				imageData = append(imageData, chunk.data[4:])
+			}
+
+		default:
+			if !seenIDAT {
+				shared = append(shared, chunk)
+			}
+		}
+	}
+	endFrame()
+
+	if len(frames) == 0 {
+		return nil, FormatError("png: animation has no frames")
+	}
+
+	canvasRect := image.Rect(0, 0, canvasWidth, canvasHeight)
+
+	for i, fc := range frames {
+		region := image.Rect(fc.x, fc.y, fc.x+fc.width, fc.y+fc.height)
+		if region.Empty() || !region.In(canvasRect) {
+			return nil, FormatError("png: frame lies outside of the canvas")
+		}
+
+		// Disposing of the first frame to the previous canvas clears it instead
+		if i == 0 && fc.dispose == disposePrevious {
+			fc.dispose = disposeBackground
+		}
+
+		decoder.frames = append(decoder.frames, animationFrame{
+			decode: func() (image.Image, error) {
+				return decodeAPNGFrame(ihdr, shared, images[i], region)
+			},
+			delay:   fc.delay,
+			blend:   fc.blend,
+			dispose: fc.dispose,
+		})
+	}
+
+	return decoder, nil
+}
+
+// Rebuilds a frame as a standalone PNG and decodes it, moving it to its region of the canvas
+func decodeAPNGFrame(ihdr []byte, shared []pngChunk, imageData [][]byte, region image.Rectangle) (image.Image, error) {
+	var buf bytes.Buffer
+	buf.WriteString(pngSignature)
+
+	frameHeader := append([]byte(nil), ihdr...)
+	binary.BigEndian.PutUint32(frameHeader[0:], uint32(region.Dx()))
+	binary.BigEndian.PutUint32(frameHeader[4:], uint32(region.Dy()))
+	writePNGChunk(&buf, "IHDR", frameHeader)
+
+	for _, chunk := range shared {
+		writePNGChunk(&buf, chunk.kind, chunk.data)
+	}
+	for _, data := range imageData {
+		writePNGChunk(&buf, "IDAT", data)
+	}
+	writePNGChunk(&buf, "IEND", nil)
+
+	frameImage, err := png.Decode(&buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return offsetImage{frameImage, region.Min}, nil
+}
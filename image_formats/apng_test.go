@@ -0,0 +1,171 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image_formats
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"image/color"
+	"testing"
+	"time"
+)
+
+// Encodes an 8-bit RGBA APNG of the passed frames, the first of which is the default image. Delays
+// are stored in hundredths of a second
+func testAPNG(t *testing.T, width, height int, loopCount uint32, frames ...testFrame) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	buf.WriteString(pngSignature)
+
+	ihdr := binary.BigEndian.AppendUint32(nil, uint32(width))
+	ihdr = binary.BigEndian.AppendUint32(ihdr, uint32(height))
+	writePNGChunk(&buf, "IHDR", append(ihdr, 8, 6, 0, 0, 0))
+
+	actl := binary.BigEndian.AppendUint32(nil, uint32(len(frames)))
+	writePNGChunk(&buf, "acTL", binary.BigEndian.AppendUint32(actl, loopCount))
+
+	var sequence uint32
+	for i, frame := range frames {
+		fctl := binary.BigEndian.AppendUint32(nil, sequence)
+		for _, value := range []int{frame.width, frame.height, frame.x, frame.y} {
+			fctl = binary.BigEndian.AppendUint32(fctl, uint32(value))
+		}
+		fctl = binary.BigEndian.AppendUint16(fctl, uint16(frame.delay/(10*time.Millisecond)))
+		fctl = binary.BigEndian.AppendUint16(fctl, 100)
+
+		blend := byte(0)
+		if frame.blend {
+			blend = 1
+		}
+		writePNGChunk(&buf, "fcTL", append(fctl, byte(frame.dispose), blend))
+		sequence++
+
+		// Rows of a single color, each starting with filter type 0
+		var compressed bytes.Buffer
+		writer := zlib.NewWriter(&compressed)
+		for range frame.height {
+			writer.Write([]byte{0})
+			for range frame.width {
+				writer.Write([]byte{frame.fill.R, frame.fill.G, frame.fill.B, frame.fill.A})
+			}
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		if i == 0 {
+			writePNGChunk(&buf, "IDAT", compressed.Bytes())
+		} else {
+			writePNGChunk(&buf, "fdAT", append(binary.BigEndian.AppendUint32(nil, sequence), compressed.Bytes()...))
+			sequence++
+		}
+	}
+
+	writePNGChunk(&buf, "IEND", nil)
+	return buf.Bytes()
+}
+
+func TestAPNG(t *testing.T) {
+	blended := over(halfBlue, red)
+
+	tests := []struct {
+		name   string
+		frames []testFrame
+		want   [][][]color.Color
+	}{
+		{
+			"disposal and blending",
+			[]testFrame{
+				{0, 0, 4, 2, red, 100 * time.Millisecond, disposeNone, false},
+				// Cleared to transparent once displayed
+				{2, 0, 2, 2, green, 200 * time.Millisecond, disposeBackground, false},
+				{0, 0, 2, 2, halfBlue, 300 * time.Millisecond, disposeNone, true},
+				// Replaces the transparent area without blending, and is undone once displayed
+				{2, 0, 2, 2, halfBlue, 400 * time.Millisecond, disposePrevious, false},
+				{0, 0, 2, 1, green, 500 * time.Millisecond, disposeNone, true},
+			},
+			[][][]color.Color{
+				{{red, red, red, red}, {red, red, red, red}},
+				{{red, red, green, green}, {red, red, green, green}},
+				{{blended, blended, transparent, transparent}, {blended, blended, transparent, transparent}},
+				{{blended, blended, halfBlue, halfBlue}, {blended, blended, halfBlue, halfBlue}},
+				{{green, green, transparent, transparent}, {blended, blended, transparent, transparent}},
+			},
+		},
+		{
+			"first frame disposed to the previous canvas",
+			[]testFrame{
+				{0, 0, 4, 2, red, 100 * time.Millisecond, disposePrevious, false},
+				{2, 0, 2, 2, green, 100 * time.Millisecond, disposeNone, true},
+			},
+			[][][]color.Color{
+				{{red, red, red, red}, {red, red, red, red}},
+				{{transparent, transparent, green, green}, {transparent, transparent, green, green}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var delays []time.Duration
+			for _, frame := range test.frames {
+				delays = append(delays, frame.delay)
+			}
+
+			checkAnimation(t, testAPNG(t, 4, 2, 3, test.frames...), 3, test.want, delays)
+		})
+	}
+}
+
+func TestAPNGErrors(t *testing.T) {
+	valid := testAPNG(t, 4, 2, 0,
+		testFrame{0, 0, 4, 2, red, 0, disposeNone, false},
+		testFrame{2, 0, 2, 2, green, 0, disposeNone, false},
+	)
+
+	outside := testAPNG(t, 4, 2, 0,
+		testFrame{0, 0, 4, 2, red, 0, disposeNone, false},
+		testFrame{3, 0, 2, 2, green, 0, disposeNone, false},
+	)
+
+	// The last frame's fdAT chunk, holding only part of a sequence number
+	var shortFdAT bytes.Buffer
+	shortFdAT.Write(valid[:len(valid)-12])
+	writePNGChunk(&shortFdAT, "fcTL", make([]byte, 26))
+	writePNGChunk(&shortFdAT, "fdAT", []byte{0, 0})
+	writePNGChunk(&shortFdAT, "IEND", nil)
+
+	tests := []struct {
+		name      string
+		data      []byte
+		truncated bool
+	}{
+		{"chunk cut off", valid[:len(valid)-20], true},
+		{"chunk header cut off", valid[:len(pngSignature)+6], true},
+		{"frame outside of the canvas", outside, false},
+		{"fdAT chunk without a sequence number", shortFdAT.Bytes(), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewAnimationDecoder(test.data)
+			checkDecodeError(t, err, test.truncated)
+		})
+	}
+}
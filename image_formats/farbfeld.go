@@ -0,0 +1,80 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image_formats
+
+import (
+	"encoding/binary"
+	"image"
+	"image/color"
+	"io"
+)
+
+// Farbfeld images, see https://tools.suckless.org/farbfeld/
+
+func init() {
+	register(Format{
+		Name:        "farbfeld",
+		Description: "Farbfeld",
+		Extensions:  []string{".ff"},
+		Magic:       []string{"farbfeld"},
+	}, decodeFarbfeld, decodeFarbfeldConfig)
+}
+
+func readFarbfeldHeader(r io.Reader) (width, height int, err error) {
+	var header [16]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, 0, unexpectedEOF("farbfeld", err)
+	}
+
+	if string(header[:8]) != "farbfeld" {
+		return 0, 0, FormatError("farbfeld: invalid magic bytes")
+	}
+
+	w := binary.BigEndian.Uint32(header[8:])
+	h := binary.BigEndian.Uint32(header[12:])
+
+	if err := checkDimensions("farbfeld", uint64(w), uint64(h), 8); err != nil {
+		return 0, 0, err
+	}
+
+	return int(w), int(h), nil
+}
+
+func decodeFarbfeldConfig(r io.Reader) (image.Config, error) {
+	width, height, err := readFarbfeldHeader(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+
+	return image.Config{ColorModel: color.NRGBA64Model, Width: width, Height: height}, nil
+}
+
+func decodeFarbfeld(r io.Reader) (image.Image, error) {
+	width, height, err := readFarbfeldHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pixels are stored as big-endian, non-premultiplied 16-bit RGBA, exactly like image.NRGBA64
+	img := image.NewNRGBA64(image.Rect(0, 0, width, height))
+
+	if _, err := io.ReadFull(r, img.Pix); err != nil {
+		return nil, unexpectedEOF("farbfeld", err)
+	}
+
+	return img, nil
+}
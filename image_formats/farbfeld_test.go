@@ -0,0 +1,64 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image_formats
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/color"
+	"testing"
+)
+
+// Builds a Farbfeld image out of a header and 16-bit samples
+func testFarbfeld(width, height uint32, samples ...uint16) []byte {
+	data := []byte("farbfeld")
+	data = binary.BigEndian.AppendUint32(data, width)
+	data = binary.BigEndian.AppendUint32(data, height)
+	for _, sample := range samples {
+		data = binary.BigEndian.AppendUint16(data, sample)
+	}
+	return data
+}
+
+func TestFarbfeld(t *testing.T) {
+	img := decodeRegistered(t, testFarbfeld(2, 1, 0xffff, 0x8000, 0, 0xffff, 0x1234, 0x5678, 0x9abc, 0x8000), "farbfeld")
+
+	checkPixels(t, img, [][]color.Color{{
+		color.NRGBA64{0xffff, 0x8000, 0, 0xffff},
+		color.NRGBA64{0x1234, 0x5678, 0x9abc, 0x8000},
+	}})
+}
+
+func TestFarbfeldErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     []byte
+		truncated bool
+	}{
+		{"truncated header", []byte("farbfeld\x00\x00"), true},
+		{"truncated pixels", testFarbfeld(2, 1, 0xffff, 0, 0, 0xffff, 0xffff), true},
+		{"no pixels", testFarbfeld(1, 0), false},
+		{"oversized header", testFarbfeld(0xffffffff, 0xffffffff), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := decodeFarbfeld(bytes.NewReader(test.input))
+			checkDecodeError(t, err, test.truncated)
+		})
+	}
+}
@@ -0,0 +1,185 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image_formats
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/gif"
+	"io"
+	"time"
+)
+
+/*
+GIF animations, see https://www.w3.org/Graphics/GIF/spec-gif89a.txt. The blocks of the file are
+walked to find each frame's image data and graphic control extension. Frames are decoded by
+rebuilding them as standalone GIFs, which share the animation's header and global color table
+*/
+
+const (
+	gifExtension       = 0x21
+	gifImageDescriptor = 0x2c
+	gifTrailer         = 0x3b
+
+	gifGraphicControl = 0xf9
+	gifApplication    = 0xff
+
+	// The header and logical screen descriptor
+	gifHeaderSize = 13
+)
+
+// Returns the size of the color table following a descriptor with the passed packed fields
+func gifColorTableSize(fields byte) int {
+	if fields&0x80 == 0 {
+		return 0
+	}
+	return 3 << (fields&0x07 + 1)
+}
+
+// Returns the offset after the data sub-blocks starting at offset, which end with an empty block
+func skipGIFSubBlocks(data []byte, offset int) (int, error) {
+	for {
+		if offset >= len(data) {
+			return 0, unexpectedEOF("gif", io.EOF)
+		}
+
+		size := int(data[offset])
+		offset++
+		if size == 0 {
+			return offset, nil
+		}
+		offset += size
+	}
+}
+
+func readGIFAnimation(data []byte) (*AnimationDecoder, error) {
+	if len(data) < gifHeaderSize {
+		return nil, unexpectedEOF("gif", io.EOF)
+	}
+
+	decoder := &AnimationDecoder{
+		Width:  int(binary.LittleEndian.Uint16(data[6:])),
+		Height: int(binary.LittleEndian.Uint16(data[8:])),
+
+		// Without a NETSCAPE2.0 extension, the animation is played once
+		LoopCount: 1,
+	}
+
+	offset := gifHeaderSize + gifColorTableSize(data[10])
+	if offset > len(data) {
+		return nil, unexpectedEOF("gif", io.EOF)
+	}
+	header := data[:offset]
+
+	// The last graphic control extension, which applies to the next frame
+	var control []byte
+
+	for offset < len(data) && data[offset] != gifTrailer {
+		start := offset
+
+		switch data[offset] {
+		case gifExtension:
+			if offset+2 > len(data) {
+				return nil, unexpectedEOF("gif", io.EOF)
+			}
+
+			end, err := skipGIFSubBlocks(data, offset+2)
+			if err != nil {
+				return nil, err
+			}
+
+			switch extension := data[start+1 : end]; extension[0] {
+			case gifGraphicControl:
+				if len(extension) < 6 || extension[1] != 4 {
+					return nil, FormatError("gif: invalid graphic control extension")
+				}
+				control = data[start:end]
+
+			case gifApplication:
+				// A GIF's loop count is the number of times it's restarted, where 0 loops forever
+				if len(extension) >= 17 && string(extension[2:13]) == "NETSCAPE2.0" && extension[13] == 3 && extension[14] == 1 {
+					if restarts := int(binary.LittleEndian.Uint16(extension[15:])); restarts > 0 {
+						decoder.LoopCount = restarts + 1
+					} else {
+						decoder.LoopCount = 0
+					}
+				}
+			}
+
+			offset = end
+
+		case gifImageDescriptor:
+			if offset+10 > len(data) {
+				return nil, unexpectedEOF("gif", io.EOF)
+			}
+
+			// The local color table and the LZW minimum code size precede the image data
+			end, err := skipGIFSubBlocks(data, offset+10+gifColorTableSize(data[offset+9])+1)
+			if err != nil {
+				return nil, err
+			}
+
+			frame := gifFrame(header, control, data[start:end])
+
+			// Transparent palette entries let the canvas show through
+			frame.blend = true
+
+			if control != nil {
+				switch (control[3] >> 2) & 0x07 {
+				case gif.DisposalBackground:
+					frame.dispose = disposeBackground
+				case gif.DisposalPrevious:
+					frame.dispose = disposePrevious
+				}
+				frame.delay = time.Duration(binary.LittleEndian.Uint16(control[4:])) * 10 * time.Millisecond
+			}
+
+			decoder.frames = append(decoder.frames, frame)
+			control, offset = nil, end
+
+		default:
+			return nil, FormatError("gif: unknown block type")
+		}
+	}
+
+	if len(decoder.frames) == 0 {
+		return nil, FormatError("gif: no frames")
+	}
+
+	if err := checkDimensions("gif", uint64(decoder.Width), uint64(decoder.Height), 4); err != nil {
+		return nil, err
+	}
+
+	return decoder, nil
+}
+
+// Returns a frame decoded as a standalone GIF made of the animation's header, the frame's graphic
+// control extension and its image
+func gifFrame(header, control, imageData []byte) animationFrame {
+	return animationFrame{
+		decode: func() (image.Image, error) {
+			standalone := make([]byte, 0, len(header)+len(control)+len(imageData)+1)
+			standalone = append(standalone, header...)
+			standalone = append(standalone, control...)
+			standalone = append(standalone, imageData...)
+			standalone = append(standalone, gifTrailer)
+
+			return gif.Decode(bytes.NewReader(standalone))
+		},
+	}
+}
@@ -0,0 +1,315 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image_formats
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+/*
+Windows icons and cursors. A file holds several images of different sizes, of which the largest is
+decoded. Each image is either a PNG, or a headerless BMP (DIB) followed by a 1-bit transparency mask
+*/
+
+const (
+	icoHeaderSize = 6
+	icoEntrySize  = 16
+	dibHeaderSize = 40
+)
+
+func init() {
+	register(Format{
+		Name:        "ico",
+		Description: "ICO/CUR",
+		Extensions:  []string{".ico", ".cur"},
+		Magic:       []string{"\x00\x00\x01\x00", "\x00\x00\x02\x00"},
+	}, decodeICO, decodeICOConfig)
+}
+
+type icoEntry struct {
+	width, height int
+	bitCount      int
+	size, offset  uint32
+}
+
+// Reads the whole file, since entries point at arbitrary offsets, and returns the largest entry's data
+func readLargestICOEntry(r io.Reader) (icoEntry, []byte, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return icoEntry{}, nil, err
+	}
+
+	if len(data) < icoHeaderSize {
+		return icoEntry{}, nil, unexpectedEOF("ico", io.EOF)
+	}
+
+	if binary.LittleEndian.Uint16(data[0:]) != 0 {
+		return icoEntry{}, nil, FormatError("ico: invalid header")
+	}
+	if fileType := binary.LittleEndian.Uint16(data[2:]); fileType != 1 && fileType != 2 {
+		return icoEntry{}, nil, FormatError("ico: invalid header")
+	}
+	isCursor := binary.LittleEndian.Uint16(data[2:]) == 2
+
+	count := int(binary.LittleEndian.Uint16(data[4:]))
+	if count == 0 {
+		return icoEntry{}, nil, FormatError("ico: file has no images")
+	}
+	if len(data) < icoHeaderSize+count*icoEntrySize {
+		return icoEntry{}, nil, unexpectedEOF("ico", io.EOF)
+	}
+
+	var largest icoEntry
+
+	for i := 0; i < count; i++ {
+		raw := data[icoHeaderSize+i*icoEntrySize:]
+
+		entry := icoEntry{
+			width:  int(raw[0]),
+			height: int(raw[1]),
+			size:   binary.LittleEndian.Uint32(raw[8:]),
+			offset: binary.LittleEndian.Uint32(raw[12:]),
+		}
+
+		// A size of 0 stands for 256 pixels
+		if entry.width == 0 {
+			entry.width = 256
+		}
+		if entry.height == 0 {
+			entry.height = 256
+		}
+
+		// Cursors store their hotspot in place of the bit count
+		if !isCursor {
+			entry.bitCount = int(binary.LittleEndian.Uint16(raw[6:]))
+		}
+
+		area, largestArea := entry.width*entry.height, largest.width*largest.height
+		if area > largestArea || (area == largestArea && entry.bitCount > largest.bitCount) {
+			largest = entry
+		}
+	}
+
+	if uint64(largest.offset)+uint64(largest.size) > uint64(len(data)) {
+		return icoEntry{}, nil, unexpectedEOF("ico", io.EOF)
+	}
+
+	return largest, data[largest.offset : largest.offset+largest.size], nil
+}
+
+func isPNG(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n"))
+}
+
+func decodeICOConfig(r io.Reader) (image.Config, error) {
+	_, data, err := readLargestICOEntry(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+
+	// The directory's sizes can't be trusted, and can't describe images over 256 pixels
+	if isPNG(data) {
+		return png.DecodeConfig(bytes.NewReader(data))
+	}
+
+	header, err := readDIBHeader(data)
+	if err != nil {
+		return image.Config{}, err
+	}
+
+	return image.Config{ColorModel: color.NRGBAModel, Width: header.width, Height: header.height}, nil
+}
+
+func decodeICO(r io.Reader) (image.Image, error) {
+	_, data, err := readLargestICOEntry(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if isPNG(data) {
+		return png.Decode(bytes.NewReader(data))
+	}
+
+	return decodeDIB(data)
+}
+
+type dibHeader struct {
+	width, height int
+	bitCount      int
+	paletteSize   int
+
+	// Where the palette, or the pixels if there's none, start
+	offset int
+}
+
+func readDIBHeader(data []byte) (dibHeader, error) {
+	if len(data) < dibHeaderSize {
+		return dibHeader{}, unexpectedEOF("ico", io.EOF)
+	}
+
+	headerSize := binary.LittleEndian.Uint32(data[0:])
+	if headerSize < dibHeaderSize || uint64(headerSize) > uint64(len(data)) {
+		return dibHeader{}, FormatError("ico: invalid bitmap header")
+	}
+
+	width := int32(binary.LittleEndian.Uint32(data[4:]))
+	// The height covers both the color data and the transparency mask
+	height := int32(binary.LittleEndian.Uint32(data[8:])) / 2
+
+	header := dibHeader{
+		width:    int(width),
+		height:   int(height),
+		bitCount: int(binary.LittleEndian.Uint16(data[14:])),
+		offset:   int(headerSize),
+	}
+
+	// Only uncompressed and bitfield (for 32-bit) bitmaps are used in icons
+	switch compression := binary.LittleEndian.Uint32(data[16:]); compression {
+	case 0:
+	case 3:
+		if header.bitCount != 32 {
+			return dibHeader{}, FormatError("ico: unsupported bitmap compression")
+		}
+
+		// The red, green and blue masks follow a BITMAPINFOHEADER, but are part of larger headers
+		if headerSize == dibHeaderSize {
+			header.offset += 12
+		}
+		if len(data) < dibHeaderSize+12 {
+			return dibHeader{}, unexpectedEOF("ico", io.EOF)
+		}
+
+		red := binary.LittleEndian.Uint32(data[40:])
+		green := binary.LittleEndian.Uint32(data[44:])
+		blue := binary.LittleEndian.Uint32(data[48:])
+		if red != 0x00ff0000 || green != 0x0000ff00 || blue != 0x000000ff {
+			return dibHeader{}, FormatError("ico: unsupported bitfields")
+		}
+	default:
+		return dibHeader{}, FormatError("ico: unsupported bitmap compression")
+	}
+
+	if width <= 0 || height <= 0 {
+		return dibHeader{}, FormatError("ico: invalid bitmap dimensions")
+	}
+
+	switch header.bitCount {
+	case 1, 4, 8:
+		header.paletteSize = int(binary.LittleEndian.Uint32(data[32:]))
+		if header.paletteSize == 0 || header.paletteSize > 1<<header.bitCount {
+			header.paletteSize = 1 << header.bitCount
+		}
+	case 24, 32:
+	default:
+		return dibHeader{}, FormatError("ico: unsupported bit count")
+	}
+
+	if err := checkDimensions("ico", uint64(header.width), uint64(header.height), 4); err != nil {
+		return dibHeader{}, err
+	}
+
+	return header, nil
+}
+
+// Decodes a bitmap without a file header, whose rows are stored bottom up and padded to 4 bytes
+func decodeDIB(data []byte) (image.Image, error) {
+	header, err := readDIBHeader(data)
+	if err != nil {
+		return nil, err
+	}
+
+	offset := header.offset
+
+	palette := make([][4]byte, header.paletteSize)
+	for i := range palette {
+		if offset+4 > len(data) {
+			return nil, unexpectedEOF("ico", io.EOF)
+		}
+		// Palette entries are stored as blue, green, red and a reserved byte
+		palette[i] = [4]byte{data[offset+2], data[offset+1], data[offset], 255}
+		offset += 4
+	}
+
+	stride := (header.width*header.bitCount + 31) / 32 * 4
+	maskStride := (header.width + 31) / 32 * 4
+
+	if offset+stride*header.height > len(data) {
+		return nil, unexpectedEOF("ico", io.EOF)
+	}
+
+	colors, data := data[offset:offset+stride*header.height], data[offset+stride*header.height:]
+
+	// Some icons omit the mask when their colors carry alpha
+	var mask []byte
+	if len(data) >= maskStride*header.height {
+		mask = data[:maskStride*header.height]
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, header.width, header.height))
+	hasAlpha := false
+
+	for y := 0; y < header.height; y++ {
+		row := colors[(header.height-1-y)*stride:]
+
+		for x := 0; x < header.width; x++ {
+			var px [4]byte
+
+			switch header.bitCount {
+			case 1, 4, 8:
+				bitOffset := x * header.bitCount
+				index := int(row[bitOffset/8]>>(8-header.bitCount-bitOffset%8)) & (1<<header.bitCount - 1)
+				if index < len(palette) {
+					px = palette[index]
+				}
+			case 24:
+				px = [4]byte{row[x*3+2], row[x*3+1], row[x*3], 255}
+			case 32:
+				px = [4]byte{row[x*4+2], row[x*4+1], row[x*4], row[x*4+3]}
+				hasAlpha = hasAlpha || px[3] != 0
+			}
+
+			copy(img.Pix[y*img.Stride+x*4:], px[:])
+		}
+	}
+
+	// 32-bit icons with all alpha values set to 0 are older icons relying on the mask
+	useMask := mask != nil && (header.bitCount != 32 || !hasAlpha)
+
+	for y := 0; y < header.height; y++ {
+		for x := 0; x < header.width; x++ {
+			alpha := &img.Pix[y*img.Stride+x*4+3]
+
+			if useMask {
+				transparent := mask[(header.height-1-y)*maskStride+x/8]&(0x80>>(x%8)) != 0
+				if transparent {
+					*alpha = 0
+				} else {
+					*alpha = 255
+				}
+			} else if header.bitCount == 32 && !hasAlpha {
+				*alpha = 255
+			}
+		}
+	}
+
+	return img, nil
+}
@@ -0,0 +1,200 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image_formats
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+type testICOEntry struct {
+	width, height int
+	bitCount      int
+	data          []byte
+}
+
+// Builds an ICO file out of its entries' images
+func testICO(entries ...testICOEntry) []byte {
+	data := binary.LittleEndian.AppendUint16(nil, 0)
+	data = binary.LittleEndian.AppendUint16(data, 1)
+	data = binary.LittleEndian.AppendUint16(data, uint16(len(entries)))
+
+	offset := icoHeaderSize + len(entries)*icoEntrySize
+	for _, entry := range entries {
+		data = append(data, byte(entry.width), byte(entry.height), 0, 0)
+		data = binary.LittleEndian.AppendUint16(data, 1)
+		data = binary.LittleEndian.AppendUint16(data, uint16(entry.bitCount))
+		data = binary.LittleEndian.AppendUint32(data, uint32(len(entry.data)))
+		data = binary.LittleEndian.AppendUint32(data, uint32(offset))
+		offset += len(entry.data)
+	}
+
+	for _, entry := range entries {
+		data = append(data, entry.data...)
+	}
+
+	return data
+}
+
+/*
+Builds a headerless bitmap, as stored in icons. extra is written between the header and the pixels,
+for the palette or bitfields. rows holds each row's pixel bytes top down, and mask the transparent
+pixels top down, or is nil to leave out the mask
+*/
+func testDIB(width, bitCount int, compression, colorsUsed uint32, extra []byte, rows [][]byte, mask [][]bool) []byte {
+	height := len(rows)
+
+	data := binary.LittleEndian.AppendUint32(nil, dibHeaderSize)
+	data = binary.LittleEndian.AppendUint32(data, uint32(width))
+	data = binary.LittleEndian.AppendUint32(data, uint32(height*2))
+	data = binary.LittleEndian.AppendUint16(data, 1)
+	data = binary.LittleEndian.AppendUint16(data, uint16(bitCount))
+	data = binary.LittleEndian.AppendUint32(data, compression)
+	data = append(data, make([]byte, 12)...)
+	data = binary.LittleEndian.AppendUint32(data, colorsUsed)
+	data = binary.LittleEndian.AppendUint32(data, 0)
+	data = append(data, extra...)
+
+	stride := (width*bitCount + 31) / 32 * 4
+	for y := height - 1; y >= 0; y-- {
+		data = append(data, rows[y]...)
+		data = append(data, make([]byte, stride-len(rows[y]))...)
+	}
+
+	if mask != nil {
+		maskStride := (width + 31) / 32 * 4
+		for y := height - 1; y >= 0; y-- {
+			row := make([]byte, maskStride)
+			for x, transparent := range mask[y] {
+				if transparent {
+					row[x/8] |= 0x80 >> (x % 8)
+				}
+			}
+			data = append(data, row...)
+		}
+	}
+
+	return data
+}
+
+func TestICO(t *testing.T) {
+	var (
+		red         = color.NRGBA{255, 0, 0, 255}
+		green       = color.NRGBA{0, 255, 0, 255}
+		blue        = color.NRGBA{0, 0, 255, 255}
+		white       = color.NRGBA{255, 255, 255, 255}
+		transparent = color.NRGBA{}
+	)
+
+	pngImage := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	pngImage.SetNRGBA(0, 0, red)
+	pngImage.SetNRGBA(1, 0, color.NRGBA{0, 255, 0, 128})
+	pngImage.SetNRGBA(0, 1, blue)
+	var pngData bytes.Buffer
+	if err := png.Encode(&pngData, pngImage); err != nil {
+		t.Fatal(err)
+	}
+
+	// Blue, green and red bytes, top down
+	bgr := [][]byte{{0, 0, 255, 0, 255, 0}, {255, 0, 0, 255, 255, 255}}
+	bgra := func(alpha byte) [][]byte {
+		return [][]byte{{0, 0, 255, alpha, 0, 255, 0, alpha}, {255, 0, 0, alpha, 255, 255, 255, alpha}}
+	}
+	mask := [][]bool{{false, true}, {false, false}}
+	bitfields := binary.LittleEndian.AppendUint32(binary.LittleEndian.AppendUint32(binary.LittleEndian.AppendUint32(nil, 0x00ff0000), 0x0000ff00), 0x000000ff)
+
+	// Two palette entries, of which only the first two pixels' bits are set
+	palette := []byte{255, 0, 0, 0, 0, 0, 255, 0}
+
+	masked := [][]color.Color{{red, transparent}, {blue, white}}
+	opaque := [][]color.Color{{red, green}, {blue, white}}
+
+	tests := []struct {
+		name    string
+		entries []testICOEntry
+		want    [][]color.Color
+	}{
+		{"png", []testICOEntry{{2, 2, 32, pngData.Bytes()}}, [][]color.Color{{red, color.NRGBA{0, 255, 0, 128}}, {blue, transparent}}},
+		{"24-bit bitmap with a mask", []testICOEntry{{2, 2, 24, testDIB(2, 24, 0, 0, nil, bgr, mask)}}, masked},
+		{"24-bit bitmap without a mask", []testICOEntry{{2, 2, 24, testDIB(2, 24, 0, 0, nil, bgr, nil)}}, opaque},
+		{"32-bit bitmap with alpha ignores the mask", []testICOEntry{{2, 2, 32, testDIB(2, 32, 0, 0, nil, bgra(255), mask)}}, opaque},
+		{"32-bit bitmap without alpha uses the mask", []testICOEntry{{2, 2, 32, testDIB(2, 32, 0, 0, nil, bgra(0), mask)}}, masked},
+		{"32-bit bitmap without alpha or a mask", []testICOEntry{{2, 2, 32, testDIB(2, 32, 0, 0, nil, bgra(0), nil)}}, opaque},
+		{"32-bit bitfields", []testICOEntry{{2, 2, 32, testDIB(2, 32, 3, 0, bitfields, bgra(255), mask)}}, opaque},
+		{
+			"8-bit bitmap with a palette", []testICOEntry{{2, 2, 8, testDIB(2, 8, 0, 2, palette, [][]byte{{1, 0}, {0, 1}}, mask)}},
+			[][]color.Color{{red, transparent}, {blue, red}},
+		},
+		{
+			"1-bit bitmap", []testICOEntry{{2, 2, 1, testDIB(2, 1, 0, 2, palette, [][]byte{{0x80}, {0x40}}, nil)}},
+			[][]color.Color{{red, blue}, {blue, red}},
+		},
+		{
+			"largest entry is decoded",
+			[]testICOEntry{{1, 1, 24, testDIB(1, 24, 0, 0, nil, [][]byte{{0, 0, 0}}, nil)}, {2, 2, 24, testDIB(2, 24, 0, 0, nil, bgr, nil)}, {1, 1, 32, pngData.Bytes()}},
+			opaque,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			checkPixels(t, decodeRegistered(t, testICO(test.entries...), "ico"), test.want)
+		})
+	}
+}
+
+func TestICOErrors(t *testing.T) {
+	bgr := [][]byte{{0, 0, 255}}
+	dib := testDIB(1, 24, 0, 0, nil, bgr, nil)
+
+	withHeader := func(offset int, value uint32) []byte {
+		data := bytes.Clone(dib)
+		binary.LittleEndian.PutUint32(data[offset:], value)
+		return data
+	}
+
+	tests := []struct {
+		name      string
+		input     []byte
+		truncated bool
+	}{
+		{"truncated header", []byte{0, 0, 1}, true},
+		{"truncated directory", testICO(testICOEntry{1, 1, 24, dib})[:icoHeaderSize+8], true},
+		{"entry past the end of the file", testICO(testICOEntry{1, 1, 24, dib})[:icoHeaderSize+icoEntrySize+dibHeaderSize], true},
+		{"no images", []byte{0, 0, 1, 0, 0, 0}, false},
+		{"invalid type", []byte{0, 0, 3, 0, 1, 0}, false},
+		{"truncated pixels", testICO(testICOEntry{1, 1, 24, dib[:dibHeaderSize+2]}), true},
+		{"truncated bitmap header", testICO(testICOEntry{1, 1, 24, dib[:20]}), true},
+		{"unsupported compression", testICO(testICOEntry{1, 1, 24, withHeader(16, 1)}), false},
+		{"bitfields below 32 bits", testICO(testICOEntry{1, 1, 24, withHeader(16, 3)}), false},
+		{"unusual bitfields", testICO(testICOEntry{1, 1, 32, testDIB(1, 32, 3, 0, make([]byte, 12), [][]byte{{0, 0, 0, 0}}, nil)}), false},
+		{"unsupported bit count", testICO(testICOEntry{1, 1, 16, withHeader(14, 16)}), false},
+		{"negative width", testICO(testICOEntry{1, 1, 24, withHeader(4, 0xffffffff)}), false},
+		{"oversized header", testICO(testICOEntry{1, 1, 24, withHeader(8, 0x7ffffffe)}), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := decodeICO(bytes.NewReader(test.input))
+			checkDecodeError(t, err, test.truncated)
+		})
+	}
+}
@@ -0,0 +1,307 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image_formats
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+)
+
+/*
+Netpbm images: PBM (P1, P4), PGM (P2, P5) and PPM (P3, P6), in both their plain (ASCII) and raw
+(binary) variants. Samples with a maximum value above 255 are decoded into 16-bit images
+*/
+
+func init() {
+	register(Format{
+		Name:        "pnm",
+		Description: "Netpbm (PBM/PGM/PPM)",
+		Extensions:  []string{".pbm", ".pgm", ".ppm", ".pnm"},
+		Magic:       []string{"P1", "P2", "P3", "P4", "P5", "P6"},
+	}, decodePNM, decodePNMConfig)
+}
+
+type pnmHeader struct {
+	magic         byte
+	width, height int
+	maxValue      int
+}
+
+// Number of samples per pixel
+func (h pnmHeader) channels() int {
+	if h.magic == '3' || h.magic == '6' {
+		return 3
+	}
+	return 1
+}
+
+func (h pnmHeader) bitmap() bool {
+	return h.magic == '1' || h.magic == '4'
+}
+
+func (h pnmHeader) plain() bool {
+	return h.magic <= '3'
+}
+
+func (h pnmHeader) colorModel() color.Model {
+	switch {
+	case h.channels() == 3 && h.maxValue > 255:
+		return color.RGBA64Model
+	case h.channels() == 3:
+		return color.RGBAModel
+	case h.maxValue > 255:
+		return color.Gray16Model
+	default:
+		return color.GrayModel
+	}
+}
+
+func decodePNMConfig(r io.Reader) (image.Config, error) {
+	header, err := readPNMHeader(bufio.NewReader(r))
+	if err != nil {
+		return image.Config{}, err
+	}
+
+	return image.Config{ColorModel: header.colorModel(), Width: header.width, Height: header.height}, nil
+}
+
+func decodePNM(r io.Reader) (image.Image, error) {
+	br := bufio.NewReader(r)
+
+	header, err := readPNMHeader(br)
+	if err != nil {
+		return nil, err
+	}
+
+	if header.bitmap() {
+		return decodePBM(br, header)
+	}
+
+	bounds := image.Rect(0, 0, header.width, header.height)
+
+	var img interface {
+		image.Image
+		Set(x, y int, c color.Color)
+	}
+
+	switch header.colorModel() {
+	case color.RGBA64Model:
+		img = image.NewRGBA64(bounds)
+	case color.RGBAModel:
+		img = image.NewRGBA(bounds)
+	case color.Gray16Model:
+		img = image.NewGray16(bounds)
+	default:
+		img = image.NewGray(bounds)
+	}
+
+	samples := make([]uint32, header.channels())
+
+	for y := 0; y < header.height; y++ {
+		for x := 0; x < header.width; x++ {
+			for i := range samples {
+				if samples[i], err = readPNMSample(br, header); err != nil {
+					return nil, err
+				}
+			}
+
+			if len(samples) == 1 {
+				img.Set(x, y, color.Gray16{uint16(samples[0])})
+			} else {
+				img.Set(x, y, color.RGBA64{uint16(samples[0]), uint16(samples[1]), uint16(samples[2]), 0xffff})
+			}
+		}
+	}
+
+	return img, nil
+}
+
+// Bitmaps store 1 for black and 0 for white. Raw bitmaps pack 8 pixels into each byte, with every
+// row starting on a new byte
+func decodePBM(br *bufio.Reader, header pnmHeader) (image.Image, error) {
+	img := image.NewGray(image.Rect(0, 0, header.width, header.height))
+
+	row := make([]byte, (header.width+7)/8)
+
+	for y := 0; y < header.height; y++ {
+		if !header.plain() {
+			if _, err := io.ReadFull(br, row); err != nil {
+				return nil, unexpectedEOF("pnm", err)
+			}
+		}
+
+		for x := 0; x < header.width; x++ {
+			var black bool
+
+			if header.plain() {
+				// Plain bitmap pixels needn't be separated by whitespace
+				b, err := skipPNMSpace(br)
+				if err != nil {
+					return nil, unexpectedEOF("pnm", err)
+				}
+				if b != '0' && b != '1' {
+					return nil, FormatError("pnm: invalid bitmap pixel")
+				}
+				black = b == '1'
+			} else {
+				black = row[x/8]&(0x80>>(x%8)) != 0
+			}
+
+			if !black {
+				img.Pix[y*img.Stride+x] = 0xff
+			}
+		}
+	}
+
+	return img, nil
+}
+
+// Reads a sample and scales it to 16 bits
+func readPNMSample(br *bufio.Reader, header pnmHeader) (uint32, error) {
+	var value int
+
+	if header.plain() {
+		var err error
+		if value, err = readPNMInt(br); err != nil {
+			return 0, err
+		}
+	} else if header.maxValue > 255 {
+		var sample [2]byte
+		if _, err := io.ReadFull(br, sample[:]); err != nil {
+			return 0, unexpectedEOF("pnm", err)
+		}
+		value = int(sample[0])<<8 | int(sample[1])
+	} else {
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0, unexpectedEOF("pnm", err)
+		}
+		value = int(b)
+	}
+
+	if value > header.maxValue {
+		return 0, FormatError("pnm: sample exceeds the maximum value")
+	}
+
+	return uint32(value) * 0xffff / uint32(header.maxValue), nil
+}
+
+func readPNMHeader(br *bufio.Reader) (pnmHeader, error) {
+	var magic [2]byte
+	if _, err := io.ReadFull(br, magic[:]); err != nil {
+		return pnmHeader{}, unexpectedEOF("pnm", err)
+	}
+
+	if magic[0] != 'P' || magic[1] < '1' || magic[1] > '6' {
+		return pnmHeader{}, FormatError("pnm: invalid magic number")
+	}
+
+	header := pnmHeader{magic: magic[1], maxValue: 1}
+
+	var err error
+	if header.width, err = readPNMInt(br); err != nil {
+		return pnmHeader{}, err
+	}
+	if header.height, err = readPNMInt(br); err != nil {
+		return pnmHeader{}, err
+	}
+
+	if !header.bitmap() {
+		if header.maxValue, err = readPNMInt(br); err != nil {
+			return pnmHeader{}, err
+		}
+		if header.maxValue < 1 || header.maxValue > 0xffff {
+			return pnmHeader{}, FormatError("pnm: maximum value must be between 1 and 65535")
+		}
+	}
+
+	if err := checkDimensions("pnm", uint64(header.width), uint64(header.height), uint64(header.channels()*2)); err != nil {
+		return pnmHeader{}, err
+	}
+
+	// A single whitespace character separates the header from raw pixel data
+	if !header.plain() {
+		if _, err := br.ReadByte(); err != nil {
+			return pnmHeader{}, unexpectedEOF("pnm", err)
+		}
+	}
+
+	return header, nil
+}
+
+// Reads a decimal number, skipping preceding whitespace and comments. The whitespace character
+// ending the number is left unread
+func readPNMInt(br *bufio.Reader) (int, error) {
+	b, err := skipPNMSpace(br)
+	if err != nil {
+		return 0, unexpectedEOF("pnm", err)
+	}
+
+	if b < '0' || b > '9' {
+		return 0, FormatError(fmt.Sprintf("pnm: expected a number, got %q", b))
+	}
+
+	value := 0
+	for {
+		value = value*10 + int(b-'0')
+		if value > 1<<30 {
+			return 0, FormatError("pnm: number is too large")
+		}
+
+		b, err = br.ReadByte()
+		if err == io.EOF {
+			return value, nil
+		} else if err != nil {
+			return 0, err
+		}
+
+		if b < '0' || b > '9' {
+			return value, br.UnreadByte()
+		}
+	}
+}
+
+// Returns the first byte that's neither whitespace nor part of a comment
+func skipPNMSpace(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+
+		switch b {
+		case ' ', '\t', '\n', '\v', '\f', '\r':
+		case '#':
+			if _, err := br.ReadBytes('\n'); err != nil {
+				return 0, err
+			}
+		default:
+			return b, nil
+		}
+	}
+}
+
+// Image data ending early is an error, even if the reader reports it as io.EOF
+func unexpectedEOF(format string, err error) error {
+	if err == io.EOF {
+		return fmt.Errorf("%v: %w", format, io.ErrUnexpectedEOF)
+	}
+	return err
+}
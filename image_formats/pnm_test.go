@@ -0,0 +1,101 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image_formats
+
+import (
+	"bytes"
+	"image/color"
+	"testing"
+)
+
+func TestPNM(t *testing.T) {
+	var (
+		black = color.Gray{0}
+		white = color.Gray{255}
+		red   = color.RGBA{255, 0, 0, 255}
+		green = color.RGBA{0, 255, 0, 255}
+		blue  = color.RGBA{0, 0, 255, 255}
+		gray  = color.RGBA{128, 128, 128, 255}
+	)
+
+	bitmap := [][]color.Color{{black, white, white}, {white, black, black}}
+	graymap := [][]color.Color{{color.Gray{0}, color.Gray{128}}, {color.Gray{255}, color.Gray{64}}}
+	pixmap := [][]color.Color{{red, green}, {blue, gray}}
+
+	tests := []struct {
+		name  string
+		input string
+		want  [][]color.Color
+	}{
+		{"plain bitmap", "P1\n# a comment\n3 2\n1 0 0\n0 1 1\n", bitmap},
+		{"plain bitmap without separators", "P1 3 2 100011", bitmap},
+		{"raw bitmap", "P4\n3 2\n\x80\x60", bitmap},
+		{"raw bitmap padding bits are ignored", "P4\n3 2\n\x9f\x7f", bitmap},
+		{"plain graymap", "P2\n2 2\n255\n0 128\n255 64\n", graymap},
+		{"raw graymap", "P5\n2 2\n255\n\x00\x80\xff\x40", graymap},
+		{"plain graymap scaled to 8 bits", "P2 2 2 15 0 0 15 0", [][]color.Color{{black, black}, {white, black}}},
+		{"raw 16-bit graymap", "P5 2 1 65535\n\x12\x34\xff\xff", [][]color.Color{{color.Gray16{0x1234}, color.Gray16{0xffff}}}},
+		{"plain pixmap", "P3\n2 2 255\n255 0 0  0 255 0\n0 0 255  128 128 128\n", pixmap},
+		{"raw pixmap", "P6\n2 2\n255\n\xff\x00\x00\x00\xff\x00\x00\x00\xff\x80\x80\x80", pixmap},
+		{"raw 16-bit pixmap", "P6 1 1 65535\n\x12\x34\x56\x78\x9a\xbc", [][]color.Color{{color.RGBA64{0x1234, 0x5678, 0x9abc, 0xffff}}}},
+		{"comment in the header", "P5 2#width\n2 # height\n255\n\x00\x80\xff\x40", graymap},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			checkPixels(t, decodeRegistered(t, []byte(test.input), "pnm"), test.want)
+		})
+	}
+}
+
+func TestPNMErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		truncated bool
+
+		// Whether the error is found by reading the header, which DecodeConfig() does too
+		inHeader bool
+	}{
+		{"invalid magic number", "P7 1 1 255\n\x00", false, true},
+		{"truncated header", "P5 2 2", true, true},
+		{"no header", "P5", true, true},
+		{"truncated raw graymap", "P5 2 2 255\n\x00\x80", true, false},
+		{"truncated raw bitmap", "P4 9 2\n\x00\x00\x00", true, false},
+		{"truncated plain pixmap", "P3 2 1 255 1 2 3 4", true, false},
+		{"sample over the maximum value", "P2 1 1 10 11", false, false},
+		{"maximum value of 0", "P2 1 1 0 0", false, true},
+		{"maximum value over 16 bits", "P2 1 1 65536 0", false, true},
+		{"invalid bitmap pixel", "P1 2 1 0 2", false, false},
+		{"not a number", "P2 x 1 255 0", false, true},
+		{"no pixels", "P5 0 1 255\n", false, true},
+		{"oversized header", "P6 100000 100000 255\n", false, true},
+		{"number overflowing", "P5 99999999999 1 255\n", false, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := decodePNM(bytes.NewReader([]byte(test.input)))
+			checkDecodeError(t, err, test.truncated)
+
+			if test.inHeader {
+				_, err := decodePNMConfig(bytes.NewReader([]byte(test.input)))
+				checkDecodeError(t, err, test.truncated)
+			}
+		})
+	}
+}
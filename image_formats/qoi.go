@@ -0,0 +1,147 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image_formats
+
+import (
+	"bufio"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"io"
+)
+
+// The Quite OK Image format, see https://qoiformat.org/qoi-specification.pdf
+
+const qoiHeaderSize = 14
+
+const (
+	qoiOpIndex = 0x00
+	qoiOpDiff  = 0x40
+	qoiOpLuma  = 0x80
+	qoiOpRun   = 0xc0
+	qoiOpRGB   = 0xfe
+	qoiOpRGBA  = 0xff
+
+	qoiMask2 = 0xc0
+)
+
+func init() {
+	register(Format{
+		Name:        "qoi",
+		Description: "QOI",
+		Extensions:  []string{".qoi"},
+		Magic:       []string{"qoif"},
+	}, decodeQOI, decodeQOIConfig)
+}
+
+func readQOIHeader(r io.Reader) (width, height int, err error) {
+	var header [qoiHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, 0, unexpectedEOF("qoi", err)
+	}
+
+	if string(header[:4]) != "qoif" {
+		return 0, 0, FormatError("qoi: invalid magic bytes")
+	}
+
+	w := binary.BigEndian.Uint32(header[4:])
+	h := binary.BigEndian.Uint32(header[8:])
+
+	if channels := header[12]; channels != 3 && channels != 4 {
+		return 0, 0, FormatError("qoi: invalid number of channels")
+	}
+
+	if err := checkDimensions("qoi", uint64(w), uint64(h), 4); err != nil {
+		return 0, 0, err
+	}
+
+	return int(w), int(h), nil
+}
+
+func decodeQOIConfig(r io.Reader) (image.Config, error) {
+	width, height, err := readQOIHeader(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+
+	return image.Config{ColorModel: color.NRGBAModel, Width: width, Height: height}, nil
+}
+
+func decodeQOI(r io.Reader) (image.Image, error) {
+	br := bufio.NewReader(r)
+
+	width, height, err := readQOIHeader(br)
+	if err != nil {
+		return nil, err
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+
+	var (
+		index [64][4]byte
+		px    = [4]byte{0, 0, 0, 255}
+		run   = 0
+	)
+
+	readByte := func() byte {
+		if err != nil {
+			return 0
+		}
+		var b byte
+		b, err = br.ReadByte()
+		return b
+	}
+
+	for offset := 0; offset < len(img.Pix); offset += 4 {
+		if run > 0 {
+			run--
+		} else {
+			op := readByte()
+
+			switch {
+			case op == qoiOpRGB:
+				px[0], px[1], px[2] = readByte(), readByte(), readByte()
+			case op == qoiOpRGBA:
+				px[0], px[1], px[2], px[3] = readByte(), readByte(), readByte(), readByte()
+			case op&qoiMask2 == qoiOpIndex:
+				px = index[op]
+			case op&qoiMask2 == qoiOpDiff:
+				px[0] += (op>>4)&0x03 - 2
+				px[1] += (op>>2)&0x03 - 2
+				px[2] += op&0x03 - 2
+			case op&qoiMask2 == qoiOpLuma:
+				next := readByte()
+				greenDiff := op&0x3f - 32
+				px[0] += greenDiff - 8 + (next>>4)&0x0f
+				px[1] += greenDiff
+				px[2] += greenDiff - 8 + next&0x0f
+			case op&qoiMask2 == qoiOpRun:
+				run = int(op & 0x3f)
+			}
+
+			if err != nil {
+				return nil, unexpectedEOF("qoi", err)
+			}
+
+			index[(int(px[0])*3+int(px[1])*5+int(px[2])*7+int(px[3])*11)%64] = px
+		}
+
+		copy(img.Pix[offset:], px[:])
+	}
+
+	return img, nil
+}
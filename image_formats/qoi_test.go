@@ -0,0 +1,112 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image_formats
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/color"
+	"testing"
+)
+
+var qoiEndMarker = []byte{0, 0, 0, 0, 0, 0, 0, 1}
+
+// Builds a QOI image out of a header and the passed chunks
+func testQOI(width, height uint32, channels byte, chunks ...byte) []byte {
+	data := []byte("qoif")
+	data = binary.BigEndian.AppendUint32(data, width)
+	data = binary.BigEndian.AppendUint32(data, height)
+	data = append(data, channels, 0)
+	data = append(data, chunks...)
+	return append(data, qoiEndMarker...)
+}
+
+func TestQOI(t *testing.T) {
+	first := color.NRGBA{10, 20, 30, 255}
+
+	tests := []struct {
+		name   string
+		width  uint32
+		chunks []byte
+		want   []color.Color
+	}{
+		{
+			"every op",
+			8,
+			[]byte{
+				qoiOpRGB, 10, 20, 30,
+				// Red +1, green -1 and blue +0
+				qoiOpDiff | 3<<4 | 1<<2 | 2,
+				// Green +10, red +13 and blue +16
+				qoiOpLuma | 42, 11<<4 | 14,
+				qoiOpRGBA, 200, 100, 50, 128,
+				// Index of the first pixel, (10*3 + 20*5 + 30*7 + 255*11) % 64
+				qoiOpIndex | 9,
+				// Three more of the same pixel
+				qoiOpRun | 2,
+			},
+			[]color.Color{first, color.NRGBA{11, 19, 30, 255}, color.NRGBA{24, 29, 46, 255}, color.NRGBA{200, 100, 50, 128}, first, first, first, first},
+		},
+		{
+			"differences wrap around",
+			2,
+			[]byte{qoiOpDiff | 0<<4 | 2<<2 | 3, qoiOpLuma | 0, 0<<4 | 15},
+			[]color.Color{color.NRGBA{254, 0, 1, 255}, color.NRGBA{214, 224, 232, 255}},
+		},
+		{
+			"runs start from opaque black",
+			3,
+			[]byte{qoiOpRun | 2},
+			[]color.Color{color.NRGBA{0, 0, 0, 255}, color.NRGBA{0, 0, 0, 255}, color.NRGBA{0, 0, 0, 255}},
+		},
+		{
+			"index starts zeroed",
+			1,
+			[]byte{qoiOpIndex | 5},
+			[]color.Color{color.NRGBA{0, 0, 0, 0}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			img := decodeRegistered(t, testQOI(test.width, 1, 4, test.chunks...), "qoi")
+			checkPixels(t, img, [][]color.Color{test.want})
+		})
+	}
+}
+
+func TestQOIErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     []byte
+		truncated bool
+	}{
+		{"truncated header", []byte("qoif\x00\x00"), true},
+		{"truncated pixels", testQOI(4, 1, 4, qoiOpRGB, 1, 2, 3)[:qoiHeaderSize+4], true},
+		{"truncated chunk", testQOI(4, 1, 4, qoiOpRGBA, 1, 2)[:qoiHeaderSize+3], true},
+		{"invalid channels", testQOI(1, 1, 5, qoiOpRun), false},
+		{"no pixels", testQOI(0, 1, 4), false},
+		{"oversized header", testQOI(0xffffffff, 0xffffffff, 4), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := decodeQOI(bytes.NewReader(test.input))
+			checkDecodeError(t, err, test.truncated)
+		})
+	}
+}
@@ -0,0 +1,144 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package image_formats registers decoders for image formats missing from the standard library and
+golang.org/x/image with the image package, and keeps a registry of every supported input format.
+
+Importing it for its side effects is enough for image.Decode() to read Netpbm, ICO/CUR, QOI and
+Farbfeld images, on top of PNG, JPEG, GIF, WebP, BMP and TIFF:
+
+	import _ "github.com/Ares1605/ascii-image-converter-wasm/image_formats"
+*/
+package image_formats
+
+import (
+	"bytes"
+	"image"
+	"io"
+
+	// Formats decoded by the standard library and golang.org/x/image
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+)
+
+// Format describes a supported input format
+type Format struct {
+	// Name returned by image.Decode() and image.DecodeConfig(), e.g. "png"
+	Name string
+
+	// Human readable name, e.g. "PNG"
+	Description string
+
+	// File extensions, including the leading dot
+	Extensions []string
+
+	// Byte prefixes identifying the format, where '?' matches any byte
+	Magic []string
+
+	// Whether the format may hold an animation, decoded with DecodeAnimation()
+	Animated bool
+
+	// Whether the format may be a stream of frames, read with NewVideoReader()
+	Video bool
+}
+
+// Decoders for the first formats are registered by the packages imported above
+var registry = []Format{
+	{Name: "png", Description: "PNG", Extensions: []string{".png"}, Magic: []string{"\x89PNG\r\n\x1a\n"}, Animated: true},
+	{Name: "jpeg", Description: "JPEG", Extensions: []string{".jpg", ".jpeg"}, Magic: []string{"\xff\xd8"}, Video: true},
+	{Name: "webp", Description: "WebP", Extensions: []string{".webp"}, Magic: []string{"RIFF????WEBPVP8"}, Animated: true},
+	{Name: "bmp", Description: "BMP", Extensions: []string{".bmp"}, Magic: []string{"BM????\x00\x00\x00\x00"}},
+	{Name: "tiff", Description: "TIFF", Extensions: []string{".tif", ".tiff"}, Magic: []string{"II*\x00", "MM\x00*"}},
+	{Name: "gif", Description: "GIF", Extensions: []string{".gif"}, Magic: []string{"GIF87a", "GIF89a"}, Animated: true},
+}
+
+// Adds a format decoded by this package to the registry and registers it with the image package
+func register(format Format, decode func(io.Reader) (image.Image, error), decodeConfig func(io.Reader) (image.Config, error)) {
+	registry = append(registry, format)
+
+	for _, magic := range format.Magic {
+		image.RegisterFormat(format.Name, magic, decode, decodeConfig)
+	}
+}
+
+// Formats returns every supported input format, in the order they were registered
+func Formats() []Format {
+	return append([]Format(nil), registry...)
+}
+
+/*
+Sniff identifies the format of an image from its first bytes, which needn't be complete. Formats in
+the registry are matched by their magic bytes. Decoders registered with the image package by other
+packages are tried with image.DecodeConfig() as a fallback, which may need more than a prefix of the
+image to succeed. The second value is false if the format couldn't be identified
+*/
+func Sniff(header []byte) (Format, bool) {
+	for _, format := range registry {
+		for _, magic := range format.Magic {
+			if matchMagic(magic, header) {
+				return format, true
+			}
+		}
+	}
+
+	if _, name, err := image.DecodeConfig(bytes.NewReader(header)); err == nil {
+		return Format{Name: name, Description: name}, true
+	}
+
+	return Format{}, false
+}
+
+// Same matching as the image package, where '?' in magic matches any byte
+func matchMagic(magic string, header []byte) bool {
+	if len(header) < len(magic) {
+		return false
+	}
+
+	for i, b := range header[:len(magic)] {
+		if magic[i] != b && magic[i] != '?' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Returns an error for dimensions that are empty or whose pixel data couldn't be allocated
+func checkDimensions(format string, width, height, bytesPerPixel uint64) error {
+	if width == 0 || height == 0 {
+		return FormatError(format + ": image has no pixels")
+	}
+
+	const maxBytes = 1<<31 - 1
+	if width > maxBytes/height/bytesPerPixel {
+		return FormatError(format + ": image dimensions are too large")
+	}
+
+	return nil
+}
+
+// FormatError reports that the input isn't valid for the format it was identified as
+type FormatError string
+
+func (e FormatError) Error() string {
+	return string(e)
+}
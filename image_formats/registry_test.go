@@ -0,0 +1,113 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image_formats
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+	"testing"
+)
+
+// Compares an image's pixels with want, given row by row
+func checkPixels(t *testing.T, img image.Image, want [][]color.Color) {
+	t.Helper()
+
+	if bounds := img.Bounds(); bounds.Dx() != len(want[0]) || bounds.Dy() != len(want) {
+		t.Fatalf("image is %vx%v, want %vx%v", bounds.Dx(), bounds.Dy(), len(want[0]), len(want))
+	}
+
+	for y, row := range want {
+		for x, wantColor := range row {
+			r, g, b, a := img.At(img.Bounds().Min.X+x, img.Bounds().Min.Y+y).RGBA()
+			wr, wg, wb, wa := wantColor.RGBA()
+
+			if r != wr || g != wg || b != wb || a != wa {
+				t.Errorf("pixel (%v, %v) is %04x, want %04x", x, y, []uint32{r, g, b, a}, []uint32{wr, wg, wb, wa})
+			}
+		}
+	}
+}
+
+// Checks that an error is a FormatError, or wraps io.ErrUnexpectedEOF if truncated is set
+func checkDecodeError(t *testing.T, err error, truncated bool) {
+	t.Helper()
+
+	var formatErr FormatError
+
+	switch {
+	case err == nil:
+		t.Error("decoding succeeded, want an error")
+	case truncated && !errors.Is(err, io.ErrUnexpectedEOF):
+		t.Errorf("got %v, want an unexpected EOF", err)
+	case !truncated && !errors.As(err, &formatErr):
+		t.Errorf("got %v, want a FormatError", err)
+	}
+}
+
+// Decodes through the image package, checking the format name and that DecodeConfig() agrees
+func decodeRegistered(t *testing.T, data []byte, wantFormat string) image.Image {
+	t.Helper()
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("DecodeConfig: %v", err)
+	}
+	if format != wantFormat {
+		t.Errorf("format %q, want %q", format, wantFormat)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+
+	if bounds := img.Bounds(); bounds.Dx() != config.Width || bounds.Dy() != config.Height {
+		t.Errorf("DecodeConfig gave %vx%v, Decode %vx%v", config.Width, config.Height, bounds.Dx(), bounds.Dy())
+	}
+
+	return img
+}
+
+func TestSniff(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+		ok     bool
+	}{
+		{"\x89PNG\r\n\x1a\n", "png", true},
+		{"\xff\xd8\xff", "jpeg", true},
+		{"RIFF\x00\x00\x00\x00WEBPVP8X", "webp", true},
+		{"GIF89a", "gif", true},
+		{"P6\n", "pnm", true},
+		{"\x00\x00\x01\x00", "ico", true},
+		{"\x00\x00\x02\x00", "ico", true},
+		{"qoif", "qoi", true},
+		{"farbfeld", "farbfeld", true},
+		{"P7", "", false},
+		{"", "", false},
+	}
+
+	for _, test := range tests {
+		format, ok := Sniff([]byte(test.header))
+		if ok != test.ok || format.Name != test.want {
+			t.Errorf("Sniff(%q) = %q, %v, want %q, %v", test.header, format.Name, ok, test.want, test.ok)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image_formats
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+	"io"
+)
+
+// ErrFrameTooLarge is returned for JPEG frames larger than the limit passed to ReadJPEGFrame()
+var ErrFrameTooLarge = errors.New("frame exceeds the size limit")
+
+// VideoReader reads the frames of a video stream one at a time, as they arrive
+type VideoReader interface {
+	// Next returns the next frame, or io.EOF once the stream has ended
+	Next() (image.Image, error)
+
+	// FrameRate returns the frame rate in frames per second, or 0 if the stream doesn't specify it
+	FrameRate() float64
+}
+
+/*
+NewVideoReader identifies a YUV4MPEG2 or MJPEG (concatenated JPEG) stream from its first bytes and
+returns a reader for its frames. Nothing beyond the Y4M header is read before the first call to Next().
+
+If checkSize isn't nil, it's called with the size of frames before they're decoded, and an error it
+returns is returned instead of the frame. Y4M frames share the size from the stream's header. If
+maxFrameBytes is greater than 0, MJPEG frames larger than it are rejected with ErrFrameTooLarge
+while they're read, while Y4M frames are only as large as the header's frame size
+*/
+func NewVideoReader(r io.Reader, maxFrameBytes int64, checkSize func(width, height int) error) (VideoReader, error) {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+
+	header, err := br.Peek(len(y4mMagic))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	format, _ := Sniff(header)
+
+	switch format.Name {
+	case "y4m":
+		reader, err := NewY4MReader(br)
+		if err != nil {
+			return nil, err
+		}
+
+		if checkSize != nil {
+			if err := checkSize(reader.width, reader.height); err != nil {
+				return nil, err
+			}
+		}
+		return reader, nil
+
+	case "jpeg":
+		reader := NewMJPEGReader(br)
+		reader.MaxFrameBytes = maxFrameBytes
+		reader.CheckSize = checkSize
+		return reader, nil
+	}
+
+	return nil, FormatError("input isn't a Y4M or MJPEG stream")
+}
+
+// MJPEGReader reads a stream of concatenated JPEG images, such as ffmpeg's -f mjpeg output
+type MJPEGReader struct {
+	r *bufio.Reader
+
+	// If greater than 0, frames larger than this many bytes are rejected with ErrFrameTooLarge,
+	// without reading the rest of them
+	MaxFrameBytes int64
+
+	// If set, called with each frame's size before it's decoded. An error it returns is
+	// returned by Next() instead of the frame
+	CheckSize func(width, height int) error
+}
+
+func NewMJPEGReader(r io.Reader) *MJPEGReader {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	return &MJPEGReader{r: br}
+}
+
+// MJPEG streams have no header to take a frame rate from
+func (m *MJPEGReader) FrameRate() float64 {
+	return 0
+}
+
+func (m *MJPEGReader) Next() (image.Image, error) {
+	frame, err := ReadJPEGFrame(m.r, m.MaxFrameBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.CheckSize != nil {
+		config, err := jpeg.DecodeConfig(bytes.NewReader(frame))
+		if err != nil {
+			return nil, err
+		}
+		if err := m.CheckSize(config.Width, config.Height); err != nil {
+			return nil, err
+		}
+	}
+
+	return jpeg.Decode(bytes.NewReader(frame))
+}
+
+/*
+ReadJPEGFrame reads a single JPEG image from br, from its start of image marker up to and including its
+end of image marker, leaving any following data unread. It returns io.EOF if br holds no more data, and
+ErrFrameTooLarge as soon as the image exceeds maxBytes, if greater than 0.
+
+JPEG decoders read ahead of the image's end, so a stream of JPEGs can't be decoded by calling them
+repeatedly. Instead, the stream is split by walking the image's markers
+*/
+func ReadJPEGFrame(br *bufio.Reader, maxBytes int64) ([]byte, error) {
+	const (
+		markerSOI = 0xd8
+		markerEOI = 0xd9
+		markerSOS = 0xda
+		markerTEM = 0x01
+	)
+
+	if _, err := br.Peek(1); err == io.EOF {
+		return nil, io.EOF
+	}
+
+	var frame bytes.Buffer
+
+	soi := make([]byte, 2)
+	if _, err := io.ReadFull(br, soi); err != nil {
+		return nil, unexpectedEOF("jpeg", err)
+	}
+	if soi[0] != 0xff || soi[1] != markerSOI {
+		return nil, FormatError("jpeg: missing start of image marker")
+	}
+	frame.Write(soi)
+
+	// Marker ending a scan, which has already been read
+	var pending byte
+
+	for {
+		var err error
+
+		if maxBytes > 0 && int64(frame.Len()) > maxBytes {
+			return nil, ErrFrameTooLarge
+		}
+
+		marker := pending
+		pending = 0
+
+		if marker == 0 {
+			var b byte
+			if b, err = br.ReadByte(); err != nil {
+				return nil, unexpectedEOF("jpeg", err)
+			}
+			if b != 0xff {
+				return nil, FormatError("jpeg: expected a marker")
+			}
+
+			if marker, err = readMarker(br); err != nil {
+				return nil, err
+			}
+		}
+		frame.Write([]byte{0xff, marker})
+
+		switch {
+		case marker == markerEOI:
+			if maxBytes > 0 && int64(frame.Len()) > maxBytes {
+				return nil, ErrFrameTooLarge
+			}
+			return frame.Bytes(), nil
+		case marker == markerTEM || (marker >= 0xd0 && marker <= 0xd7):
+			// Markers without a segment
+			continue
+		}
+
+		// Segments start with their length, which includes the length's own 2 bytes
+		length := make([]byte, 2)
+		if _, err = io.ReadFull(br, length); err != nil {
+			return nil, unexpectedEOF("jpeg", err)
+		}
+		frame.Write(length)
+
+		segmentLength := int(length[0])<<8 | int(length[1])
+		if segmentLength < 2 {
+			return nil, FormatError("jpeg: invalid segment length")
+		}
+		if maxBytes > 0 && int64(frame.Len()+segmentLength-2) > maxBytes {
+			return nil, ErrFrameTooLarge
+		}
+		if _, err = io.CopyN(&frame, br, int64(segmentLength-2)); err != nil {
+			return nil, unexpectedEOF("jpeg", err)
+		}
+
+		if marker == markerSOS {
+			if pending, err = readEntropyCodedData(br, &frame, maxBytes); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// Reads the byte identifying a marker, following its 0xff. Markers may be preceded by any number
+// of 0xff fill bytes
+func readMarker(br *bufio.Reader) (byte, error) {
+	for {
+		marker, err := br.ReadByte()
+		if err != nil {
+			return 0, unexpectedEOF("jpeg", err)
+		}
+		if marker != 0xff {
+			return marker, nil
+		}
+	}
+}
+
+// Copies the compressed data following a start of scan segment, up to the next marker, which is
+// returned. In this data, 0xff bytes followed by 0x00 or a restart marker don't end the scan. Returns
+// ErrFrameTooLarge once the frame exceeds maxBytes, if greater than 0
+func readEntropyCodedData(br *bufio.Reader, frame *bytes.Buffer, maxBytes int64) (byte, error) {
+	for {
+		if maxBytes > 0 && int64(frame.Len()) > maxBytes {
+			return 0, ErrFrameTooLarge
+		}
+
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0, unexpectedEOF("jpeg", err)
+		}
+
+		if b != 0xff {
+			frame.WriteByte(b)
+			continue
+		}
+
+		next, err := readMarker(br)
+		if err != nil {
+			return 0, err
+		}
+
+		if next != 0x00 && (next < 0xd0 || next > 0xd7) {
+			return next, nil
+		}
+
+		frame.WriteByte(b)
+		frame.WriteByte(next)
+	}
+}
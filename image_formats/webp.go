@@ -0,0 +1,205 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image_formats
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"io"
+	"time"
+
+	"golang.org/x/image/webp"
+)
+
+/*
+Animated WebP, see https://developers.google.com/speed/webp/docs/riff_container. Each ANMF chunk
+holds a frame's region, duration, blending and disposal, followed by its image data. Frames are
+decoded by rebuilding them as standalone WebP images
+*/
+
+type riffChunk struct {
+	kind string
+	data []byte
+}
+
+// Splits RIFF data into its chunks, each padded to an even length
+func readRIFFChunks(data []byte) ([]riffChunk, error) {
+	var chunks []riffChunk
+
+	for len(data) >= 8 {
+		length := binary.LittleEndian.Uint32(data[4:])
+		if uint64(length)+8 > uint64(len(data)) {
+			return nil, unexpectedEOF("webp", io.EOF)
+		}
+
+		chunks = append(chunks, riffChunk{kind: string(data[:4]), data: data[8 : 8+length]})
+
+		data = data[8+length:]
+		if length%2 == 1 && len(data) > 0 {
+			data = data[1:]
+		}
+	}
+
+	return chunks, nil
+}
+
+func appendRIFFChunk(buf []byte, kind string, data []byte) []byte {
+	buf = append(buf, kind...)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(data)))
+	buf = append(buf, data...)
+	if len(data)%2 == 1 {
+		buf = append(buf, 0)
+	}
+	return buf
+}
+
+// Reads a 24-bit little-endian number
+func uint24(data []byte) int {
+	return int(data[0]) | int(data[1])<<8 | int(data[2])<<16
+}
+
+// Returns the chunks of a WebP file after the "RIFF" header
+func readWebPChunks(data []byte) ([]riffChunk, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, FormatError("webp: invalid header")
+	}
+
+	return readRIFFChunks(data[12:])
+}
+
+// Animated WebPs start with a VP8X chunk that has the animation flag set
+func isAnimatedWebP(data []byte) bool {
+	const animationFlag = 1 << 1
+
+	return len(data) > 20 && string(data[12:16]) == "VP8X" && data[20]&animationFlag != 0
+}
+
+func readAnimatedWebP(data []byte) (*AnimationDecoder, error) {
+	chunks, err := readWebPChunks(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(chunks) == 0 || chunks[0].kind != "VP8X" || len(chunks[0].data) < 10 {
+		return nil, FormatError("webp: missing VP8X chunk")
+	}
+
+	canvasWidth := uint24(chunks[0].data[4:]) + 1
+	canvasHeight := uint24(chunks[0].data[7:]) + 1
+	if err := checkDimensions("webp", uint64(canvasWidth), uint64(canvasHeight), 4); err != nil {
+		return nil, err
+	}
+
+	decoder := &AnimationDecoder{Width: canvasWidth, Height: canvasHeight}
+	canvasRect := image.Rect(0, 0, canvasWidth, canvasHeight)
+
+	for _, chunk := range chunks[1:] {
+		switch chunk.kind {
+		case "ANIM":
+			// The background color is only a hint, and players commonly use transparency instead
+			if len(chunk.data) < 6 {
+				return nil, FormatError("webp: invalid ANIM chunk")
+			}
+			decoder.LoopCount = int(binary.LittleEndian.Uint16(chunk.data[4:]))
+
+		case "ANMF":
+			if len(chunk.data) < 16 {
+				return nil, FormatError("webp: invalid ANMF chunk")
+			}
+
+			header := chunk.data[:16]
+
+			// Offsets are stored halved
+			x, y := uint24(header[0:])*2, uint24(header[3:])*2
+			width, height := uint24(header[6:])+1, uint24(header[9:])+1
+			duration := time.Duration(uint24(header[12:])) * time.Millisecond
+			flags := header[15]
+
+			region := image.Rect(x, y, x+width, y+height)
+			if !region.In(canvasRect) {
+				return nil, FormatError("webp: frame lies outside of the canvas")
+			}
+
+			frameData := chunk.data[16:]
+
+			dispose := disposeNone
+			if flags&1 != 0 {
+				dispose = disposeBackground
+			}
+
+			// The blending bit is set to overwrite the area instead of alpha blending
+			blend := flags&2 == 0
+
+			decoder.frames = append(decoder.frames, animationFrame{
+				decode: func() (image.Image, error) {
+					frameImage, err := decodeWebPFrame(frameData, width, height)
+					if err != nil {
+						return nil, err
+					}
+					return offsetImage{frameImage, region.Min}, nil
+				},
+				delay:   duration,
+				blend:   blend,
+				dispose: dispose,
+			})
+		}
+	}
+
+	if len(decoder.frames) == 0 {
+		return nil, FormatError("webp: animation has no frames")
+	}
+
+	return decoder, nil
+}
+
+// Wraps a frame's ALPH, VP8 or VP8L chunks into a standalone WebP file and decodes it
+func decodeWebPFrame(frameData []byte, width, height int) (image.Image, error) {
+	chunks, err := readRIFFChunks(frameData)
+	if err != nil {
+		return nil, err
+	}
+
+	var body []byte
+
+	for _, chunk := range chunks {
+		// Lossy frames with transparency need a VP8X chunk announcing their alpha channel
+		if chunk.kind == "ALPH" {
+			const alphaFlag = 1 << 4
+
+			header := make([]byte, 10)
+			header[0] = alphaFlag
+			header[4], header[5], header[6] = byte(width-1), byte((width-1)>>8), byte((width-1)>>16)
+			header[7], header[8], header[9] = byte(height-1), byte((height-1)>>8), byte((height-1)>>16)
+
+			body = appendRIFFChunk(body, "VP8X", header)
+			break
+		}
+	}
+
+	for _, chunk := range chunks {
+		if chunk.kind == "ALPH" || chunk.kind == "VP8 " || chunk.kind == "VP8L" {
+			body = appendRIFFChunk(body, chunk.kind, chunk.data)
+		}
+	}
+
+	file := append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(len(body)+4))...)
+	file = append(file, "WEBP"...)
+	file = append(file, body...)
+
+	return webp.Decode(bytes.NewReader(file))
+}
@@ -0,0 +1,177 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image_formats
+
+import (
+	"encoding/binary"
+	"image/color"
+	"testing"
+	"time"
+)
+
+// Writes bits least significant first, as VP8L bitstreams are read
+type testBitWriter struct {
+	data []byte
+	bits int
+}
+
+func (w *testBitWriter) write(value uint32, bits int) {
+	for i := range bits {
+		if w.bits%8 == 0 {
+			w.data = append(w.data, 0)
+		}
+		w.data[len(w.data)-1] |= byte(value>>i&1) << (w.bits % 8)
+		w.bits++
+	}
+}
+
+// Encodes a lossless VP8L image of a single color. Each prefix code has a single symbol, which takes
+// no bits to read, so the pixels themselves take no space
+func testVP8L(width, height int, fill color.NRGBA) []byte {
+	var w testBitWriter
+
+	w.write(0x2f, 8)
+	w.write(uint32(width-1), 14)
+	w.write(uint32(height-1), 14)
+	w.write(1, 1) // Alpha is used
+	w.write(0, 3) // Version
+
+	w.write(0, 1) // No transforms
+	w.write(0, 1) // No color cache
+	w.write(0, 1) // No meta prefix codes
+
+	// Green, red, blue and alpha codes: a simple code of one 8-bit symbol
+	for _, value := range []uint8{fill.G, fill.R, fill.B, fill.A} {
+		w.write(1, 1)
+		w.write(0, 1)
+		w.write(1, 1)
+		w.write(uint32(value), 8)
+	}
+
+	// Distance code: a simple code of one 1-bit symbol
+	w.write(1, 1)
+	w.write(0, 1)
+	w.write(0, 1)
+	w.write(0, 1)
+
+	return w.data
+}
+
+func appendUint24(data []byte, value int) []byte {
+	return append(data, byte(value), byte(value>>8), byte(value>>16))
+}
+
+// Encodes an animated WebP of the passed frames, which are lossless. Frames can't be disposed of to
+// the previous canvas, and their offsets must be even
+func testAnimatedWebP(width, height int, loopCount uint16, frames ...testFrame) []byte {
+	const animationFlag, alphaFlag = 1 << 1, 1 << 4
+
+	vp8x := appendUint24([]byte{animationFlag | alphaFlag, 0, 0, 0}, width-1)
+	body := appendRIFFChunk(nil, "VP8X", appendUint24(vp8x, height-1))
+
+	anim := binary.LittleEndian.AppendUint16([]byte{0, 0, 0, 0}, loopCount)
+	body = appendRIFFChunk(body, "ANIM", anim)
+
+	for _, frame := range frames {
+		anmf := appendUint24(nil, frame.x/2)
+		anmf = appendUint24(anmf, frame.y/2)
+		anmf = appendUint24(anmf, frame.width-1)
+		anmf = appendUint24(anmf, frame.height-1)
+		anmf = appendUint24(anmf, int(frame.delay/time.Millisecond))
+
+		var flags byte
+		if frame.dispose == disposeBackground {
+			flags |= 1
+		}
+		if !frame.blend {
+			flags |= 2
+		}
+		anmf = append(anmf, flags)
+
+		anmf = appendRIFFChunk(anmf, "VP8L", testVP8L(frame.width, frame.height, frame.fill))
+		body = appendRIFFChunk(body, "ANMF", anmf)
+	}
+
+	data := binary.LittleEndian.AppendUint32([]byte("RIFF"), uint32(len(body)+4))
+	data = append(data, "WEBP"...)
+	return append(data, body...)
+}
+
+func TestAnimatedWebP(t *testing.T) {
+	blended := over(halfBlue, red)
+
+	frames := []testFrame{
+		{0, 0, 4, 2, red, 100 * time.Millisecond, disposeNone, false},
+		// Cleared to transparent once displayed
+		{2, 0, 2, 2, green, 200 * time.Millisecond, disposeBackground, false},
+		{0, 0, 2, 2, halfBlue, 300 * time.Millisecond, disposeNone, true},
+		// Replaces the transparent area without blending
+		{2, 0, 2, 2, halfBlue, 400 * time.Millisecond, disposeNone, false},
+		{0, 0, 2, 1, green, 500 * time.Millisecond, disposeNone, true},
+	}
+
+	want := [][][]color.Color{
+		{{red, red, red, red}, {red, red, red, red}},
+		{{red, red, green, green}, {red, red, green, green}},
+		{{blended, blended, transparent, transparent}, {blended, blended, transparent, transparent}},
+		{{blended, blended, halfBlue, halfBlue}, {blended, blended, halfBlue, halfBlue}},
+		{{green, green, halfBlue, halfBlue}, {blended, blended, halfBlue, halfBlue}},
+	}
+
+	var delays []time.Duration
+	for _, frame := range frames {
+		delays = append(delays, frame.delay)
+	}
+
+	data := testAnimatedWebP(4, 2, 2, frames...)
+	if !IsAnimated(data) {
+		t.Fatal("generated WebP isn't recognized as an animation")
+	}
+	checkAnimation(t, data, 2, want, delays)
+}
+
+func TestAnimatedWebPErrors(t *testing.T) {
+	valid := testAnimatedWebP(4, 2, 0,
+		testFrame{0, 0, 4, 2, red, 0, disposeNone, false},
+		testFrame{2, 0, 2, 2, green, 0, disposeNone, false},
+	)
+
+	outside := testAnimatedWebP(4, 2, 0,
+		testFrame{0, 0, 4, 2, red, 0, disposeNone, false},
+		testFrame{2, 2, 2, 2, green, 0, disposeNone, false},
+	)
+
+	shortANMF := appendRIFFChunk(append([]byte(nil), valid...), "ANMF", make([]byte, 15))
+	binary.LittleEndian.PutUint32(shortANMF[4:], uint32(len(shortANMF)-8))
+
+	tests := []struct {
+		name      string
+		data      []byte
+		truncated bool
+	}{
+		{"chunk cut off", valid[:len(valid)-4], true},
+		{"frame outside of the canvas", outside, false},
+		{"ANMF chunk without a frame header", shortANMF, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewAnimationDecoder(test.data)
+			checkDecodeError(t, err, test.truncated)
+		})
+	}
+}
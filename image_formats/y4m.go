@@ -0,0 +1,239 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image_formats
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"strconv"
+	"strings"
+)
+
+/*
+YUV4MPEG2 streams, as written by ffmpeg with -f yuv4mpegpipe. A text header giving the frame size,
+rate and chroma subsampling is followed by frames of raw, planar YCbCr samples. Decoding a stream
+with image.Decode() returns its first frame, while Y4MReader reads every frame
+*/
+
+const y4mMagic = "YUV4MPEG2 "
+
+func init() {
+	register(Format{
+		Name:        "y4m",
+		Description: "YUV4MPEG2 video",
+		Extensions:  []string{".y4m"},
+		Magic:       []string{y4mMagic},
+		Video:       true,
+	}, decodeY4M, decodeY4MConfig)
+}
+
+// Y4MReader reads the frames of a YUV4MPEG2 stream one at a time
+type Y4MReader struct {
+	r *bufio.Reader
+
+	width, height int
+	frameRate     float64
+	subsampling   image.YCbCrSubsampleRatio
+	mono          bool
+	fullRange     bool
+}
+
+// NewY4MReader reads the stream header from r, leaving the frames to Next()
+func NewY4MReader(r io.Reader) (*Y4MReader, error) {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+
+	line, err := br.ReadString('\n')
+	if err != nil {
+		return nil, unexpectedEOF("y4m", err)
+	}
+
+	if !strings.HasPrefix(line, y4mMagic) {
+		return nil, FormatError("y4m: invalid header")
+	}
+
+	reader := &Y4MReader{r: br, subsampling: image.YCbCrSubsampleRatio420}
+
+	for _, param := range strings.Fields(line[len(y4mMagic):]) {
+		value := param[1:]
+
+		switch param[0] {
+		case 'W':
+			reader.width, err = strconv.Atoi(value)
+		case 'H':
+			reader.height, err = strconv.Atoi(value)
+		case 'F':
+			reader.frameRate, err = parseY4MRatio(value)
+		case 'C':
+			err = reader.setColorspace(value)
+		case 'X':
+			if value == "COLORRANGE=FULL" {
+				reader.fullRange = true
+			}
+		}
+
+		if err != nil {
+			return nil, FormatError(fmt.Sprintf("y4m: invalid header parameter %q", param))
+		}
+	}
+
+	if reader.width < 0 || reader.height < 0 {
+		return nil, FormatError("y4m: invalid frame size")
+	}
+	if err := checkDimensions("y4m", uint64(reader.width), uint64(reader.height), 4); err != nil {
+		return nil, err
+	}
+
+	return reader, nil
+}
+
+func (y *Y4MReader) setColorspace(colorspace string) error {
+	switch colorspace {
+	case "420", "420jpeg", "420paldv", "420mpeg2":
+		y.subsampling = image.YCbCrSubsampleRatio420
+	case "422":
+		y.subsampling = image.YCbCrSubsampleRatio422
+	case "444":
+		y.subsampling = image.YCbCrSubsampleRatio444
+	case "411":
+		y.subsampling = image.YCbCrSubsampleRatio411
+	case "mono":
+		y.mono = true
+	default:
+		return FormatError(fmt.Sprintf("y4m: unsupported colorspace %q", colorspace))
+	}
+	return nil
+}
+
+// Parses a frame rate such as 30000:1001
+func parseY4MRatio(ratio string) (float64, error) {
+	numerator, denominator, ok := strings.Cut(ratio, ":")
+	if !ok {
+		return 0, FormatError("y4m: invalid ratio")
+	}
+
+	n, err := strconv.Atoi(numerator)
+	if err != nil {
+		return 0, err
+	}
+	d, err := strconv.Atoi(denominator)
+	if err != nil {
+		return 0, err
+	}
+
+	if n <= 0 || d <= 0 {
+		return 0, nil
+	}
+	return float64(n) / float64(d), nil
+}
+
+// Size of each frame
+func (y *Y4MReader) Bounds() image.Rectangle {
+	return image.Rect(0, 0, y.width, y.height)
+}
+
+// FrameRate returns the frame rate in frames per second, or 0 if the header doesn't specify it
+func (y *Y4MReader) FrameRate() float64 {
+	return y.frameRate
+}
+
+// Next returns the next frame, or io.EOF once the stream has ended
+func (y *Y4MReader) Next() (image.Image, error) {
+	line, err := y.r.ReadString('\n')
+	if err == io.EOF && line == "" {
+		return nil, io.EOF
+	} else if err != nil {
+		return nil, unexpectedEOF("y4m", err)
+	}
+
+	if !strings.HasPrefix(line, "FRAME") {
+		return nil, FormatError("y4m: invalid frame header")
+	}
+
+	if y.mono {
+		img := image.NewGray(y.Bounds())
+		if _, err := io.ReadFull(y.r, img.Pix); err != nil {
+			return nil, unexpectedEOF("y4m", err)
+		}
+		if !y.fullRange {
+			expandRange(img.Pix, 16, 235)
+		}
+		return img, nil
+	}
+
+	// Planes are stored without padding, which matches the strides of image.NewYCbCr()
+	img := image.NewYCbCr(y.Bounds(), y.subsampling)
+
+	for _, plane := range [][]byte{img.Y, img.Cb, img.Cr} {
+		if _, err := io.ReadFull(y.r, plane); err != nil {
+			return nil, unexpectedEOF("y4m", err)
+		}
+	}
+
+	// Video is usually limited to studio range, while image.YCbCr expects the full range
+	if !y.fullRange {
+		expandRange(img.Y, 16, 235)
+		expandRange(img.Cb, 16, 240)
+		expandRange(img.Cr, 16, 240)
+	}
+
+	return img, nil
+}
+
+// Stretches samples from [low, high] to [0, 255]
+func expandRange(samples []byte, low, high int) {
+	var table [256]byte
+	for i := range table {
+		table[i] = uint8(min(max((i-low)*255/(high-low), 0), 255))
+	}
+
+	for i, sample := range samples {
+		samples[i] = table[sample]
+	}
+}
+
+func decodeY4MConfig(r io.Reader) (image.Config, error) {
+	reader, err := NewY4MReader(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+
+	colorModel := color.YCbCrModel
+	if reader.mono {
+		colorModel = color.GrayModel
+	}
+
+	return image.Config{ColorModel: colorModel, Width: reader.width, Height: reader.height}, nil
+}
+
+func decodeY4M(r io.Reader) (image.Image, error) {
+	reader, err := NewY4MReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	frame, err := reader.Next()
+	if err == io.EOF {
+		return nil, FormatError("y4m: stream has no frames")
+	}
+	return frame, err
+}
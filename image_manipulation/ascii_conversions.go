@@ -17,6 +17,8 @@ limitations under the License.
 package image_conversions
 
 import (
+	"fmt"
+
 	gookitColor "github.com/gookit/color"
 )
 
@@ -38,13 +40,22 @@ var (
 		{0x4, 0x20},
 		{0x40, 0x80},
 	}
-
-	BrailleThreshold uint32
 )
 
 // For each individual element of imgSet in ConvertToASCIISlice()
 const MAX_VAL float64 = 255
 
+// CharMap returns the characters ascii art is mapped against, ordered from darkest to lightest.
+// customMap is returned if set, otherwise the table of 70 characters if complex is set, or of 10
+func CharMap(complex bool, customMap string) string {
+	if customMap != "" {
+		return customMap
+	} else if complex {
+		return asciiTableDetailed
+	}
+	return asciiTableSimple
+}
+
 type AsciiChar struct {
 	OriginalColor    string
 	OriginalColorRGB gookitColor.RGBColor
@@ -52,6 +63,9 @@ type AsciiChar struct {
 	SetColorRGB      gookitColor.RGBColor
 	Simple           string
 	RgbValue         [3]uint32
+
+	// Set if every pixel this character was computed from is fully transparent
+	Transparent bool
 }
 
 /*
@@ -60,34 +74,23 @@ to a 2D image_conversions.AsciiChar slice
 
 If complex parameter is true, values are compared to 70 levels of color density in ASCII characters.
 Otherwise, values are compared to 10 levels of color density in ASCII characters.
+
+imgSet isn't modified, so the same pixels can be converted again with other options
 */
 func ConvertToAsciiChars(imgSet [][]AsciiPixel, negative, colored, grayscale, complex, colorBg bool, customMap string, fontColor [3]int, colorLevel ColorLevel) ([][]AsciiChar, error) {
 
+	if len(imgSet) == 0 || len(imgSet[0]) == 0 {
+		return nil, fmt.Errorf("%w: no pixels to convert", ErrInvalidDimensions)
+	}
+
 	height := len(imgSet)
 	width := len(imgSet[0])
 
 	chosenTable := map[int]string{}
 
 	// Turn ascii character-set string into map[int]string{} literal
-	if customMap == "" {
-		var charSet string
-
-		if complex {
-			charSet = asciiTableDetailed
-		} else {
-			charSet = asciiTableSimple
-		}
-
-		for index, char := range charSet {
-			chosenTable[index] = string(char)
-		}
-
-	} else {
-		chosenTable = map[int]string{}
-
-		for index, char := range customMap {
-			chosenTable[index] = string(char)
-		}
+	for index, char := range CharMap(complex, customMap) {
+		chosenTable[index] = string(char)
 	}
 
 	var result [][]AsciiChar
@@ -124,13 +127,6 @@ func ConvertToAsciiChars(imgSet [][]AsciiPixel, negative, colored, grayscale, co
 				g = 255 - g
 				b = 255 - b
 
-				// To preserve negative rgb values for saving png image later down the line, since it uses imgSet
-				if colored {
-					imgSet[i][j].rgbValue = [3]uint32{uint32(r), uint32(g), uint32(b)}
-				} else {
-					imgSet[i][j].grayscaleValue = [3]uint32{uint32(r), uint32(g), uint32(b)}
-				}
-
 				tempInt = (len(chosenTable) - 1) - tempInt
 			}
 
@@ -138,6 +134,7 @@ func ConvertToAsciiChars(imgSet [][]AsciiPixel, negative, colored, grayscale, co
 
 			asciiChar := chosenTable[tempInt]
 			char.Simple = asciiChar
+			char.Transparent = imgSet[i][j].alpha == 0
 
 			var err error
 			char.OriginalColor, char.OriginalColorRGB, err = getColoredCharForTerm(uint8(r), uint8(g), uint8(b), asciiChar, colorBg, colorLevel)
@@ -157,11 +154,7 @@ func ConvertToAsciiChars(imgSet [][]AsciiPixel, negative, colored, grayscale, co
 				}
 			}
 
-			if colored {
-				char.RgbValue = imgSet[i][j].rgbValue
-			} else {
-				char.RgbValue = imgSet[i][j].grayscaleValue
-			}
+			char.RgbValue = [3]uint32{uint32(r), uint32(g), uint32(b)}
 
 			tempSlice = append(tempSlice, char)
 		}
@@ -175,11 +168,14 @@ func ConvertToAsciiChars(imgSet [][]AsciiPixel, negative, colored, grayscale, co
 Converts the 2D image_conversions.AsciiPixel slice of image data (each instance representing each compressed pixel of original image)
 to a 2D image_conversions.AsciiChar slice
 
-Unlike ConvertToAsciiChars(), this function calculates braille characters instead of ascii
+Unlike ConvertToAsciiChars(), this function calculates braille characters instead of ascii. Like it,
+imgSet isn't modified
 */
 func ConvertToBrailleChars(imgSet [][]AsciiPixel, negative, colored, grayscale, colorBg bool, fontColor [3]int, threshold int, colorLevel ColorLevel) ([][]AsciiChar, error) {
 
-	BrailleThreshold = uint32(threshold)
+	if len(imgSet) == 0 || len(imgSet[0]) == 0 {
+		return nil, fmt.Errorf("%w: no pixels to convert", ErrInvalidDimensions)
+	}
 
 	height := len(imgSet)
 	width := len(imgSet[0])
@@ -192,7 +188,7 @@ func ConvertToBrailleChars(imgSet [][]AsciiPixel, negative, colored, grayscale,
 
 		for j := 0; j < width; j += 2 {
 
-			brailleChar := getBrailleChar(i, j, negative, imgSet)
+			brailleChar := getBrailleChar(i, j, negative, uint32(threshold), imgSet)
 
 			var r, g, b int
 
@@ -211,17 +207,12 @@ func ConvertToBrailleChars(imgSet [][]AsciiPixel, negative, colored, grayscale,
 				r = 255 - r
 				g = 255 - g
 				b = 255 - b
-
-				if colored {
-					imgSet[i][j].rgbValue = [3]uint32{uint32(r), uint32(g), uint32(b)}
-				} else {
-					imgSet[i][j].grayscaleValue = [3]uint32{uint32(r), uint32(g), uint32(b)}
-				}
 			}
 
 			var char AsciiChar
 
 			char.Simple = brailleChar
+			char.Transparent = isBrailleTransparent(i, j, imgSet)
 
 			var err error
 			if colorBg {
@@ -249,11 +240,7 @@ func ConvertToBrailleChars(imgSet [][]AsciiPixel, negative, colored, grayscale,
 				}
 			}
 
-			if colored {
-				char.RgbValue = imgSet[i][j].rgbValue
-			} else {
-				char.RgbValue = imgSet[i][j].grayscaleValue
-			}
+			char.RgbValue = [3]uint32{uint32(r), uint32(g), uint32(b)}
 
 			tempSlice = append(tempSlice, char)
 		}
@@ -264,24 +251,39 @@ func ConvertToBrailleChars(imgSet [][]AsciiPixel, negative, colored, grayscale,
 	return result, nil
 }
 
-// Iterate through the BrailleStruct table to see which dots need to be highlighted
-func getBrailleChar(x, y int, negative bool, imgSet [][]AsciiPixel) string {
+// A braille character is only transparent if all of its 8 dots are
+func isBrailleTransparent(x, y int, imgSet [][]AsciiPixel) bool {
+
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 2; j++ {
+			if imgSet[x+i][y+j].alpha != 0 {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// Iterate through the BrailleStruct table to see which dots need to be highlighted, those at or
+// above the threshold (or at or below it for negative)
+func getBrailleChar(x, y int, negative bool, threshold uint32, imgSet [][]AsciiPixel) string {
 
 	brailleChar := 0x2800
 
 	for i := 0; i < 4; i++ {
 		for j := 0; j < 2; j++ {
 			if negative {
-				if imgSet[x+i][y+j].charDepth <= BrailleThreshold {
+				if imgSet[x+i][y+j].charDepth <= threshold {
 					brailleChar += BrailleStruct[i][j]
 				}
 			} else {
-				if imgSet[x+i][y+j].charDepth >= BrailleThreshold {
+				if imgSet[x+i][y+j].charDepth >= threshold {
 					brailleChar += BrailleStruct[i][j]
 				}
 			}
 		}
 	}
 
-	return string(brailleChar)
+	return string(rune(brailleChar))
 }
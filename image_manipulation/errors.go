@@ -0,0 +1,60 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image_conversions
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors returned by the conversion functions, wrapped with details of the failure.
+// Compare against them with errors.Is()
+var (
+	// The input isn't one of the supported file types
+	ErrUnsupportedFormat = errors.New("unsupported file type")
+
+	// The requested ascii art size is missing, conflicting or not positive
+	ErrInvalidDimensions = errors.New("invalid dimensions")
+
+	// The requested output can't be produced from an animated input such as a GIF
+	ErrAnimationUnsupported = errors.New("animated input is not supported")
+
+	// The input exceeds the configured byte or pixel limit
+	ErrInputTooLarge = errors.New("input too large")
+
+	// The color level is neither Millions nor Hundreds
+	ErrUnsupportedColorLevel = errors.New("unsupported color level")
+)
+
+// DecodeError is returned when input of a supported file type can't be decoded, e.g. because it's
+// truncated or malformed. Retrieve it with errors.As() to inspect the decoder's error
+type DecodeError struct {
+	// Format of the input, such as "png" or "gif". Empty if the decoder couldn't tell
+	Format string
+	Err    error
+}
+
+func (e *DecodeError) Error() string {
+	if e.Format == "" {
+		return fmt.Sprintf("Can't decode input: %v", e.Err)
+	}
+	return fmt.Sprintf("Can't decode %v input: %v", e.Format, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
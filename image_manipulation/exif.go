@@ -0,0 +1,156 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image_conversions
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+
+	"github.com/disintegration/imaging"
+)
+
+const exifOrientationTag = 0x0112
+
+/*
+Reads the EXIF Orientation tag from JPEG (APP1 segment) or TIFF (IFD0) encoded bytes.
+
+Returns a value between 1 and 8 as defined by the EXIF specification. If the input has no
+orientation tag or can't be parsed, 1 (upright) is returned.
+*/
+func ExifOrientation(data []byte) int {
+
+	var orientation int
+
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8}):
+		orientation = jpegOrientation(data)
+	case bytes.HasPrefix(data, []byte("II*\x00")), bytes.HasPrefix(data, []byte("MM\x00*")):
+		orientation = tiffOrientation(data)
+	}
+
+	if orientation < 1 || orientation > 8 {
+		return 1
+	}
+	return orientation
+}
+
+// Walks the JPEG marker segments up to the start of scan, looking for an Exif APP1 segment
+func jpegOrientation(data []byte) int {
+
+	pos := 2
+
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return 0
+		}
+
+		marker := data[pos+1]
+
+		// Fill bytes may precede a marker
+		if marker == 0xFF {
+			pos++
+			continue
+		}
+
+		// Start of scan, no more metadata segments follow
+		if marker == 0xDA {
+			return 0
+		}
+
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if length < 2 || pos+2+length > len(data) {
+			return 0
+		}
+
+		segment := data[pos+4 : pos+2+length]
+
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return tiffOrientation(segment[6:])
+		}
+
+		pos += 2 + length
+	}
+
+	return 0
+}
+
+// Reads the orientation entry from the first IFD of a TIFF structure
+func tiffOrientation(data []byte) int {
+
+	if len(data) < 8 {
+		return 0
+	}
+
+	var order binary.ByteOrder
+
+	switch string(data[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+
+	ifdOffset := int(order.Uint32(data[4:]))
+	if ifdOffset < 8 || ifdOffset+2 > len(data) {
+		return 0
+	}
+
+	entries := int(order.Uint16(data[ifdOffset:]))
+
+	for i := 0; i < entries; i++ {
+		entry := ifdOffset + 2 + i*12
+		if entry+12 > len(data) {
+			return 0
+		}
+
+		if order.Uint16(data[entry:]) == exifOrientationTag {
+			// Orientation is a single SHORT, stored in the first bytes of the value field
+			return int(order.Uint16(data[entry+8:]))
+		}
+	}
+
+	return 0
+}
+
+/*
+Rotates and/or flips the passed image so that it's displayed upright according to the
+given EXIF orientation value
+*/
+func ApplyOrientation(img image.Image, orientation int) image.Image {
+
+	switch orientation {
+	case 2:
+		return imaging.FlipH(img)
+	case 3:
+		return imaging.Rotate180(img)
+	case 4:
+		return imaging.FlipV(img)
+	case 5:
+		return imaging.Transpose(img)
+	case 6:
+		return imaging.Rotate270(img)
+	case 7:
+		return imaging.Transverse(img)
+	case 8:
+		return imaging.Rotate90(img)
+	default:
+		return img
+	}
+}
@@ -0,0 +1,175 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image_conversions
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// Returns a TIFF header and first IFD holding an image width entry followed by an orientation entry
+func testTIFF(order binary.AppendByteOrder, orientation uint16) []byte {
+	var data []byte
+	if order == binary.LittleEndian {
+		data = []byte("II*\x00")
+	} else {
+		data = []byte("MM\x00*")
+	}
+	data = order.AppendUint32(data, 8)
+
+	data = order.AppendUint16(data, 2)
+	for _, entry := range [][2]uint16{{0x0100, 640}, {exifOrientationTag, orientation}} {
+		data = order.AppendUint16(data, entry[0])
+		data = order.AppendUint16(data, 3) // SHORT
+		data = order.AppendUint32(data, 1)
+		data = order.AppendUint16(data, entry[1])
+		data = append(data, 0, 0)
+	}
+
+	// Offset of the next IFD, of which there's none
+	return order.AppendUint32(data, 0)
+}
+
+// Returns the start of a JPEG with the passed segments following its start of image marker, and a
+// start of scan marker after them
+func testJPEGSegments(segments ...[]byte) []byte {
+	data := []byte{0xff, 0xd8}
+	for _, segment := range segments {
+		data = append(data, segment...)
+	}
+	return append(data, 0xff, 0xda, 0x00, 0x02)
+}
+
+// Returns a JPEG marker segment with the passed payload
+func testSegment(marker byte, payload []byte) []byte {
+	segment := []byte{0xff, marker}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+func exifSegment(tiff []byte) []byte {
+	return testSegment(0xe1, append([]byte("Exif\x00\x00"), tiff...))
+}
+
+func TestExifOrientation(t *testing.T) {
+	jfif := testSegment(0xe0, []byte("JFIF\x00\x01\x01\x00\x00\x01\x00\x01\x00\x00"))
+
+	for _, order := range []binary.AppendByteOrder{binary.LittleEndian, binary.BigEndian} {
+		for orientation := 1; orientation <= 8; orientation++ {
+			tiff := testTIFF(order, uint16(orientation))
+
+			t.Run(fmt.Sprintf("%v orientation %v", order, orientation), func(t *testing.T) {
+				if got := ExifOrientation(tiff); got != orientation {
+					t.Errorf("got %v from a TIFF, want %v", got, orientation)
+				}
+				if got := ExifOrientation(testJPEGSegments(jfif, exifSegment(tiff))); got != orientation {
+					t.Errorf("got %v from a JPEG, want %v", got, orientation)
+				}
+			})
+		}
+	}
+
+	rotated := testTIFF(binary.BigEndian, 6)
+
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{"fill bytes before a marker", testJPEGSegments([]byte{0xff, 0xff}, exifSegment(rotated)), 6},
+		{"out of range orientation", testJPEGSegments(exifSegment(testTIFF(binary.BigEndian, 9))), 1},
+		{"orientation 0", testTIFF(binary.LittleEndian, 0), 1},
+		{"no exif", testJPEGSegments(jfif), 1},
+		{"APP1 segment that isn't exif", testJPEGSegments(testSegment(0xe1, append([]byte("http://ns.adobe.com/xap/1.0/\x00"), rotated...))), 1},
+		{"exif after the start of scan", append(testJPEGSegments(jfif), exifSegment(rotated)...), 1},
+		{"segment longer than the data", testJPEGSegments(jfif, exifSegment(rotated))[:len(jfif)+20], 1},
+		{"truncated marker", []byte{0xff, 0xd8, 0xff}, 1},
+		{"no marker after the start of image", []byte{0xff, 0xd8, 0x00, 0x00, 0x00, 0x00}, 1},
+		{"exif shorter than a TIFF header", testJPEGSegments(exifSegment(rotated[:6])), 1},
+		{"exif with an unknown byte order", testJPEGSegments(exifSegment(append([]byte("XX"), rotated[2:]...))), 1},
+		{"IFD offset past the data", testJPEGSegments(exifSegment(append(append([]byte{}, rotated[:4]...), 0, 0, 1, 0))), 1},
+		{"IFD entry cut off", rotated[:8+2+12+6], 1},
+		{"TIFF cut off before its IFD", rotated[:8], 1},
+		{"PNG", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR"), 1},
+		{"empty", nil, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := ExifOrientation(test.data); got != test.want {
+				t.Errorf("got orientation %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestApplyOrientation(t *testing.T) {
+	const width, height = 3, 2
+
+	// Every pixel differs, so the position each one ends up at can be checked
+	stored := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			stored.SetNRGBA(x, y, color.NRGBA{uint8(x * 80), uint8(y * 80), 0, 255})
+		}
+	}
+
+	// Where the stored pixel at (x, y) is displayed for each orientation, per the EXIF specification
+	tests := []struct {
+		orientation int
+		upright     func(x, y int) (int, int)
+	}{
+		{1, func(x, y int) (int, int) { return x, y }},
+		{2, func(x, y int) (int, int) { return width - 1 - x, y }},
+		{3, func(x, y int) (int, int) { return width - 1 - x, height - 1 - y }},
+		{4, func(x, y int) (int, int) { return x, height - 1 - y }},
+		{5, func(x, y int) (int, int) { return y, x }},
+		{6, func(x, y int) (int, int) { return height - 1 - y, x }},
+		{7, func(x, y int) (int, int) { return height - 1 - y, width - 1 - x }},
+		{8, func(x, y int) (int, int) { return y, width - 1 - x }},
+		{0, func(x, y int) (int, int) { return x, y }},
+		{9, func(x, y int) (int, int) { return x, y }},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprint(test.orientation), func(t *testing.T) {
+			displayed := ApplyOrientation(stored, test.orientation)
+
+			wantWidth, wantHeight := width, height
+			if test.orientation >= 5 && test.orientation <= 8 {
+				wantWidth, wantHeight = height, width
+			}
+			if size := displayed.Bounds().Size(); size.X != wantWidth || size.Y != wantHeight {
+				t.Fatalf("got a %vx%v image, want %vx%v", size.X, size.Y, wantWidth, wantHeight)
+			}
+
+			origin := displayed.Bounds().Min
+			for y := 0; y < height; y++ {
+				for x := 0; x < width; x++ {
+					displayedX, displayedY := test.upright(x, y)
+					got := color.NRGBAModel.Convert(displayed.At(origin.X+displayedX, origin.Y+displayedY))
+					if want := stored.NRGBAAt(x, y); got != want {
+						t.Errorf("pixel (%v, %v) is displayed as %v at (%v, %v), want %v", x, y, got, displayedX, displayedY, want)
+					}
+				}
+			}
+		})
+	}
+}
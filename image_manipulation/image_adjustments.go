@@ -0,0 +1,268 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image_conversions
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/disintegration/imaging"
+)
+
+const (
+	// Percentiles used by auto-levels to find the darkest and lightest points of an image
+	autoLevelsLow  = 0.01
+	autoLevelsHigh = 0.99
+
+	// Adaptive equalization splits the image into at most claheTiles x claheTiles regions.
+	// Each region's histogram is clipped at claheClipLimit times the average bin height
+	claheTiles     = 8
+	claheClipLimit = 2.0
+)
+
+/*
+Applies tonal preprocessing to the passed image before it's sampled for ascii conversion.
+
+Auto-levels and adaptive equalization are applied first since they normalize the image,
+followed by brightness and contrast (percentages between -100 and 100) and gamma correction.
+A gamma of 0 or 1 leaves the image unchanged. If no adjustment is requested, the original
+image is returned as is.
+*/
+func AdjustImage(img image.Image, brightness, contrast, gamma float64, autoLevels, equalize bool) image.Image {
+
+	if autoLevels {
+		img = stretchLevels(img)
+	}
+
+	if equalize {
+		img = equalizeAdaptive(img)
+	}
+
+	if brightness != 0 {
+		img = imaging.AdjustBrightness(img, brightness)
+	}
+
+	if contrast != 0 {
+		img = imaging.AdjustContrast(img, contrast)
+	}
+
+	if gamma != 0 && gamma != 1 {
+		img = imaging.AdjustGamma(img, gamma)
+	}
+
+	return img
+}
+
+// Same weights as color.GrayModel, so adjustments agree with charDepth in ConvertToAsciiPixels()
+func luminance(r, g, b uint8) uint8 {
+	y := (19595*uint32(r) + 38470*uint32(g) + 7471*uint32(b) + 1<<15) >> 16
+	return uint8(y)
+}
+
+// Linearly stretches each channel so the 1st and 99th luminance percentiles map to 0 and 255
+func stretchLevels(img image.Image) image.Image {
+
+	nrgba := imaging.Clone(img)
+
+	var histogram [256]int
+	total := 0
+
+	for i := 0; i+3 < len(nrgba.Pix); i += 4 {
+		histogram[luminance(nrgba.Pix[i], nrgba.Pix[i+1], nrgba.Pix[i+2])]++
+		total++
+	}
+
+	if total == 0 {
+		return img
+	}
+
+	low, high := 0, 255
+	cumulative := 0
+	foundLow := false
+
+	for value, count := range histogram {
+		cumulative += count
+
+		if !foundLow && float64(cumulative) >= autoLevelsLow*float64(total) {
+			low = value
+			foundLow = true
+		}
+
+		if float64(cumulative) >= autoLevelsHigh*float64(total) {
+			high = value
+			break
+		}
+	}
+
+	// Flat images can't be stretched
+	if high <= low {
+		return img
+	}
+
+	var lut [256]uint8
+	scale := 255 / float64(high-low)
+
+	for value := range lut {
+		lut[value] = clampChannel((float64(value) - float64(low)) * scale)
+	}
+
+	return imaging.AdjustFunc(nrgba, func(c color.NRGBA) color.NRGBA {
+		return color.NRGBA{lut[c.R], lut[c.G], lut[c.B], c.A}
+	})
+}
+
+/*
+Contrast limited adaptive histogram equalization on the image's luminance.
+
+The image is split into tiles, each tile gets its own clipped equalization mapping, and every
+pixel's new luminance is bilinearly interpolated between the mappings of its 4 nearest tiles.
+Colors are then scaled by the luminance change so hues are preserved.
+*/
+func equalizeAdaptive(img image.Image) image.Image {
+
+	nrgba := imaging.Clone(img)
+	width := nrgba.Rect.Dx()
+	height := nrgba.Rect.Dy()
+
+	if width == 0 || height == 0 {
+		return img
+	}
+
+	tilesX := min(claheTiles, width)
+	tilesY := min(claheTiles, height)
+	tileWidth := int(math.Ceil(float64(width) / float64(tilesX)))
+	tileHeight := int(math.Ceil(float64(height) / float64(tilesY)))
+
+	lum := make([]uint8, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			i := y*nrgba.Stride + x*4
+			lum[y*width+x] = luminance(nrgba.Pix[i], nrgba.Pix[i+1], nrgba.Pix[i+2])
+		}
+	}
+
+	mappings := make([][256]uint8, tilesX*tilesY)
+
+	for ty := 0; ty < tilesY; ty++ {
+		for tx := 0; tx < tilesX; tx++ {
+			x0, y0 := tx*tileWidth, ty*tileHeight
+			x1, y1 := min(x0+tileWidth, width), min(y0+tileHeight, height)
+
+			var histogram [256]int
+			count := 0
+
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					histogram[lum[y*width+x]]++
+					count++
+				}
+			}
+
+			mappings[ty*tilesX+tx] = clippedEqualization(histogram, count)
+		}
+	}
+
+	// Position of a pixel in "tile center" space, along with the weight of the further tile
+	tileCoords := func(pos, tileSize, tiles int) (int, int, float64) {
+		f := (float64(pos)+0.5)/float64(tileSize) - 0.5
+		t0 := int(math.Floor(f))
+		weight := f - float64(t0)
+
+		if t0 < 0 {
+			t0, weight = 0, 0
+		}
+		if t0 >= tiles-1 {
+			t0, weight = tiles-1, 0
+		}
+
+		return t0, min(t0+1, tiles-1), weight
+	}
+
+	for y := 0; y < height; y++ {
+		ty0, ty1, wy := tileCoords(y, tileHeight, tilesY)
+
+		for x := 0; x < width; x++ {
+			tx0, tx1, wx := tileCoords(x, tileWidth, tilesX)
+
+			l := lum[y*width+x]
+			top := (1-wx)*float64(mappings[ty0*tilesX+tx0][l]) + wx*float64(mappings[ty0*tilesX+tx1][l])
+			bottom := (1-wx)*float64(mappings[ty1*tilesX+tx0][l]) + wx*float64(mappings[ty1*tilesX+tx1][l])
+			newLum := (1-wy)*top + wy*bottom
+
+			i := y*nrgba.Stride + x*4
+
+			if l == 0 {
+				value := clampChannel(newLum)
+				nrgba.Pix[i], nrgba.Pix[i+1], nrgba.Pix[i+2] = value, value, value
+				continue
+			}
+
+			scale := newLum / float64(l)
+			nrgba.Pix[i] = clampChannel(float64(nrgba.Pix[i]) * scale)
+			nrgba.Pix[i+1] = clampChannel(float64(nrgba.Pix[i+1]) * scale)
+			nrgba.Pix[i+2] = clampChannel(float64(nrgba.Pix[i+2]) * scale)
+		}
+	}
+
+	return nrgba
+}
+
+// Returns the equalization mapping of a histogram after clipping and redistributing its peaks
+func clippedEqualization(histogram [256]int, count int) [256]uint8 {
+
+	var mapping [256]uint8
+
+	if count == 0 {
+		for value := range mapping {
+			mapping[value] = uint8(value)
+		}
+		return mapping
+	}
+
+	// The limit is fractional for tiles of fewer than 128 pixels, where rounding it up would leave
+	// most of the histogram unclipped
+	limit := claheClipLimit * float64(count) / 256
+
+	var clipped [256]float64
+	excess := 0.0
+	for value, bin := range histogram {
+		clipped[value] = min(float64(bin), limit)
+		excess += float64(bin) - clipped[value]
+	}
+
+	// The excess is spread evenly over every value, so it doesn't shift the mapping either way
+	share := excess / 256
+
+	cumulative := 0.0
+	for value, bin := range clipped {
+		cumulative += bin + share
+		mapping[value] = clampChannel(cumulative * 255 / float64(count))
+	}
+
+	return mapping
+}
+
+func clampChannel(value float64) uint8 {
+	if value < 0 {
+		return 0
+	}
+	if value > 255 {
+		return 255
+	}
+	return uint8(value + 0.5)
+}
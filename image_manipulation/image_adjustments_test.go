@@ -0,0 +1,178 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image_conversions
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/disintegration/imaging"
+)
+
+// Returns a gray image with each pixel's shade given by shade, and the alpha given by alpha
+func testGrayImage(width, height int, shade func(x, y int) uint8, alpha uint8) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			value := shade(x, y)
+			img.SetNRGBA(x, y, color.NRGBA{value, value, value, alpha})
+		}
+	}
+	return img
+}
+
+// Returns the darkest and lightest luminance of an image, and whether every pixel has the passed alpha
+func luminanceRange(img image.Image, alpha uint8) (low, high uint8, alphaKept bool) {
+	nrgba := imaging.Clone(img)
+	low, high, alphaKept = 255, 0, true
+
+	for i := 0; i < len(nrgba.Pix); i += 4 {
+		l := luminance(nrgba.Pix[i], nrgba.Pix[i+1], nrgba.Pix[i+2])
+		low, high = min(low, l), max(high, l)
+		alphaKept = alphaKept && nrgba.Pix[i+3] == alpha
+	}
+	return low, high, alphaKept
+}
+
+func TestStretchLevels(t *testing.T) {
+	// Shades between 100 and 149 only
+	lowContrast := testGrayImage(50, 20, func(x, y int) uint8 { return uint8(100 + x) }, 200)
+
+	stretched := stretchLevels(lowContrast)
+
+	low, high, alphaKept := luminanceRange(stretched, 200)
+	if low != 0 || high != 255 {
+		t.Errorf("got shades between %v and %v, want the full range", low, high)
+	}
+	if !alphaKept {
+		t.Error("alpha was changed")
+	}
+
+	// The order of shades is kept
+	row := imaging.Clone(stretched)
+	for x := 1; x < 50; x++ {
+		if row.Pix[x*4] < row.Pix[(x-1)*4] {
+			t.Fatalf("shade at x=%v is darker than the one before it after stretching", x)
+		}
+	}
+
+	// A few outliers beyond the 1st and 99th percentiles don't hold back the stretch
+	outliers := testGrayImage(100, 10, func(x, y int) uint8 {
+		switch {
+		case x == 0 && y == 0:
+			return 0
+		case x == 99 && y == 9:
+			return 255
+		}
+		return uint8(120 + x%20)
+	}, 255)
+	stretched = stretchLevels(outliers)
+	if got := imaging.Clone(stretched).NRGBAAt(20, 0).R; got != 0 {
+		t.Errorf("got %v for the darkest shade besides the outlier, want 0", got)
+	}
+}
+
+func TestEqualizeAdaptive(t *testing.T) {
+	// Every tile holds the same narrow band of shades, between 120 and 129
+	lowContrast := testGrayImage(64, 64, func(x, y int) uint8 { return uint8(120 + (x*7+y*3)%10) }, 200)
+
+	equalized := equalizeAdaptive(lowContrast)
+
+	inputLow, inputHigh, _ := luminanceRange(lowContrast, 200)
+	low, high, alphaKept := luminanceRange(equalized, 200)
+	if int(high)-int(low) < 2*(int(inputHigh)-int(inputLow)) {
+		t.Errorf("got shades between %v and %v from between %v and %v, want the range to at least double", low, high, inputLow, inputHigh)
+	}
+	if !alphaKept {
+		t.Error("alpha was changed")
+	}
+
+	// Colors are scaled along with their luminance, keeping their hue
+	colored := imaging.Clone(lowContrast)
+	for i := 0; i < len(colored.Pix); i += 4 {
+		colored.Pix[i+2] /= 2
+	}
+	for i, pix := 0, imaging.Clone(equalizeAdaptive(colored)).Pix; i < len(pix); i += 4 {
+		if pix[i] != pix[i+1] || pix[i+2] > pix[i] {
+			t.Fatalf("got color %v, want red and green equal and blue no brighter", pix[i:i+3])
+		}
+	}
+}
+
+func TestEqualizeAdaptiveKeepsFlatShades(t *testing.T) {
+	// Tiles of fewer pixels clip their histograms below a whole pixel per value
+	for _, size := range []int{2, 16, 64, 256} {
+		for _, shade := range []uint8{0, 30, 90, 200, 255} {
+			flat := testGrayImage(size, size, func(x, y int) uint8 { return shade }, 255)
+
+			low, high, _ := luminanceRange(equalizeAdaptive(flat), 255)
+			if low != high || int(low) < int(shade)-8 || int(low) > int(shade)+8 {
+				t.Errorf("%vx%v image of shade %v became shades between %v and %v", size, size, shade, low, high)
+			}
+		}
+	}
+}
+
+func TestAdjustmentsOfEdgeCases(t *testing.T) {
+	flat := func(x, y int) uint8 { return 90 }
+	gradient := func(x, y int) uint8 { return uint8(x*40 + y*7) }
+
+	tests := []struct {
+		width, height int
+		shade         func(x, y int) uint8
+		name          string
+	}{
+		{0, 0, flat, "empty"},
+		{1, 1, flat, "single pixel"},
+		{3, 2, gradient, "smaller than the tile grid"},
+		{1, 100, gradient, "single column"},
+		{100, 1, gradient, "single row"},
+		{9, 9, gradient, "one pixel past the tile grid"},
+		{64, 64, flat, "flat"},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%v %vx%v", test.name, test.width, test.height), func(t *testing.T) {
+			img := testGrayImage(test.width, test.height, test.shade, 255)
+			inputLow, inputHigh, _ := luminanceRange(img, 255)
+
+			for name, adjust := range map[string]func(image.Image) image.Image{"stretchLevels": stretchLevels, "equalizeAdaptive": equalizeAdaptive} {
+				adjusted := adjust(img)
+				if adjusted.Bounds().Size() != img.Bounds().Size() {
+					t.Errorf("%v() returned a %v image, want %v", name, adjusted.Bounds().Size(), img.Bounds().Size())
+				}
+
+				// Flat images stay flat
+				if low, high, _ := luminanceRange(adjusted, 255); inputLow == inputHigh && low != high {
+					t.Errorf("%v() turned a flat image into shades between %v and %v", name, low, high)
+				}
+			}
+		})
+	}
+}
+
+func TestAdjustImageWithoutAdjustments(t *testing.T) {
+	img := testGrayImage(4, 4, func(x, y int) uint8 { return uint8(x * 60) }, 255)
+
+	for _, gamma := range []float64{0, 1} {
+		if adjusted := AdjustImage(img, 0, 0, gamma, false, false); adjusted != image.Image(img) {
+			t.Errorf("got a new image for gamma %v without any adjustment", gamma)
+		}
+	}
+}
@@ -25,23 +25,50 @@ type AsciiPixel struct {
 	charDepth      uint32
 	grayscaleValue [3]uint32
 	rgbValue       [3]uint32
+	alpha          uint32
 }
 
 /*
-This function shrinks the passed image according to specified or default dimensions.
+This function shrinks the passed image according to specified or default dimensions, using the passed
+resampling filter (Lanczos if empty). When only a width or height is given, the other is computed from
+the image's aspect ratio and cellAspect, the width to height ratio of a character cell (DefaultCellAspect
+if not positive). If both width and height are given, fitMode decides how the image is fit inside them.
 Stores each pixel's grayscale and RGB values in an AsciiPixel instance to simplify
 getting numeric data for ASCII character comparison.
 
 The returned 2D AsciiPixel slice contains each corresponding pixel's values
 */
-func ConvertToAsciiPixels(img image.Image, dimensions []int, width, height int, flipX, flipY, isBraille, dither bool) ([][]AsciiPixel, error) {
+func ConvertToAsciiPixels(img image.Image, dimensions []int, width, height int, flipX, flipY, isBraille, dither bool, filter ResampleFilter, cellAspect float64, fitMode FitMode) ([][]AsciiPixel, error) {
 
-	smallImg, err := resizeImage(img, isBraille, dimensions, width, height)
+	var imgSet [][]AsciiPixel
 
+	err := StreamAsciiPixels(img, dimensions, width, height, flipX, flipY, isBraille, dither, filter, cellAspect, fitMode, func(rows [][]AsciiPixel) error {
+		imgSet = append(imgSet, rows...)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
+	return imgSet, nil
+}
+
+/*
+Streaming counterpart of ConvertToAsciiPixels(). Rather than building the whole 2D slice, the resized
+image is sampled a few rows at a time and each group of rows is passed to emit, in display order.
+
+Each group holds the rows needed for one row of characters, which is 1 for ascii and 4 for braille.
+Groups can be passed as is to ConvertToAsciiChars() or ConvertToBrailleChars(). If emit returns an
+error, streaming stops and the error is returned.
+*/
+func StreamAsciiPixels(img image.Image, dimensions []int, width, height int, flipX, flipY, isBraille, dither bool, filter ResampleFilter, cellAspect float64, fitMode FitMode, emit func(rows [][]AsciiPixel) error) error {
+
+	smallImg, err := resizeImage(img, isBraille, dimensions, width, height, filter, cellAspect, fitMode)
+
+	if err != nil {
+		return err
+	}
+
 	// We mainatin a dithered image literal along with original image
 	// The colors are kept from original image
 	var ditheredImage image.Image
@@ -50,58 +77,84 @@ func ConvertToAsciiPixels(img image.Image, dimensions []int, width, height int,
 		ditheredImage = ditherImage(smallImg)
 	}
 
-	var imgSet [][]AsciiPixel
+	rowsPerChar := 1
+	if isBraille {
+		rowsPerChar = 4
+	}
 
 	b := smallImg.Bounds()
+	rows := make([][]AsciiPixel, 0, rowsPerChar)
 
-	// These nested loops iterate through each pixel of resized image and get an AsciiPixel instance
-	for y := b.Min.Y; y < b.Max.Y; y++ {
+	for i := 0; i < b.Dy(); i++ {
 
-		var temp []AsciiPixel
-		for x := b.Min.X; x < b.Max.X; x++ {
+		// Rows are read bottom to top when flipping vertically
+		y := b.Min.Y + i
+		if flipY {
+			y = b.Max.Y - 1 - i
+		}
 
-			oldPixel := smallImg.At(x, y)
-			grayPixel := color.GrayModel.Convert(oldPixel)
+		rows = append(rows, asciiPixelRow(smallImg, ditheredImage, y, flipX, isBraille && dither))
 
-			r1, g1, b1, _ := grayPixel.RGBA()
-			charDepth := r1 / 257 // Only Red is needed from RGB for charDepth in AsciiPixel since they have the same value for grayscale images
-			r1 = uint32(r1 / 257)
-			g1 = uint32(g1 / 257)
-			b1 = uint32(b1 / 257)
+		if len(rows) == rowsPerChar || i == b.Dy()-1 {
+			if err := emit(rows); err != nil {
+				return err
+			}
+			rows = make([][]AsciiPixel, 0, rowsPerChar)
+		}
+	}
 
-			if isBraille && dither {
+	return nil
+}
 
-				// Change charDepth if image dithering is applied
-				// 		Note that neither grayscale nor original color values are changed.
-				// 		Only charDepth is kept from dithered image. This is because a
-				// 		dithered image loses its colors so it's only used to check braille
-				// 		dots' visibility
+// Gets an AsciiPixel instance for each pixel in row y of the resized image
+func asciiPixelRow(smallImg, ditheredImage image.Image, y int, flipX, dithered bool) []AsciiPixel {
 
-				ditheredGrayPixel := color.GrayModel.Convert(ditheredImage.At(x, y))
-				charDepth, _, _, _ = ditheredGrayPixel.RGBA()
-				charDepth = charDepth / 257
-			}
+	b := smallImg.Bounds()
+	row := make([]AsciiPixel, 0, b.Dx())
 
-			// Get co1ored RGB values of original pixel for rgbValue in AsciiPixel
-			r2, g2, b2, _ := oldPixel.RGBA()
-			r2 = uint32(r2 / 257)
-			g2 = uint32(g2 / 257)
-			b2 = uint32(b2 / 257)
+	for i := 0; i < b.Dx(); i++ {
 
-			temp = append(temp, AsciiPixel{
-				charDepth:      charDepth,
-				grayscaleValue: [3]uint32{r1, g1, b1},
-				rgbValue:       [3]uint32{r2, g2, b2},
-			})
+		x := b.Min.X + i
+		if flipX {
+			x = b.Max.X - 1 - i
+		}
+
+		oldPixel := smallImg.At(x, y)
+		grayPixel := color.GrayModel.Convert(oldPixel)
+
+		r1, g1, b1, _ := grayPixel.RGBA()
+		charDepth := r1 / 257 // Only Red is needed from RGB for charDepth in AsciiPixel since they have the same value for grayscale images
+		r1 = uint32(r1 / 257)
+		g1 = uint32(g1 / 257)
+		b1 = uint32(b1 / 257)
+
+		if dithered {
 
+			// Change charDepth if image dithering is applied
+			// 		Note that neither grayscale nor original color values are changed.
+			// 		Only charDepth is kept from dithered image. This is because a
+			// 		dithered image loses its colors so it's only used to check braille
+			// 		dots' visibility
+
+			ditheredGrayPixel := color.GrayModel.Convert(ditheredImage.At(x, y))
+			charDepth, _, _, _ = ditheredGrayPixel.RGBA()
+			charDepth = charDepth / 257
 		}
-		imgSet = append(imgSet, temp)
-	}
 
-	// This rarely affects performance since the ascii art 2D slice size isn't that large
-	if flipX || flipY {
-		imgSet = reverse(imgSet, flipX, flipY)
+		// Get co1ored RGB values of original pixel for rgbValue in AsciiPixel
+		r2, g2, b2, a2 := oldPixel.RGBA()
+		r2 = uint32(r2 / 257)
+		g2 = uint32(g2 / 257)
+		b2 = uint32(b2 / 257)
+		a2 = uint32(a2 / 257)
+
+		row = append(row, AsciiPixel{
+			charDepth:      charDepth,
+			grayscaleValue: [3]uint32{r1, g1, b1},
+			rgbValue:       [3]uint32{r2, g2, b2},
+			alpha:          a2,
+		})
 	}
 
-	return imgSet, nil
+	return row
 }
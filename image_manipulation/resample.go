@@ -0,0 +1,169 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image_conversions
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/disintegration/imaging"
+)
+
+// Filter used to shrink the image down to one pixel per character (or braille dot)
+type ResampleFilter string
+
+const (
+	NearestNeighbor   ResampleFilter = "nearest"
+	Box               ResampleFilter = "box"
+	Linear            ResampleFilter = "linear"
+	Hermite           ResampleFilter = "hermite"
+	MitchellNetravali ResampleFilter = "mitchell"
+	CatmullRom        ResampleFilter = "catmullrom"
+	BSpline           ResampleFilter = "bspline"
+	Gaussian          ResampleFilter = "gaussian"
+	Bartlett          ResampleFilter = "bartlett"
+	Lanczos           ResampleFilter = "lanczos"
+	Hann              ResampleFilter = "hann"
+	Hamming           ResampleFilter = "hamming"
+	Blackman          ResampleFilter = "blackman"
+	Welch             ResampleFilter = "welch"
+	Cosine            ResampleFilter = "cosine"
+
+	// Not an interpolation filter. Each cell is the exact average of the source
+	// pixels it covers, which is fast on huge inputs and doesn't ring on pixel art
+	CellMean ResampleFilter = "cell-mean"
+)
+
+var imagingFilters = map[ResampleFilter]imaging.ResampleFilter{
+	NearestNeighbor:   imaging.NearestNeighbor,
+	Box:               imaging.Box,
+	Linear:            imaging.Linear,
+	Hermite:           imaging.Hermite,
+	MitchellNetravali: imaging.MitchellNetravali,
+	CatmullRom:        imaging.CatmullRom,
+	BSpline:           imaging.BSpline,
+	Gaussian:          imaging.Gaussian,
+	Bartlett:          imaging.Bartlett,
+	Lanczos:           imaging.Lanczos,
+	Hann:              imaging.Hann,
+	Hamming:           imaging.Hamming,
+	Blackman:          imaging.Blackman,
+	Welch:             imaging.Welch,
+	Cosine:            imaging.Cosine,
+}
+
+// Returns every supported resampling filter, starting with the default
+func ResampleFilters() []ResampleFilter {
+	return []ResampleFilter{
+		Lanczos,
+		NearestNeighbor,
+		Box,
+		Linear,
+		Hermite,
+		MitchellNetravali,
+		CatmullRom,
+		BSpline,
+		Gaussian,
+		Bartlett,
+		Hann,
+		Hamming,
+		Blackman,
+		Welch,
+		Cosine,
+		CellMean,
+	}
+}
+
+// Resizes the image to the passed width and height with the chosen filter. An empty filter uses Lanczos
+func resample(img image.Image, width, height int, filter ResampleFilter) (image.Image, error) {
+
+	if filter == "" {
+		filter = Lanczos
+	}
+
+	if filter == CellMean {
+		return cellMean(img, width, height), nil
+	}
+
+	imagingFilter, ok := imagingFilters[filter]
+	if !ok {
+		return nil, fmt.Errorf("unknown resample filter %q", filter)
+	}
+
+	return imaging.Resize(img, width, height, imagingFilter), nil
+}
+
+/*
+Area-average downscaling. Every destination pixel is the mean of the block of source
+pixels that falls inside it, with colors weighted by alpha so transparent pixels don't
+bleed into their neighbours. When enlarging, blocks are a single source pixel.
+*/
+func cellMean(img image.Image, width, height int) *image.NRGBA {
+
+	src := imaging.Clone(img)
+	srcWidth := src.Rect.Dx()
+	srcHeight := src.Rect.Dy()
+
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+
+	if srcWidth == 0 || srcHeight == 0 {
+		return dst
+	}
+
+	// Column boundaries are shared by every row of cells
+	xBounds := make([]int, width+1)
+	for x := range xBounds {
+		xBounds[x] = x * srcWidth / width
+	}
+
+	for y := 0; y < height; y++ {
+		y0 := y * srcHeight / height
+		y1 := max((y+1)*srcHeight/height, y0+1)
+
+		for x := 0; x < width; x++ {
+			x0 := xBounds[x]
+			x1 := max(xBounds[x+1], x0+1)
+
+			var r, g, b, a, count uint64
+
+			for sy := y0; sy < y1; sy++ {
+				i := sy*src.Stride + x0*4
+
+				for sx := x0; sx < x1; sx++ {
+					alpha := uint64(src.Pix[i+3])
+					r += uint64(src.Pix[i]) * alpha
+					g += uint64(src.Pix[i+1]) * alpha
+					b += uint64(src.Pix[i+2]) * alpha
+					a += alpha
+					count++
+					i += 4
+				}
+			}
+
+			j := y*dst.Stride + x*4
+
+			if a != 0 {
+				dst.Pix[j] = uint8(r / a)
+				dst.Pix[j+1] = uint8(g / a)
+				dst.Pix[j+2] = uint8(b / a)
+			}
+			dst.Pix[j+3] = uint8(a / count)
+		}
+	}
+
+	return dst
+}
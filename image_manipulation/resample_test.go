@@ -0,0 +1,50 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image_conversions
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// Shrinks a 12 megapixel photo-sized image to a terminal's worth of characters with each filter,
+// comparing them on the huge inputs CellMean is meant for:
+//
+//	go test -run '^$' -bench Resample ./image_manipulation
+func BenchmarkResample(b *testing.B) {
+	const width, height = 4000, 3000
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{uint8(x), uint8(y), uint8(x ^ y), 255})
+		}
+	}
+
+	for _, filter := range ResampleFilters() {
+		b.Run(string(filter), func(b *testing.B) {
+			b.SetBytes(int64(len(img.Pix)))
+
+			for b.Loop() {
+				if _, err := resample(img, 160, 60, filter); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image_conversions
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/disintegration/imaging"
+)
+
+/*
+Crops the passed image to the region given as x, y, width and height. If inPercent is true, the
+values are percentages (0-100) of the image's width and height. Otherwise, they're in pixels.
+
+Regions extending past the image are clipped to its bounds. A nil crop returns the image as is.
+*/
+func CropImage(img image.Image, crop []float64, inPercent bool) (image.Image, error) {
+
+	if crop == nil {
+		return img, nil
+	}
+
+	if len(crop) != 4 {
+		return nil, fmt.Errorf("crop requires 4 values (x, y, width, height), got %v", len(crop))
+	}
+
+	x, y, w, h := crop[0], crop[1], crop[2], crop[3]
+
+	if x < 0 || y < 0 || w <= 0 || h <= 0 {
+		return nil, fmt.Errorf("invalid values for crop region")
+	}
+
+	bounds := img.Bounds()
+
+	if inPercent {
+		if x+w > 100 || y+h > 100 {
+			return nil, fmt.Errorf("crop region percentages can't exceed 100")
+		}
+
+		imgWidth := float64(bounds.Dx())
+		imgHeight := float64(bounds.Dy())
+
+		x, w = x*imgWidth/100, w*imgWidth/100
+		y, h = y*imgHeight/100, h*imgHeight/100
+	}
+
+	x0 := bounds.Min.X + int(math.Round(x))
+	y0 := bounds.Min.Y + int(math.Round(y))
+	region := image.Rect(x0, y0, x0+max(int(math.Round(w)), 1), y0+max(int(math.Round(h)), 1))
+
+	if region.Intersect(bounds).Empty() {
+		return nil, fmt.Errorf("crop region lies outside of the image")
+	}
+
+	return imaging.Crop(img, region), nil
+}
+
+/*
+Rotates the passed image clockwise by the given angle in degrees. Multiples of 90 degrees are
+rotated losslessly. For arbitrary angles, the image is enlarged to fit the rotated result and
+the uncovered corners are filled with the background RGB color.
+*/
+func RotateImage(img image.Image, angle float64, background [3]int) image.Image {
+
+	angle = math.Mod(angle, 360)
+	if angle < 0 {
+		angle += 360
+	}
+
+	// imaging rotates counter-clockwise
+	switch angle {
+	case 0:
+		return img
+	case 90:
+		return imaging.Rotate270(img)
+	case 180:
+		return imaging.Rotate180(img)
+	case 270:
+		return imaging.Rotate90(img)
+	}
+
+	fill := color.NRGBA{uint8(background[0]), uint8(background[1]), uint8(background[2]), 255}
+
+	return imaging.Rotate(img, 360-angle, fill)
+}
+
+/*
+Composites the passed image over an opaque RGB background color, so transparent and
+translucent areas take on the background instead of being read as black
+*/
+func CompositeOnto(img image.Image, background [3]int) image.Image {
+
+	fill := color.NRGBA{uint8(background[0]), uint8(background[1]), uint8(background[2]), 255}
+	canvas := imaging.New(img.Bounds().Dx(), img.Bounds().Dy(), fill)
+
+	return imaging.Overlay(canvas, img, image.Point{}, 1)
+}
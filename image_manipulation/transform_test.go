@@ -0,0 +1,249 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image_conversions
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// Returns a width x height image in which every pixel's color encodes its position
+func testPositionImage(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetNRGBA(x, y, positionColor(x, y))
+		}
+	}
+	return img
+}
+
+func positionColor(x, y int) color.NRGBA {
+	return color.NRGBA{uint8(x * 20), uint8(y * 20), 0, 255}
+}
+
+// Checks that img is width x height and that the pixel at each position came from source(x, y)
+func checkPositions(t *testing.T, img image.Image, width, height int, source func(x, y int) (int, int)) {
+	t.Helper()
+
+	if size := img.Bounds().Size(); size.X != width || size.Y != height {
+		t.Fatalf("got a %vx%v image, want %vx%v", size.X, size.Y, width, height)
+	}
+
+	origin := img.Bounds().Min
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			got := color.NRGBAModel.Convert(img.At(origin.X+x, origin.Y+y))
+			if want := positionColor(source(x, y)); got != want {
+				t.Fatalf("pixel (%v, %v) is %v, want %v", x, y, got, want)
+			}
+		}
+	}
+}
+
+func TestCropImage(t *testing.T) {
+	img := testPositionImage(10, 8)
+
+	tests := []struct {
+		name          string
+		crop          []float64
+		inPercent     bool
+		width, height int
+		x, y          int
+	}{
+		{"pixels", []float64{2, 3, 4, 2}, false, 4, 2, 2, 3},
+		{"whole image", []float64{0, 0, 10, 8}, false, 10, 8, 0, 0},
+		{"clipped past the right and bottom edges", []float64{6, 5, 100, 100}, false, 4, 3, 6, 5},
+		{"fractional pixels rounded", []float64{1.4, 1.6, 2.5, 0.6}, false, 3, 1, 1, 2},
+		{"less than a pixel kept as one", []float64{3, 3, 0.1, 0.1}, false, 1, 1, 3, 3},
+		{"percent", []float64{50, 25, 50, 50}, true, 5, 4, 5, 2},
+		{"whole image in percent", []float64{0, 0, 100, 100}, true, 10, 8, 0, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cropped, err := CropImage(img, test.crop, test.inPercent)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			checkPositions(t, cropped, test.width, test.height, func(x, y int) (int, int) {
+				return test.x + x, test.y + y
+			})
+		})
+	}
+
+	t.Run("relative to the image's origin", func(t *testing.T) {
+		sub := img.SubImage(image.Rect(4, 2, 10, 8))
+
+		cropped, err := CropImage(sub, []float64{1, 1, 2, 2}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkPositions(t, cropped, 2, 2, func(x, y int) (int, int) { return 5 + x, 3 + y })
+	})
+
+	t.Run("nil crop", func(t *testing.T) {
+		if cropped, err := CropImage(img, nil, false); err != nil || cropped != image.Image(img) {
+			t.Errorf("got %v, %v, want the image as is", cropped, err)
+		}
+	})
+}
+
+func TestCropImageErrors(t *testing.T) {
+	img := testPositionImage(10, 8)
+
+	tests := []struct {
+		name      string
+		crop      []float64
+		inPercent bool
+	}{
+		{"outside the image", []float64{10, 0, 5, 5}, false},
+		{"far outside the image", []float64{100, 100, 5, 5}, false},
+		{"below the image", []float64{0, 8, 5, 5}, false},
+		{"3 values", []float64{0, 0, 5}, false},
+		{"negative offset", []float64{-1, 0, 5, 5}, false},
+		{"width of 0", []float64{0, 0, 0, 5}, false},
+		{"percentages past 100", []float64{60, 0, 50, 50}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if cropped, err := CropImage(img, test.crop, test.inPercent); err == nil {
+				t.Errorf("got a %v image, want an error", cropped.Bounds().Size())
+			}
+		})
+	}
+}
+
+func TestRotateImage(t *testing.T) {
+	const width, height = 3, 2
+	img := testPositionImage(width, height)
+
+	// Where each pixel of the rotated image comes from, for clockwise rotations
+	upright := func(x, y int) (int, int) { return x, y }
+	clockwise := func(x, y int) (int, int) { return y, height - 1 - x }
+	upsideDown := func(x, y int) (int, int) { return width - 1 - x, height - 1 - y }
+	counterClockwise := func(x, y int) (int, int) { return width - 1 - y, x }
+
+	tests := []struct {
+		angle  float64
+		source func(x, y int) (int, int)
+	}{
+		{0, upright},
+		{360, upright},
+		{-720, upright},
+		{90, clockwise},
+		{450, clockwise},
+		{-270, clockwise},
+		{180, upsideDown},
+		{-180, upsideDown},
+		{270, counterClockwise},
+		{-90, counterClockwise},
+		{-450, counterClockwise},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprint(test.angle), func(t *testing.T) {
+			rotated := RotateImage(img, test.angle, [3]int{})
+
+			rotatedWidth, rotatedHeight := width, height
+			if int(test.angle)%180 != 0 {
+				rotatedWidth, rotatedHeight = height, width
+			}
+			checkPositions(t, rotated, rotatedWidth, rotatedHeight, test.source)
+		})
+	}
+}
+
+func TestRotateImageByArbitraryAngle(t *testing.T) {
+	img := testPositionImage(20, 10)
+	background := [3]int{0, 0, 255}
+
+	rotated := RotateImage(img, 45, background)
+
+	// The rotated image is enlarged to fit, leaving corners of the background color
+	size := rotated.Bounds().Size()
+	if size.X <= 20 || size.Y <= 10 {
+		t.Fatalf("got a %vx%v image, want one enlarged to fit the rotated 20x10 image", size.X, size.Y)
+	}
+
+	want := color.NRGBA{0, 0, 255, 255}
+	for _, corner := range []image.Point{{0, 0}, {size.X - 1, 0}, {0, size.Y - 1}, {size.X - 1, size.Y - 1}} {
+		point := rotated.Bounds().Min.Add(corner)
+		if got := color.NRGBAModel.Convert(rotated.At(point.X, point.Y)); got != want {
+			t.Errorf("corner %v is %v, want the background %v", corner, got, want)
+		}
+	}
+}
+
+func TestCompositeOnto(t *testing.T) {
+	// A row of opaque red, fully transparent, half transparent white and half transparent red pixels
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 1))
+	img.SetNRGBA(0, 0, color.NRGBA{255, 0, 0, 255})
+	img.SetNRGBA(1, 0, color.NRGBA{12, 34, 56, 0})
+	img.SetNRGBA(2, 0, color.NRGBA{255, 255, 255, 128})
+	img.SetNRGBA(3, 0, color.NRGBA{255, 0, 0, 128})
+
+	tests := []struct {
+		name       string
+		img        image.Image
+		background [3]int
+		want       []color.NRGBA
+	}{
+		{"black background", img, [3]int{0, 0, 0}, []color.NRGBA{
+			{255, 0, 0, 255}, {0, 0, 0, 255}, {128, 128, 128, 255}, {128, 0, 0, 255},
+		}},
+		{"white background", img, [3]int{255, 255, 255}, []color.NRGBA{
+			{255, 0, 0, 255}, {255, 255, 255, 255}, {255, 255, 255, 255}, {255, 127, 127, 255},
+		}},
+		{"colored background", img, [3]int{0, 0, 200}, []color.NRGBA{
+			{255, 0, 0, 255}, {0, 0, 200, 255}, {128, 128, 227, 255}, {128, 0, 100, 255},
+		}},
+		{"image not at the origin", img.SubImage(image.Rect(1, 0, 3, 1)), [3]int{0, 0, 0}, []color.NRGBA{
+			{0, 0, 0, 255}, {128, 128, 128, 255},
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			composited := CompositeOnto(test.img, test.background)
+
+			if size := composited.Bounds().Size(); size.X != len(test.want) || size.Y != 1 {
+				t.Fatalf("got a %vx%v image, want %vx1", size.X, size.Y, len(test.want))
+			}
+
+			origin := composited.Bounds().Min
+			for x, want := range test.want {
+				got := color.NRGBAModel.Convert(composited.At(origin.X+x, origin.Y)).(color.NRGBA)
+				if !closeColors(got, want) {
+					t.Errorf("pixel %v is %v, want %v", x, got, want)
+				}
+			}
+		})
+	}
+}
+
+// Reports whether colors differ by at most 1 in each channel, allowing for rounding
+func closeColors(a, b color.NRGBA) bool {
+	near := func(a, b uint8) bool {
+		return a-b <= 1 || b-a <= 1
+	}
+	return near(a.R, b.R) && near(a.G, b.G) && near(a.B, b.B) && near(a.A, b.A)
+}
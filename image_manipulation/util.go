@@ -19,7 +19,6 @@ package image_conversions
 import (
 	"fmt"
 	"image"
-	"errors"
 	"image/color"
 
 	"github.com/disintegration/imaging"
@@ -40,15 +39,38 @@ func ditherImage(img image.Image) image.Image {
 	return d.DitherCopy(img)
 }
 
-func resizeImage(img image.Image, isBraille bool, dimensions []int, width, height int) (image.Image, error) {
+// DefaultCellAspect is the width to height ratio of a character cell in most terminal fonts
+const DefaultCellAspect = 0.5
+
+// How the ascii art is sized when both a width and a height are passed
+type FitMode string
+
+const (
+	// Both width and height can't be set, dimensions must be used instead
+	FitNone FitMode = ""
+
+	// Width and height form a bounding box. The art is scaled to the largest size
+	// that fits inside it while keeping the image's aspect ratio
+	FitWithin FitMode = "fit"
+
+	// The art covers the whole width and height. The image keeps its aspect
+	// ratio and is cropped around its center to fill the box
+	FitFill FitMode = "fill"
+)
+
+func resizeImage(img image.Image, isBraille bool, dimensions []int, width, height int, filter ResampleFilter, cellAspect float64, fitMode FitMode) (image.Image, error) {
 
 	var asciiWidth, asciiHeight int
-	var smallImg image.Image
 
 	imgWidth := float64(img.Bounds().Dx())
 	imgHeight := float64(img.Bounds().Dy())
 	aspectRatio := imgWidth / imgHeight
 
+	// Characters are taller than they're wide, so the image is squashed vertically to compensate
+	if cellAspect <= 0 {
+		cellAspect = DefaultCellAspect
+	}
+
 	if (width != 0 || height != 0) && len(dimensions) == 0 {
 		// If either width or height is set and dimensions aren't given
 
@@ -56,30 +78,47 @@ func resizeImage(img image.Image, isBraille bool, dimensions []int, width, heigh
 			// If width is set and height is not set, use width to calculate aspect ratio
 
 			asciiWidth = width
-			asciiHeight = int(float64(asciiWidth) / aspectRatio)
-			asciiHeight = int(0.5 * float64(asciiHeight))
-
-			if asciiHeight == 0 {
-				asciiHeight = 1
-			}
+			asciiHeight = heightFromWidth(asciiWidth, aspectRatio, cellAspect)
 
 		} else if height != 0 && width == 0 {
 			// If height is set and width is not set, use height to calculate aspect ratio
 
 			asciiHeight = height
-			asciiWidth = int(float64(asciiHeight) * aspectRatio)
-			asciiWidth = int(2 * float64(asciiWidth))
+			asciiWidth = widthFromHeight(asciiHeight, aspectRatio, cellAspect)
 
-			if asciiWidth == 0 {
-				asciiWidth = 1
+		} else if fitMode == FitWithin {
+			// Try filling the box's width first, and fall back to its height if the art would be too tall
+
+			asciiWidth = width
+			asciiHeight = heightFromWidth(asciiWidth, aspectRatio, cellAspect)
+
+			if asciiHeight > height {
+				asciiHeight = height
+				asciiWidth = min(widthFromHeight(asciiHeight, aspectRatio, cellAspect), width)
+			}
+
+		} else if fitMode == FitFill {
+			// Crop the image to the box's on-screen aspect ratio, then stretch it over the whole box
+
+			asciiWidth = width
+			asciiHeight = height
+
+			boxRatio := float64(width) * cellAspect / float64(height)
+
+			if aspectRatio > boxRatio {
+				img = imaging.CropCenter(img, max(int(imgHeight*boxRatio), 1), int(imgHeight))
+			} else {
+				img = imaging.CropCenter(img, int(imgWidth), max(int(imgWidth/boxRatio), 1))
 			}
 
 		} else {
-			return nil, fmt.Errorf("error: both width and height can't be set. Use dimensions instead")
+			return nil, fmt.Errorf("%w: both width and height can't be set. Use dimensions or a fit mode instead", ErrInvalidDimensions)
 		}
 
 	} else if len(dimensions) == 0 {
-		return nil, errors.New("Either dimensions, width, or height must be passed.")
+		return nil, fmt.Errorf("%w: either dimensions, width, or height must be passed", ErrInvalidDimensions)
+	} else if len(dimensions) != 2 {
+		return nil, fmt.Errorf("%w: dimensions require 2 values (width, height), got %v", ErrInvalidDimensions, len(dimensions))
 	} else {
 		// Else, set passed dimensions
 
@@ -87,33 +126,30 @@ func resizeImage(img image.Image, isBraille bool, dimensions []int, width, heigh
 		asciiHeight = dimensions[1]
 	}
 
+	if asciiWidth <= 0 || asciiHeight <= 0 {
+		return nil, fmt.Errorf("%w: ascii art of %vx%v characters is empty", ErrInvalidDimensions, asciiWidth, asciiHeight)
+	}
+
 	// Because one braille character has 8 dots (4 rows and 2 columns)
 	if isBraille {
 		asciiWidth *= 2
 		asciiHeight *= 4
 	}
-	smallImg = imaging.Resize(img, asciiWidth, asciiHeight, imaging.Lanczos)
-
-	return smallImg, nil
+	return resample(img, asciiWidth, asciiHeight, filter)
 }
 
-func reverse(imgSet [][]AsciiPixel, flipX, flipY bool) [][]AsciiPixel {
+func heightFromWidth(width int, aspectRatio, cellAspect float64) int {
+	height := int(float64(width) / aspectRatio)
+	height = int(cellAspect * float64(height))
 
-	if flipX {
-		for _, row := range imgSet {
-			for i, j := 0, len(row)-1; i < j; i, j = i+1, j-1 {
-				row[i], row[j] = row[j], row[i]
-			}
-		}
-	}
+	return max(height, 1)
+}
 
-	if flipY {
-		for i, j := 0, len(imgSet)-1; i < j; i, j = i+1, j-1 {
-			imgSet[i], imgSet[j] = imgSet[j], imgSet[i]
-		}
-	}
+func widthFromHeight(height int, aspectRatio, cellAspect float64) int {
+	width := int(float64(height) * aspectRatio)
+	width = int(float64(width) / cellAspect)
 
-	return imgSet
+	return max(width, 1)
 }
 
 // This functions calculates terminal color level between rgb colors and 256-colors
@@ -132,6 +168,6 @@ func getColoredCharForTerm(r, g, b uint8, char string, background bool, colorLev
 		// after converting the RGB to C256, convert it back to RGB so we can have a 256 color normalized as an RGB color
 		return coloredChar, colorRenderer.RGB(), nil
 	default:
-		return "", gookitColor.RGBColor{}, fmt.Errorf("%d-bit color level is unsupported.", colorLevel)
+		return "", gookitColor.RGBColor{}, fmt.Errorf("%w: %d-bit", ErrUnsupportedColorLevel, colorLevel)
 	}
 }
@@ -1,3 +1,5 @@
+//go:build !(js && wasm)
+
 /*
 Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
 
@@ -0,0 +1,493 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package server serves conversions over HTTP, for services that would otherwise run the CLI.
+
+New() returns an http.Handler with two endpoints:
+
+	POST /convert   converts the image in the request body, as raw bytes or in a multipart form
+	GET  /healthz   reports that the server is up
+
+Options are passed as query parameters named after the fields of aic_package.Settings, e.g.
+?width=80&colored=true, or as settings JSON in the settings query parameter or multipart field. The
+output format is negotiated from the Accept header, or chosen with the output query parameter.
+
+The handler doesn't listen on its own, so it can be served by any http.Server, or tested with
+net/http/httptest without opening a socket.
+*/
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Ares1605/ascii-image-converter-wasm/aic_package"
+	"github.com/Ares1605/ascii-image-converter-wasm/image_formats"
+	gookitColor "github.com/gookit/color"
+)
+
+// Defaults used for zero fields of Config
+const (
+	DefaultMaxBodyBytes = 10 << 20
+	DefaultTimeout      = 30 * time.Second
+)
+
+// Width in characters of ascii art for requests that don't set a size, in place of the CLI's
+// terminal size
+const DefaultWidth = 80
+
+// Maximum size of settings JSON passed in a multipart form
+const maxSettingsBytes = 64 << 10
+
+// Config sets the limits of a server, and the flags every conversion starts from
+type Config struct {
+	// Flags each request's options are applied on top of, such as aic_package.DefaultFlags() with
+	// Flags.MaxPixels set. Input limits can't be changed by requests, and requests that end up without
+	// a size get ascii art DefaultWidth characters wide
+	Flags aic_package.Flags
+
+	// Maximum size of a request's body in bytes. Defaults to DefaultMaxBodyBytes. Images are limited
+	// to the smaller of this and Flags.MaxInputBytes, if that's set
+	MaxBodyBytes int64
+
+	// Maximum time a conversion can take, including waiting for a free slot. Defaults to DefaultTimeout
+	Timeout time.Duration
+
+	// Maximum number of conversions running at once. Further requests wait for a slot until they time
+	// out. Defaults to the number of CPUs
+	MaxConcurrent int
+}
+
+// An output format a request can ask for
+type output struct {
+	name        string
+	contentType string
+}
+
+// Output formats in order of preference, for Accept headers that allow several equally
+var outputs = []output{
+	{"text", "text/plain"},
+	{"json", "application/json"},
+	{"html", "text/html"},
+	{"svg", "image/svg+xml"},
+	{"png", "image/png"},
+}
+
+type server struct {
+	config Config
+
+	// Holds a value for each conversion running, up to Config.MaxConcurrent
+	slots chan struct{}
+}
+
+// New() returns a handler serving conversions with the passed config
+func New(config Config) http.Handler {
+	s := newServer(config)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /convert", s.convert)
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		io.WriteString(w, "ok\n")
+	})
+
+	return mux
+}
+
+// Returns a server with the zero fields of config set to their defaults
+func newServer(config Config) *server {
+	if config.MaxBodyBytes <= 0 {
+		config.MaxBodyBytes = DefaultMaxBodyBytes
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+	if config.MaxConcurrent <= 0 {
+		config.MaxConcurrent = runtime.NumCPU()
+	}
+
+	// There's no terminal to detect color support from, and clients decide how colors are displayed
+	gookitColor.ForceColor()
+
+	return &server{config: config, slots: make(chan struct{}, config.MaxConcurrent)}
+}
+
+func (s *server) convert(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Vary", "Accept")
+
+	out, ok := negotiate(r)
+	if !ok {
+		http.Error(w, "none of text/plain, application/json, text/html, image/svg+xml or image/png is acceptable", http.StatusNotAcceptable)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodyBytes)
+
+	inputBytes, settingsJSON, err := s.readRequest(r)
+	if maxBytesErr := (*http.MaxBytesError)(nil); errors.As(err, &maxBytesErr) {
+		err = fmt.Errorf("%w: request body exceeds the limit of %v bytes", aic_package.ErrInputTooLarge, maxBytesErr.Limit)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), statusOf(err))
+		return
+	}
+
+	flags, err := s.requestFlags(r, settingsJSON)
+	if err != nil {
+		http.Error(w, err.Error(), statusOf(err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.config.Timeout)
+	defer cancel()
+
+	select {
+	case s.slots <- struct{}{}:
+	case <-ctx.Done():
+		http.Error(w, "timed out waiting for a conversion slot", http.StatusServiceUnavailable)
+		return
+	}
+
+	type result struct {
+		body []byte
+		err  error
+	}
+	done := make(chan result, 1)
+
+	// Conversions can't be interrupted, so the slot is held until the conversion finishes, even if
+	// the request has timed out by then
+	go func() {
+		var converted result
+
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				converted.err = fmt.Errorf("conversion failed: %v", recovered)
+			}
+
+			<-s.slots
+			done <- converted
+		}()
+
+		converted.body, converted.err = render(inputBytes, flags, out.name)
+	}()
+
+	select {
+	case converted := <-done:
+		if converted.err != nil {
+			http.Error(w, converted.err.Error(), statusOf(converted.err))
+			return
+		}
+
+		contentType := out.contentType
+		if strings.HasPrefix(contentType, "text/") {
+			contentType += "; charset=utf-8"
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Write(converted.body)
+
+	case <-ctx.Done():
+		http.Error(w, "conversion timed out", http.StatusServiceUnavailable)
+	}
+}
+
+/*
+Reads the image from the request, either the raw body or the image field of a multipart form. The
+settings field of a multipart form is returned as well, if present
+*/
+func (s *server) readRequest(r *http.Request) (inputBytes, settingsJSON []byte, err error) {
+	maxInputBytes := s.config.MaxBodyBytes
+	if s.config.Flags.MaxInputBytes > 0 {
+		maxInputBytes = min(maxInputBytes, s.config.Flags.MaxInputBytes)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	if mediaType != "multipart/form-data" {
+		inputBytes, err = aic_package.ReadInput(r.Body, maxInputBytes)
+		return inputBytes, nil, err
+	}
+
+	parts, err := r.MultipartReader()
+	if err != nil {
+		return nil, nil, badRequest("invalid multipart form: %v", err)
+	}
+
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, nil, badRequest("invalid multipart form: %w", err)
+		}
+
+		switch part.FormName() {
+		case "image":
+			if inputBytes, err = aic_package.ReadInput(part, maxInputBytes); err != nil {
+				return nil, nil, err
+			}
+
+		case "settings":
+			settingsJSON, err = io.ReadAll(io.LimitReader(part, maxSettingsBytes+1))
+			if err != nil {
+				return nil, nil, badRequest("invalid multipart form: %w", err)
+			}
+			if len(settingsJSON) > maxSettingsBytes {
+				return nil, nil, badRequest("settings exceed the limit of %v bytes", maxSettingsBytes)
+			}
+		}
+	}
+
+	if inputBytes == nil {
+		return nil, nil, badRequest("multipart form has no image field")
+	}
+
+	return inputBytes, settingsJSON, nil
+}
+
+/*
+Applies the request's options to the configured flags: settings JSON from the multipart form or the
+settings query parameter first, then the other query parameters
+*/
+func (s *server) requestFlags(r *http.Request, settingsJSON []byte) (aic_package.Flags, error) {
+	flags := s.config.Flags
+	query := r.URL.Query()
+
+	if settingsJSON == nil && query.Has("settings") {
+		settingsJSON = []byte(query.Get("settings"))
+	}
+
+	if settingsJSON != nil {
+		settings, err := aic_package.ParseSettings(settingsJSON)
+		if err != nil {
+			return flags, err
+		}
+
+		if flags, err = settings.Apply(flags); err != nil {
+			return flags, err
+		}
+	}
+
+	settings, err := querySettings(query)
+	if err != nil {
+		return flags, err
+	}
+
+	if flags, err = settings.Apply(flags); err != nil {
+		return flags, err
+	}
+
+	// Requests can't raise the configured input limits
+	flags.MaxInputBytes = s.config.Flags.MaxInputBytes
+	flags.MaxPixels = s.config.Flags.MaxPixels
+
+	if flags.Dimensions == nil && flags.Width == 0 && flags.Height == 0 {
+		flags.Width = DefaultWidth
+	}
+
+	return flags, flags.Validate()
+}
+
+// Options passed as strings in query parameters. Other options are JSON values, where lists can be
+// passed without brackets, e.g. dimensions=80,40
+var stringOptions = map[string]bool{
+	"preset":         true,
+	"fitMode":        true,
+	"customMap":      true,
+	"resampleFilter": true,
+}
+
+// Parses query parameters named after the fields of aic_package.Settings into settings
+func querySettings(query map[string][]string) (aic_package.Settings, error) {
+	fields := map[string]json.RawMessage{
+		"version": json.RawMessage(strconv.Itoa(aic_package.SettingsVersion)),
+	}
+
+	for key, values := range query {
+		if key == "output" || key == "settings" {
+			continue
+		}
+		value := values[len(values)-1]
+
+		if stringOptions[key] {
+			quoted, err := json.Marshal(value)
+			if err != nil {
+				return aic_package.Settings{}, err
+			}
+			value = string(quoted)
+		} else if strings.Contains(value, ",") && !strings.HasPrefix(value, "[") {
+			value = "[" + value + "]"
+		}
+
+		if !json.Valid([]byte(value)) {
+			return aic_package.Settings{}, fmt.Errorf("%w: invalid value %q for query parameter %q", aic_package.ErrInvalidSettings, values[len(values)-1], key)
+		}
+		fields[key] = json.RawMessage(value)
+	}
+
+	settingsJSON, err := json.Marshal(fields)
+	if err != nil {
+		return aic_package.Settings{}, err
+	}
+
+	return aic_package.ParseSettings(settingsJSON)
+}
+
+// Converts the image into the named output format
+func render(inputBytes []byte, flags aic_package.Flags, output string) ([]byte, error) {
+
+	// Convert() would play animations on the server's terminal
+	if image_formats.IsAnimated(inputBytes) {
+		return nil, fmt.Errorf("%w by the server", aic_package.ErrAnimationUnsupported)
+	}
+
+	switch output {
+	case "json":
+		asciiArt, err := aic_package.ConvertJSON(inputBytes, flags)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(asciiArt)
+
+	case "html":
+		html, err := aic_package.ConvertHTML(inputBytes, flags)
+		return []byte(html), err
+
+	case "svg":
+		svg, err := aic_package.ConvertSVG(inputBytes, flags)
+		return []byte(svg), err
+
+	case "png":
+		return aic_package.ConvertPNG(inputBytes, flags)
+
+	default:
+		asciiArt, err := aic_package.Convert(inputBytes, flags)
+		return []byte(asciiArt + "\n"), err
+	}
+}
+
+/*
+Picks the output format from the output query parameter, or else from the Accept header, honoring
+quality values. Requests without an Accept header get text. ok is false if no format is acceptable
+*/
+func negotiate(r *http.Request) (out output, ok bool) {
+	if name := r.URL.Query().Get("output"); name != "" {
+		for _, out := range outputs {
+			if out.name == name {
+				return out, true
+			}
+		}
+		return output{}, false
+	}
+
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return outputs[0], true
+	}
+
+	best := -1.0
+	for _, candidate := range outputs {
+		if quality := acceptQuality(accept, candidate.contentType); quality > best && quality > 0 {
+			out, best = candidate, quality
+		}
+	}
+
+	return out, best > 0
+}
+
+// Returns the quality value an Accept header gives a content type, taking the most specific match
+func acceptQuality(accept, contentType string) float64 {
+	quality, specificity := 0.0, -1
+	mainType, _, _ := strings.Cut(contentType, "/")
+
+	for _, item := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(item))
+		if err != nil {
+			continue
+		}
+
+		matched := -1
+		switch mediaType {
+		case contentType:
+			matched = 2
+		case mainType + "/*":
+			matched = 1
+		case "*/*":
+			matched = 0
+		}
+		if matched <= specificity {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				q = 0
+			}
+		}
+
+		quality, specificity = q, matched
+	}
+
+	return quality
+}
+
+// An error caused by a malformed request
+type requestError struct {
+	err error
+}
+
+func (e *requestError) Error() string {
+	return e.err.Error()
+}
+
+func (e *requestError) Unwrap() error {
+	return e.err
+}
+
+func badRequest(format string, args ...any) error {
+	return &requestError{fmt.Errorf(format, args...)}
+}
+
+// Maps an error onto the HTTP status code it's reported with
+func statusOf(err error) int {
+	var (
+		decodeErr  *aic_package.DecodeError
+		flagErr    *aic_package.FlagError
+		requestErr *requestError
+	)
+
+	switch {
+	case errors.Is(err, aic_package.ErrInputTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, aic_package.ErrUnsupportedFormat), errors.Is(err, aic_package.ErrAnimationUnsupported), errors.As(err, &decodeErr):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, aic_package.ErrInvalidSettings), errors.Is(err, aic_package.ErrInvalidDimensions), errors.Is(err, aic_package.ErrUnsupportedColorLevel), errors.As(err, &flagErr), errors.As(err, &requestErr):
+		return http.StatusBadRequest
+	}
+
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,329 @@
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Ares1605/ascii-image-converter-wasm/aic_package"
+)
+
+// Encodes a 32x16 gradient as a PNG
+func testPNG(t *testing.T) []byte {
+	t.Helper()
+
+	img := image.NewNRGBA(image.Rect(0, 0, 32, 16))
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 32; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{uint8(x * 8), uint8(y * 16), 128, 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// Encodes a two frame GIF
+func testGIF(t *testing.T) []byte {
+	t.Helper()
+
+	palette := color.Palette{color.Black, color.White}
+	first := image.NewPaletted(image.Rect(0, 0, 4, 4), palette)
+	second := image.NewPaletted(image.Rect(0, 0, 4, 4), palette)
+	second.SetColorIndex(1, 1, 1)
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, &gif.GIF{Image: []*image.Paletted{first, second}, Delay: []int{10, 10}}); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// Encodes a multipart form with the passed fields, returning its body and content type
+func testForm(t *testing.T, fields map[string][]byte) (*bytes.Buffer, string) {
+	t.Helper()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+
+	for name, value := range fields {
+		part, err := form.CreateFormFile(name, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write(value)
+	}
+	if err := form.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return &body, form.FormDataContentType()
+}
+
+func post(handler http.Handler, target, contentType, accept string, body io.Reader) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPost, target, body)
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+	if accept != "" {
+		request.Header.Set("Accept", accept)
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+// Returns the lines of text output, without the trailing newline
+func lines(body string) []string {
+	return strings.Split(strings.TrimSuffix(body, "\n"), "\n")
+}
+
+func TestHealthz(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	New(Config{Flags: aic_package.DefaultFlags()}).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	if recorder.Code != http.StatusOK || recorder.Body.String() != "ok\n" {
+		t.Errorf("got %v %q, want 200 \"ok\\n\"", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestNegotiation(t *testing.T) {
+	handler := New(Config{Flags: aic_package.DefaultFlags()})
+	image := testPNG(t)
+
+	tests := []struct {
+		name        string
+		accept      string
+		query       string
+		status      int
+		contentType string
+		bodyPrefix  string
+	}{
+		{"no accept header", "", "", http.StatusOK, "text/plain; charset=utf-8", ""},
+		{"any type", "*/*", "", http.StatusOK, "text/plain; charset=utf-8", ""},
+		{"json", "application/json", "", http.StatusOK, "application/json", "[["},
+		{"html", "text/html", "", http.StatusOK, "text/html; charset=utf-8", "<pre"},
+		{"svg", "image/svg+xml", "", http.StatusOK, "image/svg+xml", "<svg"},
+		{"png", "image/png", "", http.StatusOK, "image/png", "\x89PNG"},
+		{"highest quality wins", "text/html;q=0.5, image/png", "", http.StatusOK, "image/png", "\x89PNG"},
+		{"wildcard subtype", "image/*", "", http.StatusOK, "image/svg+xml", "<svg"},
+		{"more specific match wins", "*/*;q=0.1, image/svg+xml;q=0", "", http.StatusOK, "text/plain; charset=utf-8", ""},
+		{"output parameter overrides accept", "image/png", "&output=json", http.StatusOK, "application/json", "[["},
+		{"unacceptable", "image/gif", "", http.StatusNotAcceptable, "", ""},
+		{"unknown output", "", "&output=bmp", http.StatusNotAcceptable, "", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := post(handler, "/convert?dimensions=8,4"+test.query, "", test.accept, bytes.NewReader(image))
+
+			if recorder.Code != test.status {
+				t.Fatalf("status %v, want %v: %s", recorder.Code, test.status, recorder.Body.String())
+			}
+			if recorder.Header().Get("Vary") != "Accept" {
+				t.Errorf("Vary header is %q, want Accept", recorder.Header().Get("Vary"))
+			}
+			if test.status != http.StatusOK {
+				return
+			}
+
+			if got := recorder.Header().Get("Content-Type"); got != test.contentType {
+				t.Errorf("content type %q, want %q", got, test.contentType)
+			}
+			if !strings.HasPrefix(recorder.Body.String(), test.bodyPrefix) {
+				t.Errorf("body starts with %q, want %q", recorder.Body.String()[:min(recorder.Body.Len(), 10)], test.bodyPrefix)
+			}
+		})
+	}
+}
+
+func TestRawBodyAndMultipart(t *testing.T) {
+	handler := New(Config{Flags: aic_package.DefaultFlags()})
+	image := testPNG(t)
+
+	settings := []byte(`{"version":1,"dimensions":[6,3]}`)
+
+	tests := []struct {
+		name    string
+		target  string
+		fields  map[string][]byte
+		columns int
+		rows    int
+	}{
+		{"raw body", "/convert?dimensions=8,4", nil, 8, 4},
+		{"raw body without a size", "/convert", nil, DefaultWidth, DefaultWidth / 4},
+		{"settings query parameter", `/convert?settings={"version":1,"width":10}`, nil, 10, 2},
+		{"multipart image", "/convert?dimensions=8,4", map[string][]byte{"image": image}, 8, 4},
+		{"multipart settings", "/convert", map[string][]byte{"image": image, "settings": settings}, 6, 3},
+		{"query overrides multipart settings", "/convert?dimensions=5,2", map[string][]byte{"image": image, "settings": settings}, 5, 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				body        io.Reader = bytes.NewReader(image)
+				contentType string
+			)
+			if test.fields != nil {
+				body, contentType = testForm(t, test.fields)
+			}
+
+			recorder := post(handler, strings.ReplaceAll(test.target, `"`, "%22"), contentType, "", body)
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("status %v: %s", recorder.Code, recorder.Body.String())
+			}
+
+			rows := lines(recorder.Body.String())
+			if len(rows) != test.rows || len(rows[0]) != test.columns {
+				t.Errorf("got %vx%v characters, want %vx%v", len(rows[0]), len(rows), test.columns, test.rows)
+			}
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	const maxBodyBytes = 4096
+
+	handler := New(Config{Flags: aic_package.DefaultFlags(), MaxBodyBytes: maxBodyBytes})
+	image := testPNG(t)
+
+	// Still a PNG as far as sniffing is concerned, but over the limit
+	oversized := append(append([]byte{}, image...), make([]byte, maxBodyBytes)...)
+	oversizedForm, oversizedFormType := testForm(t, map[string][]byte{"image": oversized})
+	noImageForm, noImageFormType := testForm(t, map[string][]byte{"settings": []byte(`{"version":1}`)})
+
+	tests := []struct {
+		name        string
+		target      string
+		contentType string
+		body        io.Reader
+		status      int
+	}{
+		{"unknown option", "/convert?widht=10", "", bytes.NewReader(image), http.StatusBadRequest},
+		{"invalid option value", "/convert?width=abc", "", bytes.NewReader(image), http.StatusBadRequest},
+		{"option out of range", "/convert?width=10&braille=true&threshold=999", "", bytes.NewReader(image), http.StatusBadRequest},
+		{"invalid settings", "/convert?settings=%7B%7D", "", bytes.NewReader(image), http.StatusBadRequest},
+		{"multipart without an image", "/convert", noImageFormType, noImageForm, http.StatusBadRequest},
+		{"body over the limit", "/convert?width=10", "", bytes.NewReader(oversized), http.StatusRequestEntityTooLarge},
+		{"multipart body over the limit", "/convert?width=10", oversizedFormType, oversizedForm, http.StatusRequestEntityTooLarge},
+		{"animated input", "/convert?width=10", "", bytes.NewReader(testGIF(t)), http.StatusUnsupportedMediaType},
+		{"undecodable input", "/convert?width=10", "", strings.NewReader("not an image"), http.StatusUnsupportedMediaType},
+		{"truncated input", "/convert?width=10", "", bytes.NewReader(image[:len(image)/2]), http.StatusUnsupportedMediaType},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := post(handler, test.target, test.contentType, "", test.body)
+			if recorder.Code != test.status {
+				t.Errorf("status %v, want %v: %s", recorder.Code, test.status, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestLimitsCannotBeRaised(t *testing.T) {
+	flags := aic_package.DefaultFlags()
+	flags.MaxPixels = 100
+
+	recorder := post(New(Config{Flags: flags}), "/convert?width=10&maxPixels=0", "", "", bytes.NewReader(testPNG(t)))
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("status %v, want %v for an option raising the pixel limit", recorder.Code, http.StatusBadRequest)
+	}
+
+	recorder = post(New(Config{Flags: flags}), "/convert?width=10", "", "", bytes.NewReader(testPNG(t)))
+	if recorder.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status %v, want %v for an image over the pixel limit", recorder.Code, http.StatusRequestEntityTooLarge)
+	}
+}
+
+func TestMaxInputBytes(t *testing.T) {
+	image := testPNG(t)
+	oversized := append(append([]byte{}, image...), make([]byte, 1024)...)
+
+	tests := []struct {
+		name          string
+		maxBodyBytes  int64
+		maxInputBytes int64
+		input         []byte
+		multipart     bool
+		status        int
+	}{
+		{"within both limits", 1 << 20, int64(len(image)), image, false, http.StatusOK},
+		{"over the input limit", 1 << 20, int64(len(image)), oversized, false, http.StatusRequestEntityTooLarge},
+		{"multipart over the input limit", 1 << 20, int64(len(image)), oversized, true, http.StatusRequestEntityTooLarge},
+		{"no input limit", 1 << 20, 0, oversized, false, http.StatusOK},
+		{"input limit above the body limit", int64(len(image)), 1 << 20, oversized, false, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			flags := aic_package.DefaultFlags()
+			flags.MaxInputBytes = test.maxInputBytes
+			handler := New(Config{Flags: flags, MaxBodyBytes: test.maxBodyBytes})
+
+			var body io.Reader = bytes.NewReader(test.input)
+			contentType := ""
+			if test.multipart {
+				body, contentType = testForm(t, map[string][]byte{"image": test.input})
+			}
+
+			recorder := post(handler, "/convert?width=10", contentType, "", body)
+			if recorder.Code != test.status {
+				t.Errorf("status %v, want %v: %s", recorder.Code, test.status, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestSlotTimeout(t *testing.T) {
+	s := newServer(Config{Flags: aic_package.DefaultFlags(), MaxConcurrent: 1, Timeout: 20 * time.Millisecond})
+	image := testPNG(t)
+
+	// Take the only slot, as a conversion in progress would
+	s.slots <- struct{}{}
+
+	recorder := post(http.HandlerFunc(s.convert), "/convert?width=10", "", "", bytes.NewReader(image))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status %v, want %v while every slot is taken", recorder.Code, http.StatusServiceUnavailable)
+	}
+
+	<-s.slots
+
+	recorder = post(http.HandlerFunc(s.convert), "/convert?width=10", "", "", bytes.NewReader(image))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status %v once the slot is free: %s", recorder.Code, recorder.Body.String())
+	}
+	if len(s.slots) != 0 {
+		t.Errorf("%v slots still taken after the conversion finished", len(s.slots))
+	}
+}
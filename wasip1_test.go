@@ -0,0 +1,140 @@
+//go:build !js && !wasip1
+
+/*
+Copyright © 2021 Zoraiz Hassan <hzoraiz8@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+var update = flag.Bool("update", false, "Rewrite the golden files of TestWASIP1 with the current output")
+
+// Runs the CLI built for GOOS=wasip1 under wazero, with testdata/gradient.png piped in, and compares
+// its output with the golden files in testdata/wasip1
+func TestWASIP1(t *testing.T) {
+	if testing.Short() {
+		t.Skip("building the wasip1 module is slow")
+	}
+
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not found")
+	}
+
+	modulePath := filepath.Join(t.TempDir(), "aic.wasm")
+
+	build := exec.Command(goTool, "build", "-o", modulePath, ".")
+	build.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm")
+	if output, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("building the wasip1 module: %v\n%s", err, output)
+	}
+
+	moduleBytes, err := os.ReadFile(modulePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	image, err := os.ReadFile(filepath.Join("testdata", "gradient.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	runtime := wazero.NewRuntime(ctx)
+	defer runtime.Close(ctx)
+
+	wasi_snapshot_preview1.MustInstantiate(ctx, runtime)
+
+	compiled, err := runtime.CompileModule(ctx, moduleBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Errors are printed to stdout like the ascii art, so the no-size case compares the error
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"ascii", []string{"-d", "24,8", "-"}},
+		{"complex", []string{"-W", "24", "-c", "-"}},
+		{"color", []string{"-d", "12,4", "-C", "-"}},
+		{"braille", []string{"-W", "16", "-b", "-"}},
+		{"json", []string{"-d", "4,2", "-C", "--json", "-"}},
+		{"info", []string{"info", "-W", "24", "-"}},
+		{"no-size", []string{"-"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+
+			config := wazero.NewModuleConfig().
+				WithName("").
+				WithArgs(append([]string{"ascii-image-converter-wasm"}, test.args...)...).
+				WithStdin(bytes.NewReader(image)).
+				WithStdout(&stdout).
+				WithStderr(&stderr).
+				WithSysWalltime().
+				WithSysNanotime().
+				WithSysNanosleep()
+
+			module, err := runtime.InstantiateModule(ctx, compiled, config)
+
+			var exitErr *sys.ExitError
+			if errors.As(err, &exitErr) {
+				if exitErr.ExitCode() != 0 {
+					t.Fatalf("exit code %v\nstderr: %s", exitErr.ExitCode(), stderr.Bytes())
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			} else {
+				module.Close(ctx)
+			}
+
+			golden := filepath.Join("testdata", "wasip1", test.name+".golden")
+
+			if *update {
+				if err := os.WriteFile(golden, stdout.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v, run go test -run TestWASIP1 -update to create it", err)
+			}
+
+			if !bytes.Equal(stdout.Bytes(), want) {
+				t.Errorf("output doesn't match %v\ngot:\n%s\nwant:\n%s", golden, stdout.Bytes(), want)
+			}
+		})
+	}
+}
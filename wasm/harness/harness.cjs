@@ -0,0 +1,210 @@
+// Headless Node.js harness for the js/wasm build. Run it after building the module:
+//
+//	GOOS=js GOARCH=wasm go build -o aic.wasm .
+//	node wasm/harness/harness.cjs aic.wasm
+//
+// Go's wasm_exec.js is located through `go env GOROOT`, or the WASM_EXEC environment variable.
+
+"use strict";
+
+const assert = require("assert");
+const { execSync } = require("child_process");
+const fs = require("fs");
+const path = require("path");
+const zlib = require("zlib");
+
+function loadWasmExec() {
+	let wasmExec = process.env.WASM_EXEC;
+
+	if (!wasmExec) {
+		const goroot = execSync("go env GOROOT").toString().trim();
+
+		wasmExec = path.join(goroot, "lib", "wasm", "wasm_exec.js");
+		if (!fs.existsSync(wasmExec)) {
+			// Go versions before 1.24
+			wasmExec = path.join(goroot, "misc", "wasm", "wasm_exec.js");
+		}
+	}
+
+	globalThis.crypto ??= require("crypto");
+	require(wasmExec);
+}
+
+// Encodes an RGBA pixel callback as a PNG, so the harness doesn't depend on image files
+function encodePNG(width, height, pixel) {
+	const crcTable = new Int32Array(256).map((_, n) => {
+		let c = n;
+		for (let k = 0; k < 8; k++) {
+			c = c & 1 ? 0xedb88320 ^ (c >>> 1) : c >>> 1;
+		}
+		return c;
+	});
+
+	const crc32 = (buf) => {
+		let c = -1;
+		for (const byte of buf) {
+			c = crcTable[(c ^ byte) & 0xff] ^ (c >>> 8);
+		}
+		return (c ^ -1) >>> 0;
+	};
+
+	const chunk = (type, data) => {
+		const length = Buffer.alloc(4);
+		length.writeUInt32BE(data.length);
+		const body = Buffer.concat([Buffer.from(type, "ascii"), data]);
+		const crc = Buffer.alloc(4);
+		crc.writeUInt32BE(crc32(body));
+		return Buffer.concat([length, body, crc]);
+	};
+
+	const header = Buffer.alloc(13);
+	header.writeUInt32BE(width, 0);
+	header.writeUInt32BE(height, 4);
+	header[8] = 8; // Bit depth
+	header[9] = 6; // RGBA
+
+	const raw = Buffer.alloc((width * 4 + 1) * height);
+	for (let y = 0; y < height; y++) {
+		for (let x = 0; x < width; x++) {
+			Buffer.from(pixel(x, y)).copy(raw, y * (width * 4 + 1) + 1 + x * 4);
+		}
+	}
+
+	return new Uint8Array(Buffer.concat([
+		Buffer.from([0x89, 0x50, 0x4e, 0x47, 0x0d, 0x0a, 0x1a, 0x0a]),
+		chunk("IHDR", header),
+		chunk("IDAT", zlib.deflateSync(raw)),
+		chunk("IEND", Buffer.alloc(0)),
+	]));
+}
+
+const tests = {
+	async "text output has the requested dimensions"(image) {
+		const art = await asciiConvert(image, { dimensions: [20, 8] });
+		const lines = art.split("\n");
+
+		assert.strictEqual(lines.length, 8);
+		lines.forEach((line) => assert.strictEqual(line.length, 20));
+	},
+
+	async "width keeps the aspect ratio"(image) {
+		const art = await asciiConvert(image, { width: 40 });
+		assert.strictEqual(art.split("\n").length, 5);
+
+		const square = await asciiConvert(image, { width: 40, cellWidth: 8, cellHeight: 8 });
+		assert.strictEqual(square.split("\n").length, 10);
+	},
+
+	async "colored text output contains ANSI codes"(image) {
+		const art = await asciiConvert(image, { width: 10, colored: true });
+		assert.match(art, /\x1b\[/);
+	},
+
+	async "json output is a grid of characters"(image) {
+		const grid = await asciiConvert(image, { dimensions: [10, 4], colored: true, output: "json" });
+
+		assert.strictEqual(grid.length, 4);
+		assert.strictEqual(grid[0].length, 10);
+		assert.strictEqual(typeof grid[0][0].char, "string");
+		assert.ok(Array.isArray(grid[0][0].rgb));
+	},
+
+	async "html output is a pre element"(image) {
+		const html = await asciiConvert(image, { width: 10, colored: true, output: "html" });
+		assert.match(html, /^<pre class="ascii-art"><span style="color:rgb\(/);
+	},
+
+	async "png output has a PNG signature"(image) {
+		const png = await asciiConvert(image, { width: 10, output: "png" });
+
+		assert.ok(png instanceof Uint8Array);
+		assert.deepStrictEqual(Array.from(png.slice(0, 4)), [0x89, 0x50, 0x4e, 0x47]);
+	},
+
+	async "braille output uses braille patterns"(image) {
+		const art = await asciiConvert(image, { width: 10, braille: true });
+		assert.match(art, /^[⠀-⣿\n]+$/);
+	},
+
+	async "presets and settings are applied before other options"(image) {
+		const preset = await asciiConvert(image, { width: 10, preset: "retro-green" });
+		assert.match(preset, /\x1b\[38;2;51;255;51m/);
+
+		const settings = JSON.stringify({ version: 1, preset: "retro-green", width: 40 });
+		const art = await asciiConvert(image, { settings, width: 10, fontColor: [255, 0, 0] });
+		assert.strictEqual(art.split("\n")[0].replace(/\x1b\[[0-9;]*m/g, "").length, 10);
+		assert.match(art, /\x1b\[38;2;255;0;0m/);
+
+		const object = await asciiConvert(image, { settings: { version: 1, width: 12 } });
+		assert.strictEqual(object.split("\n")[0].length, 12);
+	},
+
+	async "invalid settings are rejected"(image) {
+		await assert.rejects(asciiConvert(image, { width: 10, preset: "nope" }), /unknown preset "nope"/);
+		await assert.rejects(asciiConvert(image, { settings: { width: 10 } }), /version is missing/);
+		await assert.rejects(asciiConvert(image, { settings: '{"version":1,"widht":10}' }), /unknown field "widht"/);
+	},
+
+	async "invalid option types are rejected"(image) {
+		await assert.rejects(asciiConvert(image, { width: "wide" }), /option "width" must be an integer/);
+		await assert.rejects(asciiConvert(image, { fontColor: [0, 0, 300] }), /RGB values between 0 and 255/);
+	},
+
+	async "unknown options are rejected"(image) {
+		await assert.rejects(asciiConvert(image, { colour: true }), /unknown option "colour"/);
+	},
+
+	async "non-byte input is rejected"() {
+		await assert.rejects(asciiConvert("image.png", { width: 10 }), /must be a Uint8Array/);
+	},
+
+	async "images over the pixel limit are rejected"(image) {
+		await assert.rejects(asciiConvert(image, { width: 10, maxPixels: 100 }), /exceeds the limit of 100 pixels/);
+	},
+
+	async "animated input is rejected"() {
+		// Two frame 2x2 GIF
+		const gif = new Uint8Array(Buffer.from("R0lGODlhAgACAAAAACH/C05FVFNDQVBFMi4wAwEAAAAh+QQACgAAACwAAAAAAgACAIAAAAD///8CAoRRACH5BAAKAAAALAAAAAACAAIAgAAAAP///wICDF4AOw==", "base64"));
+
+		for (const output of ["text", "json", "html", "png"]) {
+			await assert.rejects(asciiConvert(gif, { width: 8, output }), /animated input is not supported/);
+		}
+	},
+
+	async "undecodable input is rejected"() {
+		await assert.rejects(asciiConvert(new Uint8Array([1, 2, 3]), { width: 10 }), Error);
+	},
+};
+
+async function main() {
+	const wasmPath = process.argv[2];
+	if (!wasmPath) {
+		console.error("usage: node harness.cjs <path to wasm module>");
+		process.exit(2);
+	}
+
+	loadWasmExec();
+
+	const go = new Go();
+	const { instance } = await WebAssembly.instantiate(fs.readFileSync(wasmPath), go.importObject);
+	go.run(instance);
+
+	// Horizontal gradient, 4 times wider than tall
+	const image = encodePNG(64, 16, (x) => [x * 4, 255 - x * 4, 128, 255]);
+
+	let failed = 0;
+
+	for (const [name, test] of Object.entries(tests)) {
+		try {
+			await test(image);
+			console.log(`ok    ${name}`);
+		} catch (err) {
+			failed++;
+			console.log(`FAIL  ${name}\n      ${err.message}`);
+		}
+	}
+
+	process.exit(failed === 0 ? 0 : 1);
+}
+
+main();
@@ -0,0 +1,309 @@
+//go:build js && wasm
+
+/*
+Copyright © 2025 Ares Stavropoulos <aresstav04@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wasm
+
+import (
+	"fmt"
+	"math"
+	"syscall/js"
+
+	"github.com/Ares1605/ascii-image-converter-wasm/aic_package"
+	image_conversions "github.com/Ares1605/ascii-image-converter-wasm/image_manipulation"
+)
+
+// Maps a JavaScript option name onto its aic_package.Flags field
+var optionSetters = map[string]func(flags *aic_package.Flags, value js.Value) error{
+	"dimensions": func(flags *aic_package.Flags, value js.Value) (err error) {
+		flags.Dimensions, err = toIntSlice(value, 2)
+		return
+	},
+	"width": func(flags *aic_package.Flags, value js.Value) (err error) {
+		flags.Width, err = toInt(value)
+		return
+	},
+	"height": func(flags *aic_package.Flags, value js.Value) (err error) {
+		flags.Height, err = toInt(value)
+		return
+	},
+	"fitMode": func(flags *aic_package.Flags, value js.Value) error {
+		mode, err := toString(value)
+		flags.FitMode = image_conversions.FitMode(mode)
+		return err
+	},
+	"complex": func(flags *aic_package.Flags, value js.Value) (err error) {
+		flags.Complex, err = toBool(value)
+		return
+	},
+	"negative": func(flags *aic_package.Flags, value js.Value) (err error) {
+		flags.Negative, err = toBool(value)
+		return
+	},
+	"colored": func(flags *aic_package.Flags, value js.Value) (err error) {
+		flags.Colored, err = toBool(value)
+		return
+	},
+	"charBackgroundColor": func(flags *aic_package.Flags, value js.Value) (err error) {
+		flags.CharBackgroundColor, err = toBool(value)
+		return
+	},
+	"grayscale": func(flags *aic_package.Flags, value js.Value) (err error) {
+		flags.Grayscale, err = toBool(value)
+		return
+	},
+	"customMap": func(flags *aic_package.Flags, value js.Value) (err error) {
+		flags.CustomMap, err = toString(value)
+		return
+	},
+	"flipX": func(flags *aic_package.Flags, value js.Value) (err error) {
+		flags.FlipX, err = toBool(value)
+		return
+	},
+	"flipY": func(flags *aic_package.Flags, value js.Value) (err error) {
+		flags.FlipY, err = toBool(value)
+		return
+	},
+	"fontColor": func(flags *aic_package.Flags, value js.Value) error {
+		rgb, err := toRGB(value)
+		flags.FontColor = rgb
+		return err
+	},
+	"braille": func(flags *aic_package.Flags, value js.Value) (err error) {
+		flags.Braille, err = toBool(value)
+		return
+	},
+	"threshold": func(flags *aic_package.Flags, value js.Value) (err error) {
+		flags.Threshold, err = toInt(value)
+		return
+	},
+	"dither": func(flags *aic_package.Flags, value js.Value) (err error) {
+		flags.Dither, err = toBool(value)
+		return
+	},
+	"colorLevel": func(flags *aic_package.Flags, value js.Value) error {
+		level, err := toInt(value)
+		if err != nil {
+			return err
+		}
+		if level != int(image_conversions.Millions) && level != int(image_conversions.Hundreds) {
+			return fmt.Errorf("must be %v or %v", image_conversions.Hundreds, image_conversions.Millions)
+		}
+		flags.ColorLevel = image_conversions.ColorLevel(level)
+		return nil
+	},
+	"brightness": func(flags *aic_package.Flags, value js.Value) (err error) {
+		flags.Brightness, err = toFloat(value)
+		return
+	},
+	"contrast": func(flags *aic_package.Flags, value js.Value) (err error) {
+		flags.Contrast, err = toFloat(value)
+		return
+	},
+	"gamma": func(flags *aic_package.Flags, value js.Value) (err error) {
+		flags.Gamma, err = toFloat(value)
+		return
+	},
+	"autoLevels": func(flags *aic_package.Flags, value js.Value) (err error) {
+		flags.AutoLevels, err = toBool(value)
+		return
+	},
+	"equalize": func(flags *aic_package.Flags, value js.Value) (err error) {
+		flags.Equalize, err = toBool(value)
+		return
+	},
+	"crop": func(flags *aic_package.Flags, value js.Value) (err error) {
+		flags.Crop, err = toFloatSlice(value, 4)
+		return
+	},
+	"cropPercent": func(flags *aic_package.Flags, value js.Value) (err error) {
+		flags.CropPercent, err = toBool(value)
+		return
+	},
+	"rotate": func(flags *aic_package.Flags, value js.Value) (err error) {
+		flags.Rotate, err = toFloat(value)
+		return
+	},
+	"rotateBackground": func(flags *aic_package.Flags, value js.Value) error {
+		rgb, err := toRGB(value)
+		flags.RotateBackground = rgb
+		return err
+	},
+	"ignoreExif": func(flags *aic_package.Flags, value js.Value) (err error) {
+		flags.IgnoreExif, err = toBool(value)
+		return
+	},
+	"alphaBackground": func(flags *aic_package.Flags, value js.Value) error {
+		rgb, err := toRGB(value)
+		flags.AlphaBackground = rgb[:]
+		return err
+	},
+	"transparentEmpty": func(flags *aic_package.Flags, value js.Value) (err error) {
+		flags.TransparentEmpty, err = toBool(value)
+		return
+	},
+	"resampleFilter": func(flags *aic_package.Flags, value js.Value) error {
+		filter, err := toString(value)
+		flags.ResampleFilter = image_conversions.ResampleFilter(filter)
+		return err
+	},
+	"cellAspect": func(flags *aic_package.Flags, value js.Value) (err error) {
+		flags.CellAspect, err = toFloat(value)
+		return
+	},
+	"cellWidth": func(flags *aic_package.Flags, value js.Value) (err error) {
+		flags.CellWidth, err = toFloat(value)
+		return
+	},
+	"cellHeight": func(flags *aic_package.Flags, value js.Value) (err error) {
+		flags.CellHeight, err = toFloat(value)
+		return
+	},
+	"maxPixels": func(flags *aic_package.Flags, value js.Value) (err error) {
+		flags.MaxPixels, err = toInt(value)
+		return
+	},
+}
+
+// Applies the preset and settings options to flags, ahead of the other options
+func applySettings(flags aic_package.Flags, options js.Value) (aic_package.Flags, error) {
+
+	if value := options.Get("preset"); !value.IsUndefined() {
+		name, err := toString(value)
+		if err != nil {
+			return flags, fmt.Errorf("option \"preset\" %v", err)
+		}
+
+		preset, err := aic_package.Preset(name)
+		if err != nil {
+			return flags, err
+		}
+
+		if flags, err = preset.Apply(flags); err != nil {
+			return flags, err
+		}
+	}
+
+	if value := options.Get("settings"); !value.IsUndefined() {
+		// Objects are serialized, so they're parsed the same way as settings passed as JSON
+		if value.Type() == js.TypeObject {
+			value = js.Global().Get("JSON").Call("stringify", value)
+		} else if value.Type() != js.TypeString {
+			return flags, fmt.Errorf("option \"settings\" must be a JSON string or an object")
+		}
+
+		settings, err := aic_package.ParseSettings([]byte(value.String()))
+		if err != nil {
+			return flags, err
+		}
+
+		if flags, err = settings.Apply(flags); err != nil {
+			return flags, err
+		}
+	}
+
+	return flags, nil
+}
+
+func setOption(flags *aic_package.Flags, key string, value js.Value) error {
+
+	setter, ok := optionSetters[key]
+	if !ok {
+		return fmt.Errorf("unknown option %q", key)
+	}
+
+	if err := setter(flags, value); err != nil {
+		return fmt.Errorf("option %q %v", key, err)
+	}
+
+	return nil
+}
+
+func toBool(value js.Value) (bool, error) {
+	if value.Type() != js.TypeBoolean {
+		return false, fmt.Errorf("must be a boolean")
+	}
+	return value.Bool(), nil
+}
+
+func toString(value js.Value) (string, error) {
+	if value.Type() != js.TypeString {
+		return "", fmt.Errorf("must be a string")
+	}
+	return value.String(), nil
+}
+
+func toFloat(value js.Value) (float64, error) {
+	if value.Type() != js.TypeNumber || math.IsNaN(value.Float()) || math.IsInf(value.Float(), 0) {
+		return 0, fmt.Errorf("must be a finite number")
+	}
+	return value.Float(), nil
+}
+
+func toInt(value js.Value) (int, error) {
+	number, err := toFloat(value)
+	if err != nil || number != math.Trunc(number) {
+		return 0, fmt.Errorf("must be an integer")
+	}
+	return int(number), nil
+}
+
+func toFloatSlice(value js.Value, length int) ([]float64, error) {
+	if !value.InstanceOf(js.Global().Get("Array")) || value.Length() != length {
+		return nil, fmt.Errorf("must be an array of %v numbers", length)
+	}
+
+	numbers := make([]float64, length)
+	for i := range numbers {
+		number, err := toFloat(value.Index(i))
+		if err != nil {
+			return nil, fmt.Errorf("must be an array of %v numbers", length)
+		}
+		numbers[i] = number
+	}
+	return numbers, nil
+}
+
+func toIntSlice(value js.Value, length int) ([]int, error) {
+	numbers, err := toFloatSlice(value, length)
+	if err != nil {
+		return nil, fmt.Errorf("must be an array of %v integers", length)
+	}
+
+	integers := make([]int, length)
+	for i, number := range numbers {
+		if number != math.Trunc(number) {
+			return nil, fmt.Errorf("must be an array of %v integers", length)
+		}
+		integers[i] = int(number)
+	}
+	return integers, nil
+}
+
+func toRGB(value js.Value) ([3]int, error) {
+	integers, err := toIntSlice(value, 3)
+	if err != nil {
+		return [3]int{}, fmt.Errorf("must be an array of 3 RGB values")
+	}
+
+	for _, integer := range integers {
+		if integer < 0 || integer > 255 {
+			return [3]int{}, fmt.Errorf("must have RGB values between 0 and 255")
+		}
+	}
+	return [3]int{integers[0], integers[1], integers[2]}, nil
+}
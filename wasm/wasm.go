@@ -0,0 +1,186 @@
+//go:build js && wasm
+
+/*
+Copyright © 2025 Ares Stavropoulos <aresstav04@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package wasm exposes the converter to JavaScript when built with GOOS=js GOARCH=wasm.
+
+Register() defines a global asciiConvert(uint8Array, options) function, which returns a
+Promise resolving to the ascii art. The format of the result depends on options.output:
+
+	"text" (default)  string, with ANSI color codes if a color option is set
+	"json"            array of rows, each an array of { char, rgb } objects
+	"html"            string of a <pre> element with styled spans
+	"png"             Uint8Array of PNG encoded bytes
+
+All other options map onto aic_package.Flags, starting from aic_package.DefaultFlags(). options.preset
+names a built-in preset, and options.settings holds aic_package.Settings, as a JSON string or an object.
+Both are applied before the other options, which override them.
+Invalid options, animated input and conversion errors reject the Promise with an Error.
+*/
+package wasm
+
+import (
+	"encoding/json"
+	"fmt"
+	"syscall/js"
+
+	"github.com/Ares1605/ascii-image-converter-wasm/aic_package"
+	"github.com/Ares1605/ascii-image-converter-wasm/image_formats"
+	gookitColor "github.com/gookit/color"
+)
+
+// Register defines the asciiConvert function on the JavaScript global object
+func Register() {
+	// There's no terminal to detect color support from, and callers decide how colors are displayed
+	gookitColor.ForceColor()
+
+	js.Global().Set("asciiConvert", js.FuncOf(asciiConvert))
+}
+
+func asciiConvert(this js.Value, args []js.Value) any {
+
+	inputBytes, output, flags, err := parseArgs(args)
+	if err != nil {
+		return rejected(err)
+	}
+
+	executor := js.FuncOf(func(this js.Value, promiseArgs []js.Value) any {
+		resolve, reject := promiseArgs[0], promiseArgs[1]
+
+		// Converting takes a while and must not block the JavaScript event loop
+		go func() {
+			result, err := convert(inputBytes, output, flags)
+			if err != nil {
+				reject.Invoke(jsError(err))
+				return
+			}
+			resolve.Invoke(result)
+		}()
+
+		return nil
+	})
+	// The executor is called synchronously by the Promise constructor
+	defer executor.Release()
+
+	return js.Global().Get("Promise").New(executor)
+}
+
+func parseArgs(args []js.Value) ([]byte, string, aic_package.Flags, error) {
+
+	flags := aic_package.DefaultFlags()
+
+	if len(args) < 1 || args[0].Type() != js.TypeObject || !args[0].InstanceOf(js.Global().Get("Uint8Array")) {
+		return nil, "", flags, fmt.Errorf("asciiConvert: first argument must be a Uint8Array of image bytes")
+	}
+
+	inputBytes := make([]byte, args[0].Get("length").Int())
+	js.CopyBytesToGo(inputBytes, args[0])
+
+	output := "text"
+
+	if len(args) < 2 || args[1].IsUndefined() || args[1].IsNull() {
+		return inputBytes, output, flags, nil
+	}
+
+	if args[1].Type() != js.TypeObject {
+		return nil, "", flags, fmt.Errorf("asciiConvert: options must be an object")
+	}
+
+	keys := js.Global().Get("Object").Call("keys", args[1])
+
+	// Presets and settings are applied first, so the other options override them
+	flags, err := applySettings(flags, args[1])
+	if err != nil {
+		return nil, "", flags, fmt.Errorf("asciiConvert: %v", err)
+	}
+
+	for i := 0; i < keys.Length(); i++ {
+		key := keys.Index(i).String()
+		value := args[1].Get(key)
+
+		if key == "preset" || key == "settings" {
+			continue
+		}
+
+		if key == "output" {
+			if value.Type() != js.TypeString {
+				return nil, "", flags, fmt.Errorf("asciiConvert: option %q must be a string", key)
+			}
+			output = value.String()
+
+			if output != "text" && output != "json" && output != "html" && output != "png" {
+				return nil, "", flags, fmt.Errorf("asciiConvert: option \"output\" must be one of text, json, html or png, got %q", output)
+			}
+			continue
+		}
+
+		if err := setOption(&flags, key, value); err != nil {
+			return nil, "", flags, fmt.Errorf("asciiConvert: %v", err)
+		}
+	}
+
+	return inputBytes, output, flags, nil
+}
+
+func convert(inputBytes []byte, output string, flags aic_package.Flags) (any, error) {
+
+	// Convert() would play animations on stdout, which is the console here
+	if image_formats.IsAnimated(inputBytes) {
+		return nil, fmt.Errorf("%w by the WASM module", aic_package.ErrAnimationUnsupported)
+	}
+
+	switch output {
+	case "json":
+		asciiArt, err := aic_package.ConvertJSON(inputBytes, flags)
+		if err != nil {
+			return nil, err
+		}
+
+		marshalled, err := json.Marshal(asciiArt)
+		if err != nil {
+			return nil, err
+		}
+
+		return js.Global().Get("JSON").Call("parse", string(marshalled)), nil
+
+	case "html":
+		return aic_package.ConvertHTML(inputBytes, flags)
+
+	case "png":
+		pngBytes, err := aic_package.ConvertPNG(inputBytes, flags)
+		if err != nil {
+			return nil, err
+		}
+
+		array := js.Global().Get("Uint8Array").New(len(pngBytes))
+		js.CopyBytesToJS(array, pngBytes)
+
+		return array, nil
+
+	default:
+		return aic_package.Convert(inputBytes, flags)
+	}
+}
+
+func jsError(err error) js.Value {
+	return js.Global().Get("Error").New(err.Error())
+}
+
+func rejected(err error) js.Value {
+	return js.Global().Get("Promise").Call("reject", jsError(err))
+}